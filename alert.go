@@ -0,0 +1,311 @@
+package loggo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Alert is an incident raised by an AlertSink.
+type Alert struct {
+	DedupKey string            // Identifies the incident; repeated alerts with the same key are merged
+	Summary  string            // The log message
+	Level    Level             // Level of the triggering entry
+	Severity string            // Provider severity from AlertConfig.Severity, or "" for the provider default
+	Source   string            // Host or service raising the alert
+	Time     time.Time         // Time of the triggering entry
+	Details  map[string]string // The entry's fields
+}
+
+// AlertProvider creates incidents in a paging system.
+type AlertProvider interface {
+	Trigger(ctx context.Context, alert *Alert) error
+}
+
+// PagerDuty triggers incidents through the PagerDuty Events API v2.
+type PagerDuty struct {
+	RoutingKey string       // Integration key of the service
+	URL        string       // Events endpoint (default "https://events.pagerduty.com/v2/enqueue")
+	Client     *http.Client // HTTP client (default http.DefaultClient)
+}
+
+// pagerDutySeverities maps levels to PagerDuty severities
+var pagerDutySeverities = map[Level]string{
+	DEBUG:    "info",
+	INFO:     "info",
+	WARN:     "warning",
+	ERROR:    "error",
+	CRITICAL: "critical",
+	FATAL:    "critical",
+	PANIC:    "critical",
+}
+
+// Trigger sends a trigger event for the alert.
+func (p *PagerDuty) Trigger(ctx context.Context, alert *Alert) error {
+	severity := alert.Severity
+	if severity == "" {
+		severity = pagerDutySeverities[alert.Level]
+	}
+	body := map[string]any{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    alert.DedupKey,
+		"payload": map[string]any{
+			"summary":        truncate(alert.Summary, 1024),
+			"source":         alert.Source,
+			"severity":       severity,
+			"timestamp":      alert.Time.Format(time.RFC3339Nano),
+			"custom_details": alert.Details,
+		},
+	}
+	url := p.URL
+	if url == "" {
+		url = "https://events.pagerduty.com/v2/enqueue"
+	}
+	return postAlert(ctx, p.Client, url, nil, body)
+}
+
+// Opsgenie creates alerts through the Opsgenie Alert API.
+type Opsgenie struct {
+	APIKey string       // API integration key
+	URL    string       // Alerts endpoint (default "https://api.opsgenie.com/v2/alerts"; use api.eu.opsgenie.com for EU accounts)
+	Client *http.Client // HTTP client (default http.DefaultClient)
+}
+
+// opsgeniePriorities maps levels to Opsgenie priorities
+var opsgeniePriorities = map[Level]string{
+	DEBUG:    "P5",
+	INFO:     "P5",
+	WARN:     "P4",
+	ERROR:    "P3",
+	CRITICAL: "P2",
+	FATAL:    "P1",
+	PANIC:    "P1",
+}
+
+// Trigger creates an alert, using the dedup key as its alias.
+func (o *Opsgenie) Trigger(ctx context.Context, alert *Alert) error {
+	priority := alert.Severity
+	if priority == "" {
+		priority = opsgeniePriorities[alert.Level]
+	}
+	body := map[string]any{
+		"message":     truncate(alert.Summary, 130),
+		"alias":       alert.DedupKey,
+		"description": alert.Summary,
+		"priority":    priority,
+		"source":      alert.Source,
+		"details":     alert.Details,
+	}
+	url := o.URL
+	if url == "" {
+		url = "https://api.opsgenie.com/v2/alerts"
+	}
+	return postAlert(ctx, o.Client, url, map[string]string{"Authorization": "GenieKey " + o.APIKey}, body)
+}
+
+// postAlert POSTs body as JSON and fails on non-2xx responses
+func postAlert(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("loggo: alert provider responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xc0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// AlertConfig configures an AlertSink. Zero values select the defaults.
+type AlertConfig struct {
+	Provider   AlertProvider    // Paging system receiving the alerts
+	MinLevel   Level            // Lowest level that raises an alert (default CRITICAL)
+	DedupField string           // Field used as the dedup key; without it, the level and fingerprint are used
+	Severity   map[Level]string // Overrides the provider's severity for a level
+	Source     string           // Alert source (default: the hostname)
+	Suppress   time.Duration    // Do not resend an alert with the same key within this period (default 5m)
+	RateLimit  int              // Maximum alerts per RateWindow (default 10)
+	RateWindow time.Duration    // Window for RateLimit (default 1m)
+	Timeout    time.Duration    // Timeout for a single provider call (default 10s)
+	OnError    func(error)      // Called when the provider rejects an alert
+}
+
+// AlertStats reports the activity of an AlertSink.
+type AlertStats struct {
+	Sent         int64 // Alerts delivered to the provider
+	Deduplicated int64 // Alerts suppressed because their key was recently sent
+	RateLimited  int64 // Alerts dropped by the rate limit
+	Errors       int64 // Alerts the provider failed to accept
+}
+
+// AlertSink raises deduplicated, rate-limited incidents for severe
+// entries. Alerts are delivered in the background so paging never blocks
+// logging; Close waits for deliveries in flight. It can also be used as a
+// hook via Hook, in which case only the message is available for the
+// dedup key.
+type AlertSink struct {
+	config AlertConfig
+	mu     sync.Mutex
+	recent map[string]time.Time // Last send time per dedup key
+	sends  []time.Time          // Send times within the rate window
+	stats  AlertStats
+	wg     sync.WaitGroup
+}
+
+// NewAlertSink creates an alert sink for the configured provider.
+func NewAlertSink(config AlertConfig) *AlertSink {
+	if config.MinLevel == DEBUG {
+		config.MinLevel = CRITICAL
+	}
+	if config.Source == "" {
+		config.Source, _ = os.Hostname()
+	}
+	if config.Suppress <= 0 {
+		config.Suppress = 5 * time.Minute
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 10
+	}
+	if config.RateWindow <= 0 {
+		config.RateWindow = time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &AlertSink{config: config, recent: make(map[string]time.Time)}
+}
+
+// WriteEntry raises an alert for entries at or above MinLevel unless it
+// is suppressed as a duplicate or by the rate limit.
+func (a *AlertSink) WriteEntry(entry *Entry) error {
+	if entry.Level < a.config.MinLevel {
+		return nil
+	}
+	key := a.dedupKey(entry)
+	now := time.Now()
+
+	a.mu.Lock()
+	if last, ok := a.recent[key]; ok && now.Sub(last) < a.config.Suppress {
+		a.stats.Deduplicated++
+		a.mu.Unlock()
+		return nil
+	}
+	cutoff := now.Add(-a.config.RateWindow)
+	for len(a.sends) > 0 && a.sends[0].Before(cutoff) {
+		a.sends = a.sends[1:]
+	}
+	if len(a.sends) >= a.config.RateLimit {
+		a.stats.RateLimited++
+		a.mu.Unlock()
+		return nil
+	}
+	a.sends = append(a.sends, now)
+	if len(a.recent) >= 1024 {
+		for k, t := range a.recent {
+			if now.Sub(t) >= a.config.Suppress {
+				delete(a.recent, k)
+			}
+		}
+	}
+	a.recent[key] = now
+	a.mu.Unlock()
+
+	alert := &Alert{
+		DedupKey: key,
+		Summary:  entry.Message,
+		Level:    entry.Level,
+		Severity: a.config.Severity[entry.Level],
+		Source:   a.config.Source,
+		Time:     entry.Time,
+	}
+	if len(entry.Fields) > 0 {
+		alert.Details = make(map[string]string, len(entry.Fields))
+		for _, f := range entry.Fields {
+			alert.Details[f.Key] = f.ValueString()
+		}
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+		defer cancel()
+		err := a.config.Provider.Trigger(ctx, alert)
+
+		a.mu.Lock()
+		if err != nil {
+			a.stats.Errors++
+			// Allow the next occurrence to retry
+			delete(a.recent, key)
+		} else {
+			a.stats.Sent++
+		}
+		a.mu.Unlock()
+		if err != nil && a.config.OnError != nil {
+			a.config.OnError(err)
+		}
+	}()
+	return nil
+}
+
+// dedupKey returns the configured field value or the entry's fingerprint
+func (a *AlertSink) dedupKey(entry *Entry) string {
+	if a.config.DedupField != "" {
+		if f, ok := entry.Field(a.config.DedupField); ok {
+			return f.ValueString()
+		}
+	}
+	return "loggo-" + entry.Level.String() + "-" + entryFingerprint(entry)
+}
+
+// Hook adapts the sink to the hook signature, for use with AddHook.
+func (a *AlertSink) Hook(level Level, msg string) error {
+	return a.WriteEntry(&Entry{Time: time.Now(), Level: level, Message: msg})
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (a *AlertSink) Stats() AlertStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stats
+}
+
+// Close waits for alerts in flight to be delivered.
+func (a *AlertSink) Close() error {
+	a.wg.Wait()
+	return nil
+}
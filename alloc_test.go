@@ -0,0 +1,217 @@
+package loggo
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Allocation ceilings of the core logging paths. Lower them when an
+// optimization removes allocations; a change that needs to raise them
+// should explain why in its commit.
+const (
+	maxAllocsInfo         = 6 // logger.Info("message")
+	maxAllocsInfoArg      = 6 // logger.Infof("message %d", n)
+	maxAllocsEvent5Fields = 7 // Event carrying five fields
+	maxAllocsTypedFields  = 5 // Event with four fields added by typed methods
+	maxAllocsFiltered     = 0 // Record below the logger's level
+	maxAllocsSinks        = 3 // Event carrying five fields, delivered to three sinks, two of them wrapped
+	maxAllocsHooks        = 3 // logger.Info("message") with a synchronous and an asynchronous hook
+	maxAllocsFormatter    = 3 // Event carrying five fields and environment fields, laid out by JSONFormatter
+)
+
+// assertMaxAllocs fails the test if fn allocates more than max times per
+// call on average
+func assertMaxAllocs(t *testing.T, name string, max float64, fn func()) {
+	t.Helper()
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful with the race detector")
+	}
+	if arenaEvents {
+		t.Skip("the ceilings apply to the default build, not to arena events")
+	}
+	if allocs := testing.AllocsPerRun(1000, fn); allocs > max {
+		t.Errorf("Expected at most %v allocations for %s, got %v", max, name, allocs)
+	}
+}
+
+// newAllocLogger returns a logger writing text to io.Discard
+func newAllocLogger() *Logger {
+	logger := New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// withFiveFields returns a logger binding five fields of different types
+func withFiveFields(logger *Logger) *Logger {
+	return logger.With("user", "alice").With("id", 42).With("ok", true).With("ratio", 0.5).With("region", "eu-west-1")
+}
+
+func TestAllocations(t *testing.T) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	fields := withFiveFields(logger)
+
+	assertMaxAllocs(t, "Info", maxAllocsInfo, func() { logger.Info("request handled") })
+	assertMaxAllocs(t, "Infof with one argument", maxAllocsInfoArg, func() { logger.Infof("request %d handled", 42) })
+	assertMaxAllocs(t, "event with five fields", maxAllocsEvent5Fields, func() { fields.InfoEvent().Msg("request handled") })
+	assertMaxAllocs(t, "filtered Debug", maxAllocsFiltered, func() { logger.Debug("not written") })
+	assertMaxAllocs(t, "event with typed fields", maxAllocsTypedFields, func() {
+		logger.InfoEvent().Str("user", "alice").Int("id", 42).Bool("ok", true).Float64("ratio", 0.5).Msg("request handled")
+	})
+
+	// Entries are pooled through the sinks and the wrappers' copies
+	sinks := newAllocLogger()
+	defer sinks.Close()
+	sinks.AddSink(discardSink{})
+	sinks.AddSink(NewMappingSink(discardSink{}, FieldMapping{Rename: map[string]string{"user": "name"}}))
+	sinks.AddSink(NewEscapeSink(discardSink{}, EscapeControl))
+	sinkFields := withFiveFields(sinks)
+	assertMaxAllocs(t, "event delivered to sinks", maxAllocsSinks, func() { sinkFields.InfoEvent().Msg("request handled") })
+
+	// Text output with hooks, and lines laid out by a formatter
+	hooks := newAllocLogger()
+	defer hooks.Close()
+	hook := func(Level, string) error { return nil }
+	hooks.AddHookSync(hook, 1)
+	hooks.AddHook(func(Level, string) error { return nil }, 0)
+	assertMaxAllocs(t, "Info with hooks", maxAllocsHooks, func() { hooks.Info("request handled") })
+
+	formatted := newAllocLogger()
+	defer formatted.Close()
+	formatted.SetFormatter(JSONFormatter{})
+	formatted.SetRuntimeEnvironment(true)
+	formattedFields := withFiveFields(formatted)
+	assertMaxAllocs(t, "event laid out by a formatter", maxAllocsFormatter, func() { formattedFields.InfoEvent().Msg("request handled") })
+}
+
+func BenchmarkInfo(b *testing.B) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Info("request handled")
+	}
+}
+
+func BenchmarkInfoArg(b *testing.B) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Infof("request %d handled", 42)
+	}
+}
+
+func BenchmarkEvent5Fields(b *testing.B) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	fields := withFiveFields(logger)
+	b.ReportAllocs()
+	for b.Loop() {
+		fields.InfoEvent().Msg("request handled")
+	}
+}
+
+func BenchmarkFiltered(b *testing.B) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Debug("not written")
+	}
+}
+
+// BenchmarkFormattedTimeParallel measures the cached timestamp path
+func BenchmarkFormattedTimeParallel(b *testing.B) {
+	logger := newAllocLogger()
+	defer logger.Close()
+	logger.SetTimeFormat(time.DateTime)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.getFormattedTime(time.Now())
+		}
+	})
+}
+
+// BenchmarkEventAllocation compares the ways of allocating an event with
+// five fields: on the heap, from a sync.Pool, and with allocEvent, which
+// uses an arena when built with GOEXPERIMENT=arenas and -tags loggo_arena
+func BenchmarkEventAllocation(b *testing.B) {
+	fill := func(e *Event) {
+		e.fields = append(e.fields, Str("a", "x"), Int("b", 1), Bool("c", true), Float64("d", 0.5), Str("e", "y"))
+	}
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			e := &Event{}
+			fill(e)
+		}
+	})
+	b.Run("sync.Pool", func(b *testing.B) {
+		pool := sync.Pool{New: func() any { return &Event{fields: make([]Field, 0, 8)} }}
+		b.ReportAllocs()
+		for b.Loop() {
+			e := pool.Get().(*Event)
+			fill(e)
+			e.fields = e.fields[:0]
+			pool.Put(e)
+		}
+	})
+	b.Run("allocEvent", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			e := allocEvent()
+			fill(e)
+			freeEvent(e)
+		}
+	})
+}
+
+// BenchmarkAppendInt compares appendInt with strconv.AppendInt for small
+// and large values
+func BenchmarkAppendInt(b *testing.B) {
+	for _, v := range []int64{7, 42, 200, 1714572187123} {
+		b.Run("fast/"+strconv.FormatInt(v, 10), func(b *testing.B) {
+			buf := make([]byte, 0, 32)
+			for b.Loop() {
+				buf = appendInt(buf[:0], v)
+			}
+		})
+		b.Run("strconv/"+strconv.FormatInt(v, 10), func(b *testing.B) {
+			buf := make([]byte, 0, 32)
+			for b.Loop() {
+				buf = strconv.AppendInt(buf[:0], v, 10)
+			}
+		})
+	}
+}
+
+// BenchmarkAppendTime compares appendTime with time.AppendFormat for each
+// layout it writes itself
+func BenchmarkAppendTime(b *testing.B) {
+	now := time.Date(2024, 5, 1, 14, 3, 7, 123456789, time.UTC)
+	layouts := map[string]string{
+		"Default":  DefaultTimeFormat,
+		"DateTime": time.DateTime,
+		"ISO8601":  iso8601Layout,
+	}
+	for _, name := range sortedKeys(layouts) {
+		layout := layouts[name]
+		b.Run("fast/"+name, func(b *testing.B) {
+			buf := make([]byte, 0, 64)
+			for b.Loop() {
+				buf = appendTime(buf[:0], now, layout)
+			}
+		})
+		b.Run("AppendFormat/"+name, func(b *testing.B) {
+			buf := make([]byte, 0, 64)
+			for b.Loop() {
+				buf = now.AppendFormat(buf[:0], layout)
+			}
+		})
+	}
+}
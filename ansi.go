@@ -0,0 +1,120 @@
+package loggo
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ansiState is the position of the ANSI parser within an escape sequence
+type ansiState uint8
+
+const (
+	ansiText      ansiState = iota // Outside escape sequences
+	ansiEscape                     // After ESC
+	ansiCSI                        // In a control sequence (ESC [)
+	ansiString                     // In a string sequence (ESC ], P, X, ^ or _) ended by BEL or ST
+	ansiStringEsc                  // After ESC within a string sequence
+)
+
+// appendStripANSI appends p to buf without ANSI escape sequences,
+// starting in state and returning the state at the end of p. buf may
+// alias p, since bytes are never written ahead of where they are read.
+func appendStripANSI(buf, p []byte, state ansiState) ([]byte, ansiState) {
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch state {
+		case ansiText:
+			if c == '\033' {
+				state = ansiEscape
+			} else {
+				buf = append(buf, c)
+			}
+		case ansiEscape:
+			switch {
+			case c == '[':
+				state = ansiCSI
+			case c == ']' || c == 'P' || c == 'X' || c == '^' || c == '_':
+				state = ansiString
+			case c >= 0x20 && c <= 0x2f:
+				// Intermediate byte of a multi-byte escape
+			case c >= 0x30 && c <= 0x7e:
+				state = ansiText
+			default:
+				// Not an escape sequence: drop the ESC only
+				state = ansiText
+				i--
+			}
+		case ansiCSI:
+			if c >= 0x40 && c <= 0x7e {
+				state = ansiText
+			}
+		case ansiString:
+			if c == '\a' {
+				state = ansiText
+			} else if c == '\033' {
+				state = ansiStringEsc
+			}
+		case ansiStringEsc:
+			if c == '\\' {
+				state = ansiText
+			} else {
+				state = ansiString
+			}
+		}
+	}
+	return buf, state
+}
+
+// StripANSI returns s without ANSI escape sequences (colors, cursor
+// movement, terminal titles and hyperlinks). Strings without an ESC
+// character are returned unchanged without allocating.
+func StripANSI(s string) string {
+	if strings.IndexByte(s, '\033') < 0 {
+		return s
+	}
+	buf, _ := appendStripANSI(make([]byte, 0, len(s)), []byte(s), ansiText)
+	return string(buf)
+}
+
+// stripANSIInPlace removes escape sequences from buf, reusing its storage
+func stripANSIInPlace(buf []byte) []byte {
+	if bytes.IndexByte(buf, '\033') < 0 {
+		return buf
+	}
+	buf, _ = appendStripANSI(buf[:0], buf, ansiText)
+	return buf
+}
+
+// StripANSIWriter removes ANSI escape sequences from everything written
+// through it, for sending colored output to files and parsers. Sequences
+// split across writes are handled. It is safe for concurrent use.
+type StripANSIWriter struct {
+	w     io.Writer
+	mu    sync.Mutex
+	state ansiState
+	buf   []byte
+}
+
+// NewStripANSIWriter returns a writer that writes to w without escape sequences.
+func NewStripANSIWriter(w io.Writer) *StripANSIWriter {
+	return &StripANSIWriter{w: w}
+}
+
+// Write writes p without escape sequences. It reports len(p) on success,
+// as the stripped bytes count as written.
+func (s *StripANSIWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == ansiText && bytes.IndexByte(p, '\033') < 0 {
+		return s.w.Write(p)
+	}
+	s.buf, s.state = appendStripANSI(s.buf[:0], p, s.state)
+	if len(s.buf) > 0 {
+		if _, err := s.w.Write(s.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
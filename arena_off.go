@@ -0,0 +1,17 @@
+//go:build !(goexperiment.arenas && loggo_arena)
+
+package loggo
+
+// arenaEvents reports whether events are allocated in arenas
+const arenaEvents = false
+
+// eventArena is unused without the loggo_arena build tag
+type eventArena struct{}
+
+// allocEvent allocates an event on the heap
+func allocEvent() *Event {
+	return &Event{}
+}
+
+// freeEvent leaves the event to the garbage collector
+func freeEvent(*Event) {}
@@ -0,0 +1,31 @@
+//go:build goexperiment.arenas && loggo_arena
+
+package loggo
+
+import "arena"
+
+// arenaEvents reports whether events are allocated in arenas
+const arenaEvents = true
+
+// eventArena holds the memory of one event
+type eventArena = arena.Arena
+
+// eventFieldsCap is the capacity of the field slice allocated with an event
+const eventFieldsCap = 8
+
+// allocEvent allocates an event and room for its fields in a new arena
+func allocEvent() *Event {
+	a := arena.NewArena()
+	e := arena.New[Event](a)
+	e.arena = a
+	e.fields = arena.MakeSlice[Field](a, 0, eventFieldsCap)
+	return e
+}
+
+// freeEvent frees the event's arena; the event must not be used afterwards
+func freeEvent(e *Event) {
+	if a := e.arena; a != nil {
+		e.arena = nil
+		a.Free()
+	}
+}
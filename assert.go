@@ -0,0 +1,45 @@
+package loggo
+
+import "runtime"
+
+// Keys of the fields added by AssertTrue.
+const (
+	AssertionKey      = "assertion"       // true on records of violated invariants
+	AssertionStackKey = "assertion.stack" // Stack of the failed check, one "function file:line" frame per line
+)
+
+// AssertTrue checks an invariant: when cond is false, it logs msg and
+// fields at CRITICAL with AssertionKey set and the stack of the check, so
+// "this should never happen" cases share one shape and can be found on
+// dashboards:
+//
+//	if !logger.AssertTrue(balance >= 0, "negative balance", loggo.Int64("balance", balance)) {
+//		return errInvalidState
+//	}
+//
+// It returns cond. With SetAssertPanics, a violated invariant also
+// panics after it is logged.
+func (l *Logger) AssertTrue(cond bool, msg string, fields ...Field) bool {
+	if cond {
+		return true
+	}
+	if e := l.newEvent(CRITICAL); e != nil {
+		var pcs [maxStackFrames]uintptr
+		n := runtime.Callers(2, pcs[:])
+		stack := pcs[:n:n]
+		e.Field(fields...).
+			Bool(AssertionKey, true).
+			LazyStr(AssertionStackKey, func() string { return formatStack(stack) }).
+			Msg(msg)
+	}
+	if l.assertPanics.Load() {
+		panicFunc("assertion failed: " + msg)
+	}
+	return false
+}
+
+// SetAssertPanics makes AssertTrue panic when an invariant is violated,
+// for development and tests where a violation should stop the program.
+func (l *Logger) SetAssertPanics(enabled bool) {
+	l.assertPanics.Store(enabled)
+}
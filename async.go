@@ -0,0 +1,311 @@
+package loggo
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxQueuedLineCap is the capacity above which a queued line's buffer is
+// released after the line is written, so one huge line does not pin its
+// memory
+const maxQueuedLineCap = 64 << 10
+
+// DropPolicy selects what an asynchronous logger does with a line when
+// its queue is full.
+type DropPolicy int
+
+// Drop policies.
+const (
+	BlockWhenFull DropPolicy = iota // Wait for room, so no line is lost
+	DropOldest                      // Discard the oldest queued line, keeping the most recent ones
+	DropNewest                      // Discard the line being logged
+)
+
+// AsyncStats reports the activity of an asynchronous logger's queue.
+type AsyncStats struct {
+	Capacity int   // Lines the queue holds
+	Queued   int   // Lines waiting to be written
+	Written  int64 // Lines written by the background goroutine
+	Dropped  int64 // Lines discarded because the queue was full
+}
+
+// SetAsync makes the logger queue formatted lines for a background
+// goroutine to write, so slow outputs such as network connections or
+// busy disks do not block logging calls. bufferSize is the number of
+// lines the queue holds, rounded up to a power of two; policy decides
+// what happens when it is full. Sinks and hooks are not affected. Flush
+// waits for the queued lines to be written, as do FATAL and PANIC
+// records, and Close writes them and returns to synchronous writes. 0
+// also returns to synchronous writes, after writing the queued lines.
+func (l *Logger) SetAsync(bufferSize int, policy DropPolicy) {
+	var q *asyncQueue
+	if bufferSize > 0 {
+		q = newAsyncQueue(l.output, bufferSize, policy)
+	}
+	if old := l.output.async.Swap(q); old != nil {
+		old.stop()
+	}
+}
+
+// AsyncStats returns the counters of the queue set with SetAsync, or
+// zero values if the logger writes synchronously.
+func (l *Logger) AsyncStats() AsyncStats {
+	if q := l.output.async.Load(); q != nil {
+		return q.stats()
+	}
+	return AsyncStats{}
+}
+
+// asyncLines recycles the buffers lines are queued in
+var asyncLines = sync.Pool{New: func() any { return new([]byte) }}
+
+// asyncQueue queues lines on a lock-free ring for a background goroutine
+// to write. Logging goroutines only copy the line and push it, so they
+// never wait on each other or on the writer unless the ring is full and
+// the policy is BlockWhenFull.
+type asyncQueue struct {
+	out    *multiWriter
+	policy DropPolicy
+	ring   *mpscRing[*[]byte]
+
+	sending  atomic.Int64  // Enqueues in progress
+	waiting  atomic.Int32  // Enqueues waiting for room
+	room     chan struct{} // Signalled by the writer when enqueues wait
+	idle     atomic.Bool   // Set while the writer waits for wake
+	wake     chan struct{} // Signalled by enqueues when idle
+	stopped  atomic.Bool
+	stopChan chan struct{}
+	done     chan struct{}
+
+	// evict serializes the writer with DropOldest enqueues discarding the
+	// oldest line, the ring having a single consumer
+	evict sync.Mutex
+	batch []*[]byte // Owned by the writer
+
+	queued   atomic.Int64 // Lines pushed onto the ring
+	retired  atomic.Int64 // Lines written or discarded from the ring
+	written  atomic.Int64
+	dropped  atomic.Int64
+	draining atomic.Int32 // Goroutines in drain
+	mu       sync.Mutex
+	cond     sync.Cond // Signalled when lines are retired while draining
+}
+
+// newAsyncQueue starts a queue of size lines writing to out
+func newAsyncQueue(out *multiWriter, size int, policy DropPolicy) *asyncQueue {
+	q := &asyncQueue{
+		out:      out,
+		policy:   policy,
+		ring:     newMPSCRing[*[]byte](size),
+		room:     make(chan struct{}, 1),
+		wake:     make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+		batch:    make([]*[]byte, 0, workerBatchSize),
+	}
+	q.cond.L = &q.mu
+	go q.run()
+	return q
+}
+
+// enqueue copies the line into the queue, applying the drop policy. It
+// returns false if the queue is stopped and the line must be written
+// synchronously.
+func (q *asyncQueue) enqueue(data []byte) bool {
+	q.sending.Add(1)
+	defer q.sending.Add(-1)
+	if q.stopped.Load() {
+		return false
+	}
+
+	line := asyncLines.Get().(*[]byte)
+	*line = append((*line)[:0], data...)
+	for !q.push(line) {
+		switch q.policy {
+		case DropNewest:
+			q.dropped.Add(1)
+			releaseLine(line)
+			return true
+		case DropOldest:
+			q.evictOldest()
+		default:
+			if !q.waitRoom(line) {
+				releaseLine(line)
+				return false
+			}
+			return true
+		}
+	}
+	return true
+}
+
+// push queues line and wakes the writer, reporting false if the ring is
+// full
+func (q *asyncQueue) push(line *[]byte) bool {
+	if !q.ring.push(line) {
+		return false
+	}
+	q.queued.Add(1)
+	if q.idle.Load() {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// evictOldest discards the oldest queued line to make room
+func (q *asyncQueue) evictOldest() {
+	var oldest [1]*[]byte
+	q.evict.Lock()
+	evicted := q.ring.popBatch(oldest[:0], 1)
+	q.evict.Unlock()
+	if len(evicted) > 0 {
+		q.dropped.Add(1)
+		releaseLine(evicted[0])
+		q.retire(1)
+	}
+}
+
+// waitRoom waits for the writer to make room for line and queues it. It
+// returns false if the queue is stopped meanwhile. Registering as waiting
+// before trying again means the writer emptying the ring after the
+// attempt sees the waiter and signals it.
+func (q *asyncQueue) waitRoom(line *[]byte) bool {
+	q.waiting.Add(1)
+	defer q.waiting.Add(-1)
+	for !q.push(line) {
+		select {
+		case <-q.room:
+		case <-q.stopChan:
+			return false
+		}
+	}
+	// Pass the signal on to the next waiter, if any
+	if q.waiting.Load() > 1 {
+		q.signalRoom()
+	}
+	return true
+}
+
+// signalRoom wakes an enqueue waiting for room
+func (q *asyncQueue) signalRoom() {
+	if q.waiting.Load() > 0 {
+		select {
+		case q.room <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// releaseLine returns a line's buffer to the pool
+func releaseLine(line *[]byte) {
+	if cap(*line) <= maxQueuedLineCap {
+		asyncLines.Put(line)
+	}
+}
+
+// run writes the queued lines in order until the queue is stopped
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for spins := 0; ; {
+		if q.writeBatch() {
+			spins = 0
+			continue
+		}
+		// Yield a few times before sleeping, as pool workers do
+		if spins < workerSpins {
+			spins++
+			runtime.Gosched()
+			continue
+		}
+		spins = 0
+		q.idle.Store(true)
+		// An enqueue either sees idle or its line is seen here
+		if q.ring.ready() {
+			q.idle.Store(false)
+			continue
+		}
+		select {
+		case <-q.wake:
+			q.idle.Store(false)
+		case <-q.stopChan:
+			q.idle.Store(false)
+			return
+		}
+	}
+}
+
+// writeBatch writes a batch of queued lines and reports whether there
+// were any
+func (q *asyncQueue) writeBatch() bool {
+	if q.policy == DropOldest {
+		q.evict.Lock()
+	}
+	q.batch = q.ring.popBatch(q.batch[:0], workerBatchSize)
+	if q.policy == DropOldest {
+		q.evict.Unlock()
+	}
+	if len(q.batch) == 0 {
+		return false
+	}
+	q.signalRoom()
+	for _, line := range q.batch {
+		q.out.writeNow(*line)
+		releaseLine(line)
+	}
+	clear(q.batch)
+	q.written.Add(int64(len(q.batch)))
+	q.retire(len(q.batch))
+	return true
+}
+
+// retire counts n lines as written or discarded, waking drain
+func (q *asyncQueue) retire(n int) {
+	q.retired.Add(int64(n))
+	if q.draining.Load() > 0 {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// drain waits until the lines queued so far have been written
+func (q *asyncQueue) drain() {
+	q.draining.Add(1)
+	defer q.draining.Add(-1)
+	target := q.queued.Load()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.retired.Load() < target {
+		q.cond.Wait()
+	}
+}
+
+// stop writes the queued lines and stops the goroutine. Lines logged
+// afterwards are written synchronously.
+func (q *asyncQueue) stop() {
+	if !q.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	close(q.stopChan)
+	for q.sending.Load() > 0 {
+		runtime.Gosched()
+	}
+	<-q.done
+	// The writer has exited, so this goroutine is the consumer now
+	for q.writeBatch() {
+	}
+}
+
+// stats returns the queue's counters
+func (q *asyncQueue) stats() AsyncStats {
+	return AsyncStats{
+		Capacity: q.ring.cap(),
+		Queued:   q.ring.len(),
+		Written:  q.written.Load(),
+		Dropped:  q.dropped.Load(),
+	}
+}
@@ -0,0 +1,194 @@
+package loggo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Authenticator adds credentials to an outgoing sink request.
+// body is the exact payload that will be sent, for schemes that sign it.
+type Authenticator interface {
+	Authenticate(req *http.Request, body []byte) error
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(req *http.Request, body []byte) error
+
+// Authenticate calls f(req, body).
+func (f AuthenticatorFunc) Authenticate(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// BearerToken returns an Authenticator sending "Authorization: Bearer <token>".
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request, _ []byte) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// BasicAuth returns an Authenticator using HTTP basic authentication.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request, _ []byte) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// SigV4Credentials are AWS access keys used to sign requests.
+type SigV4Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+}
+
+// SigV4Config holds the credentials and scope for AWS Signature Version 4.
+type SigV4Config struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+	Region          string // e.g. "us-east-1"
+	Service         string // e.g. "logs" or "es"
+	SignPayload     bool   // Also send X-Amz-Content-Sha256 (required by S3)
+
+	// Credentials, if set, is called for every request and overrides the
+	// static keys above, so rotated credentials are picked up automatically.
+	Credentials func(ctx context.Context) (SigV4Credentials, error)
+}
+
+// SigV4 returns an Authenticator signing requests with AWS Signature
+// Version 4, for collectors behind AWS authentication such as
+// CloudWatch Logs or OpenSearch.
+func SigV4(config SigV4Config) Authenticator {
+	return &sigV4Signer{config: config, now: time.Now}
+}
+
+// sigV4Signer implements AWS Signature Version 4
+type sigV4Signer struct {
+	config SigV4Config
+	now    func() time.Time
+}
+
+// Authenticate signs the request in place.
+func (s *sigV4Signer) Authenticate(req *http.Request, body []byte) error {
+	creds := SigV4Credentials{
+		AccessKeyID:     s.config.AccessKeyID,
+		SecretAccessKey: s.config.SecretAccessKey,
+		SessionToken:    s.config.SessionToken,
+	}
+	if s.config.Credentials != nil {
+		var err error
+		if creds, err = s.config.Credentials(req.Context()); err != nil {
+			return err
+		}
+	}
+
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if s.config.SignPayload {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-encoding" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/" + s.config.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, s.config.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
+
+// canonicalQuery encodes query parameters sorted by key as SigV4 requires
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var parts []string
+	for _, key := range keys {
+		vals := slices.Clone(values[key])
+		slices.Sort(vals)
+		for _, val := range vals {
+			parts = append(parts, sigV4Escape(key)+"="+sigV4Escape(val))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// sigV4Escape percent-encodes s, encoding spaces as %20
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// sha256Hex returns the lowercase hex SHA-256 digest of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns HMAC-SHA256(key, data)
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,439 @@
+package loggo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Batch is a group of entries from a single stream delivered together.
+// Entries keep their original event time in Entry.Time; SentAt records
+// when the current delivery attempt started.
+type Batch struct {
+	Stream  string    // Stream key the entries belong to
+	Entries []*Entry  // Entries ordered by event time
+	SentAt  time.Time // Start of the current delivery attempt
+	Attempt int       // Delivery attempt, starting at 1
+}
+
+// BatchSender delivers batches to a remote system such as Loki or
+// CloudWatch Logs. Send is never called concurrently for the same stream.
+type BatchSender interface {
+	Send(ctx context.Context, batch *Batch) error
+}
+
+// BatchSenderFunc adapts a function to the BatchSender interface.
+type BatchSenderFunc func(ctx context.Context, batch *Batch) error
+
+// Send calls f(ctx, batch).
+func (f BatchSenderFunc) Send(ctx context.Context, batch *Batch) error {
+	return f(ctx, batch)
+}
+
+// BatchConfig configures a BatchSink. Zero values select the defaults.
+type BatchConfig struct {
+	MaxEntries    int                 // Flush a stream once it holds this many entries (default 100)
+	FlushInterval time.Duration       // Flush all streams at least this often (default 1s)
+	MaxRetries    int                 // Retries after a failed send (default 3, negative disables)
+	RetryBackoff  time.Duration       // Initial retry delay, doubled per retry (default 100ms)
+	SendTimeout   time.Duration       // Timeout for a single send attempt (default 10s)
+	Stream        func(*Entry) string // Stream key for an entry (default: a single stream)
+	OnError       func(error, *Batch) // Called when a batch is dropped after all retries
+	DeadLetter    DeadLetter          // Receives the entries of batches dropped after all retries
+
+	// QueueFile, if set, keeps the batches that fail all retries instead
+	// of dropping them: they are resent with the next flush, and persisted
+	// to the file if still undelivered when the sink is closed. They are
+	// reloaded and resent by the next sink created with the same file, so
+	// a restart during a collector outage does not drop the backlog.
+	// Entries beyond MaxQueued are dropped oldest first, reported to
+	// DeadLetter but not to OnError.
+	QueueFile string
+	MaxQueued int // Undelivered entries kept with a QueueFile (default 10000)
+}
+
+// BatchStats reports the activity of a BatchSink.
+type BatchStats struct {
+	Batches       int64 // Batches delivered successfully
+	Entries       int64 // Entries delivered successfully
+	Retries       int64 // Failed attempts that were retried
+	Dropped       int64 // Entries dropped after exhausting retries
+	Reordered     int64 // Entries that arrived out of event time order
+	SkewCorrected int64 // Entries whose time was moved forward to keep a stream ordered
+	Persisted     int64 // Entries written to the queue file on close
+	Queued        int64 // Undelivered entries kept for the queue file
+	Restored      int64 // Entries reloaded from the queue file on startup
+}
+
+// BatchSink buffers entries per stream and delivers them in batches from a
+// background goroutine. Within a stream, entries are sorted by event time
+// before sending, and entries older than the last delivered timestamp are
+// moved forward to it (with the original time kept in an "event_time"
+// field) so that backends enforcing per-stream ordering do not reject
+// retried or late batches.
+type BatchSink struct {
+	sender  BatchSender
+	config  BatchConfig
+	mu      sync.Mutex
+	streams map[string][]*Entry
+	sending sync.Mutex           // Serializes flushes
+	last    map[string]time.Time // Last delivered event time per stream
+	stats   BatchStats
+	closed  bool
+	unsent  []*Entry // Undelivered entries kept for the queue file, guarded by sending
+	queued  bool     // The queue file holds entries not yet delivered, guarded by sending
+
+	flushChan chan struct{}
+	stopChan  chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBatchSink creates a BatchSink delivering batches through sender.
+// If config.QueueFile holds entries persisted by a previous sink, they are
+// queued for delivery before any new entries.
+func NewBatchSink(sender BatchSender, config BatchConfig) *BatchSink {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = 10 * time.Second
+	}
+	if config.MaxQueued <= 0 {
+		config.MaxQueued = 10000
+	}
+
+	s := &BatchSink{
+		sender:    sender,
+		config:    config,
+		streams:   make(map[string][]*Entry),
+		last:      make(map[string]time.Time),
+		flushChan: make(chan struct{}, 1),
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if config.QueueFile != "" {
+		s.restoreQueue()
+	}
+	go s.run()
+	return s
+}
+
+// setRand passes the logger's random source on to the sender
+func (s *BatchSink) setRand(r *randSource) {
+	if u, ok := s.sender.(randUser); ok {
+		u.setRand(r)
+	}
+}
+
+// WriteEntry queues a copy of the entry on its stream.
+func (s *BatchSink) WriteEntry(entry *Entry) error {
+	stream := ""
+	if s.config.Stream != nil {
+		stream = s.config.Stream(entry)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errSinkClosed
+	}
+	s.streams[stream] = append(s.streams[stream], entry.Clone())
+	full := len(s.streams[stream]) >= s.config.MaxEntries
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushChan <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush synchronously delivers all buffered entries.
+// It returns the errors of batches that were dropped.
+func (s *BatchSink) Flush() error {
+	return s.flush()
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (s *BatchSink) Stats() BatchStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops the background flusher and delivers any remaining entries.
+// With a QueueFile configured, entries that still cannot be delivered,
+// including the backlog kept while the collector was down, are persisted
+// instead of dropped.
+func (s *BatchSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stopChan)
+		<-s.done
+		err = s.flush()
+		if s.config.QueueFile != "" {
+			err = errors.Join(err, s.persistQueue())
+		}
+	})
+	return err
+}
+
+// run flushes on the configured interval or when a stream fills up
+func (s *BatchSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushChan:
+			s.flush()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// flush takes the buffered streams and sends them one batch at a time,
+// the backlog kept by earlier failures first
+func (s *BatchSink) flush() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+
+	s.mu.Lock()
+	buffered := s.streams
+	s.streams = make(map[string][]*Entry, len(buffered))
+	s.mu.Unlock()
+
+	pending := make(map[string][]*Entry, len(buffered))
+	for _, entry := range s.unsent {
+		stream := s.streamOf(entry)
+		pending[stream] = append(pending[stream], entry)
+	}
+	for stream, entries := range buffered {
+		pending[stream] = append(pending[stream], entries...)
+	}
+	total := 0
+	for _, entries := range pending {
+		total += len(entries)
+	}
+	s.unsent = nil
+
+	var errs []error
+	for _, stream := range sortedKeys(pending) {
+		entries := pending[stream]
+		for len(entries) > 0 {
+			n := min(len(entries), s.config.MaxEntries)
+			if err := s.send(stream, entries[:n]); err != nil {
+				errs = append(errs, err)
+			}
+			entries = entries[n:]
+		}
+	}
+	s.mu.Lock()
+	s.stats.Queued = int64(len(s.unsent))
+	s.mu.Unlock()
+	if s.queued && len(s.unsent) < total {
+		// The file keeps the restored entries in case the process dies
+		// before they are delivered; once some leave memory, it holds the
+		// backlog instead, so none is sent twice after a restart
+		if err := s.writeQueue(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// streamOf returns the stream key of an entry
+func (s *BatchSink) streamOf(entry *Entry) string {
+	if s.config.Stream != nil {
+		return s.config.Stream(entry)
+	}
+	return ""
+}
+
+// send orders a batch, corrects skew against the last delivered time of
+// the stream and delivers it with retries.
+func (s *BatchSink) send(stream string, entries []*Entry) error {
+	reordered := 0
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Time.Before(entries[i-1].Time) {
+			reordered++
+		}
+	}
+	if reordered > 0 {
+		slices.SortStableFunc(entries, func(a, b *Entry) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+
+	corrected := 0
+	if last, ok := s.last[stream]; ok {
+		for _, entry := range entries {
+			if !entry.Time.Before(last) {
+				break
+			}
+			entry.Fields = append(entry.Fields, Str("event_time", entry.Time.Format(time.RFC3339Nano)))
+			entry.Time = last
+			corrected++
+		}
+	}
+
+	batch := &Batch{Stream: stream, Entries: entries}
+	backoff := s.config.RetryBackoff
+	var err error
+	for attempt := 1; attempt <= s.config.MaxRetries+1; attempt++ {
+		batch.Attempt = attempt
+		batch.SentAt = time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.SendTimeout)
+		err = s.sender.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			break
+		}
+		if attempt <= s.config.MaxRetries {
+			s.mu.Lock()
+			s.stats.Retries++
+			s.mu.Unlock()
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	keep := err != nil && s.config.QueueFile != ""
+	s.mu.Lock()
+	s.stats.Reordered += int64(reordered)
+	s.stats.SkewCorrected += int64(corrected)
+	if err == nil {
+		s.stats.Batches++
+		s.stats.Entries += int64(len(entries))
+	} else if !keep {
+		s.stats.Dropped += int64(len(entries))
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		if keep {
+			s.keepUnsent(err, entries)
+			return err
+		}
+		if s.config.OnError != nil {
+			s.config.OnError(err, batch)
+		}
+		if s.config.DeadLetter != nil {
+			s.config.DeadLetter.DeadLetter(sinkName(s.sender), err, entries...)
+		}
+		return err
+	}
+	s.last[stream] = entries[len(entries)-1].Time
+	return nil
+}
+
+// keepUnsent adds entries that failed all retries to the backlog, dropping
+// the oldest beyond MaxQueued
+func (s *BatchSink) keepUnsent(err error, entries []*Entry) {
+	s.unsent = append(s.unsent, entries...)
+	excess := len(s.unsent) - s.config.MaxQueued
+	if excess <= 0 {
+		return
+	}
+	dropped := s.unsent[:excess]
+	s.unsent = slices.Clone(s.unsent[excess:])
+	s.mu.Lock()
+	s.stats.Dropped += int64(excess)
+	s.mu.Unlock()
+	if s.config.DeadLetter != nil {
+		s.config.DeadLetter.DeadLetter(sinkName(s.sender), err, dropped...)
+	}
+}
+
+// restoreQueue loads entries persisted by a previous sink. The file is
+// rewritten as they leave memory, and replaced on close.
+func (s *BatchSink) restoreQueue() {
+	data, err := os.ReadFile(s.config.QueueFile)
+	if err != nil {
+		return
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		entry, err := ParseJSON(line)
+		if err != nil {
+			continue
+		}
+		stream := s.streamOf(entry)
+		s.streams[stream] = append(s.streams[stream], entry)
+		s.stats.Restored++
+		s.queued = true
+	}
+}
+
+// persistQueue writes undeliverable entries to the queue file on close
+func (s *BatchSink) persistQueue() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	if len(s.unsent) == 0 {
+		return nil
+	}
+	n := len(s.unsent)
+	if err := s.writeQueue(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.stats.Persisted += int64(n)
+	s.mu.Unlock()
+	s.unsent = nil
+	return nil
+}
+
+// writeQueue replaces the queue file with the backlog, or removes it if
+// the backlog is empty. The file is replaced atomically so a crash never
+// leaves a partial queue. The caller must hold s.sending.
+func (s *BatchSink) writeQueue() error {
+	if len(s.unsent) == 0 {
+		s.queued = false
+		if err := os.Remove(s.config.QueueFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var buf []byte
+	for _, entry := range s.unsent {
+		buf = AppendJSON(buf, entry)
+		buf = append(buf, '\n')
+	}
+	tmp := s.config.QueueFile + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.config.QueueFile); err != nil {
+		return err
+	}
+	s.queued = true
+	return nil
+}
@@ -0,0 +1,256 @@
+// Package benchcmp compares the performance of loggo with other popular
+// logging libraries. It is used by the benchmarks in the parent directory
+// and by the benchcmp command, which writes reports as text, JSON or CSV
+// so results can be tracked across releases.
+package benchcmp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"loggo"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Libraries are the libraries compared by default, in report order.
+var Libraries = []string{"loggo", "zerolog", "zap", "logrus", "slog"}
+
+// Levels are the levels measured by default, in report order.
+var Levels = []string{"Debug", "Info", "Warn", "Error"}
+
+// Options configures a comparison. Zero values select the defaults.
+type Options struct {
+	Iterations int      // Calls measured per library and level; default 1000000
+	Libraries  []string // Libraries to compare; default Libraries
+	Levels     []string // Levels to measure; default Levels
+	Message    string   // Format string logged; default "Benchmark test message %d"
+	Args       []any    // Format arguments; default 123
+}
+
+// Result is the measurement of one library at one level.
+type Result struct {
+	Library     string        `json:"library"`
+	Level       string        `json:"level"`
+	Duration    time.Duration `json:"ns_per_op"`
+	BytesPerOp  float64       `json:"bytes_per_op"`
+	AllocsPerOp float64       `json:"allocs_per_op"`
+}
+
+// Report is the outcome of RunComparison.
+type Report struct {
+	Time       time.Time `json:"time"`
+	GoVersion  string    `json:"go_version"`
+	GOOS       string    `json:"goos"`
+	GOARCH     string    `json:"goarch"`
+	Iterations int       `json:"iterations"`
+	Libraries  []string  `json:"libraries"`
+	Levels     []string  `json:"levels"`
+	Results    []Result  `json:"results"`
+}
+
+// logFunc logs a formatted message at one level
+type logFunc func(format string, args ...any)
+
+// setup creates a library's logger writing to w and returns its logging
+// function for each level
+type setup func(w io.Writer) map[string]logFunc
+
+// setups configure every library the same way: text or JSON to a buffer,
+// INFO as the minimum level except for slog
+var setups = map[string]setup{
+	"loggo": func(w io.Writer) map[string]logFunc {
+		l := loggo.New()
+		l.SetOutput(w)
+		return map[string]logFunc{"Debug": l.Debugf, "Info": l.Infof, "Warn": l.Warnf, "Error": l.Errorf}
+	},
+	"logrus": func(w io.Writer) map[string]logFunc {
+		l := logrus.New()
+		l.SetOutput(w)
+		l.SetFormatter(&logrus.TextFormatter{DisableColors: true, DisableTimestamp: true})
+		return map[string]logFunc{"Debug": l.Debugf, "Info": l.Infof, "Warn": l.Warnf, "Error": l.Errorf}
+	},
+	"zap": func(w io.Writer) map[string]logFunc {
+		config := zap.NewProductionEncoderConfig()
+		config.TimeKey = ""
+		l := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(config), zapcore.AddSync(w), zap.InfoLevel))
+		wrap := func(log func(string, ...zap.Field)) logFunc {
+			return func(format string, args ...any) { log(fmt.Sprintf(format, args...)) }
+		}
+		return map[string]logFunc{"Debug": wrap(l.Debug), "Info": wrap(l.Info), "Warn": wrap(l.Warn), "Error": wrap(l.Error)}
+	},
+	"zerolog": func(w io.Writer) map[string]logFunc {
+		l := zerolog.New(w).With().Timestamp().Logger()
+		wrap := func(event func() *zerolog.Event) logFunc {
+			return func(format string, args ...any) { event().Msgf(format, args...) }
+		}
+		return map[string]logFunc{"Debug": wrap(l.Debug), "Info": wrap(l.Info), "Warn": wrap(l.Warn), "Error": wrap(l.Error)}
+	},
+	"slog": func(w io.Writer) map[string]logFunc {
+		l := slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		wrap := func(log func(string, ...any)) logFunc {
+			return func(format string, args ...any) { log(fmt.Sprintf(format, args...)) }
+		}
+		return map[string]logFunc{"Debug": wrap(l.Debug), "Info": wrap(l.Info), "Warn": wrap(l.Warn), "Error": wrap(l.Error)}
+	},
+}
+
+// RunComparison measures every library at every level, one after the
+// other, and returns the average time, bytes and allocations per call.
+// Unknown libraries and levels are reported as errors.
+func RunComparison(opts Options) (Report, error) {
+	if opts.Iterations <= 0 {
+		opts.Iterations = 1000000
+	}
+	if len(opts.Libraries) == 0 {
+		opts.Libraries = Libraries
+	}
+	if len(opts.Levels) == 0 {
+		opts.Levels = Levels
+	}
+	if opts.Message == "" {
+		opts.Message, opts.Args = "Benchmark test message %d", []any{123}
+	}
+	report := Report{
+		Time:       time.Now(),
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		Iterations: opts.Iterations,
+		Libraries:  opts.Libraries,
+		Levels:     opts.Levels,
+	}
+
+	var buf bytes.Buffer
+	for _, library := range opts.Libraries {
+		setup, ok := setups[library]
+		if !ok {
+			return Report{}, fmt.Errorf("benchcmp: unknown library %q", library)
+		}
+		funcs := setup(&buf)
+		for _, level := range opts.Levels {
+			log, ok := funcs[level]
+			if !ok {
+				return Report{}, fmt.Errorf("benchcmp: unknown level %q", level)
+			}
+			report.Results = append(report.Results, measure(library, level, opts, log, &buf))
+		}
+	}
+	return report, nil
+}
+
+// measure calls log opts.Iterations times and averages the cost
+func measure(library, level string, opts Options, log logFunc, buf *bytes.Buffer) Result {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range opts.Iterations {
+		log(opts.Message, opts.Args...)
+		buf.Reset()
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	n := float64(opts.Iterations)
+	return Result{
+		Library:     library,
+		Level:       level,
+		Duration:    elapsed / time.Duration(opts.Iterations),
+		BytesPerOp:  float64(after.TotalAlloc-before.TotalAlloc) / n,
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / n,
+	}
+}
+
+// Result returns the measurement of library at level.
+func (r Report) Result(library, level string) (Result, bool) {
+	i := slices.IndexFunc(r.Results, func(res Result) bool {
+		return res.Library == library && res.Level == level
+	})
+	if i < 0 {
+		return Result{}, false
+	}
+	return r.Results[i], true
+}
+
+// Average returns the mean duration per call of library over all levels.
+func (r Report) Average(library string) time.Duration {
+	var total time.Duration
+	var n int
+	for _, res := range r.Results {
+		if res.Library == library {
+			total += res.Duration
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// WriteText writes the report as a table with one row per level and one
+// column per library, followed by the averages.
+func (r Report) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Logging Performance Results (%d iterations each)\n", r.Iterations)
+	fmt.Fprintf(&buf, "================================================\n")
+	fmt.Fprintf(&buf, "%-10s", "Function")
+	for _, library := range r.Libraries {
+		fmt.Fprintf(&buf, " %-15s", library)
+	}
+	fmt.Fprintf(&buf, "\n------------------------------------------------\n")
+	for _, level := range r.Levels {
+		fmt.Fprintf(&buf, "%-10s", level)
+		for _, library := range r.Libraries {
+			res, _ := r.Result(library, level)
+			fmt.Fprintf(&buf, " %-15v", res.Duration)
+		}
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&buf, "------------------------------------------------\n")
+	fmt.Fprintf(&buf, "%-10s", "AVERAGE")
+	for _, library := range r.Libraries {
+		fmt.Fprintf(&buf, " %-15v", r.Average(library))
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteJSON writes the report as an indented JSON document. Durations
+// are in nanoseconds.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per result with a header row: library, level,
+// ns_per_op, bytes_per_op and allocs_per_op.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"library", "level", "ns_per_op", "bytes_per_op", "allocs_per_op"})
+	for _, res := range r.Results {
+		cw.Write([]string{
+			res.Library,
+			res.Level,
+			strconv.FormatInt(int64(res.Duration), 10),
+			strconv.FormatFloat(res.BytesPerOp, 'f', 2, 64),
+			strconv.FormatFloat(res.AllocsPerOp, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,39 @@
+package benchcmp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunComparison(t *testing.T) {
+	report, err := RunComparison(Options{Iterations: 10, Libraries: []string{"loggo", "slog"}, Levels: []string{"Info", "Error"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Results) != 4 {
+		t.Fatalf("Expected one result per library and level, got %d", len(report.Results))
+	}
+	if res, ok := report.Result("slog", "Error"); !ok || res.Duration <= 0 {
+		t.Errorf("Expected a measured slog result, got %+v", res)
+	}
+
+	var text, js, csvBuf bytes.Buffer
+	if err := report.WriteText(&text); err != nil || !strings.Contains(text.String(), "AVERAGE") {
+		t.Errorf("Expected a text table, got %q (%v)", text.String(), err)
+	}
+	var decoded Report
+	if err := report.WriteJSON(&js); err != nil || json.Unmarshal(js.Bytes(), &decoded) != nil || len(decoded.Results) != 4 {
+		t.Errorf("Expected a JSON report, got %q (%v)", js.String(), err)
+	}
+	report.WriteCSV(&csvBuf)
+	if rows, err := csv.NewReader(&csvBuf).ReadAll(); err != nil || len(rows) != 5 || rows[0][2] != "ns_per_op" {
+		t.Errorf("Expected a header and 4 CSV rows, got %v (%v)", rows, err)
+	}
+
+	if _, err := RunComparison(Options{Libraries: []string{"log15"}}); err == nil {
+		t.Errorf("Expected an error for unknown libraries")
+	}
+}
@@ -0,0 +1,61 @@
+// Command benchcmp compares loggo with other logging libraries and writes
+// the report as text, JSON or CSV:
+//
+//	go run ./cmd/benchcmp -n 100000 -format json -o bench.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"loggo/benchmarks/benchcmp"
+)
+
+func main() {
+	iterations := flag.Int("n", 1000000, "calls measured per library and level")
+	libraries := flag.String("libs", strings.Join(benchcmp.Libraries, ","), "comma-separated libraries to compare")
+	levels := flag.String("levels", strings.Join(benchcmp.Levels, ","), "comma-separated levels to measure")
+	format := flag.String("format", "text", "report format: text, json or csv")
+	output := flag.String("o", "", "write the report to this file instead of standard output")
+	flag.Parse()
+
+	report, err := benchcmp.RunComparison(benchcmp.Options{
+		Iterations: *iterations,
+		Libraries:  strings.Split(*libraries, ","),
+		Levels:     strings.Split(*levels, ","),
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "text":
+		err = report.WriteText(w)
+	case "json":
+		err = report.WriteJSON(w)
+	case "csv":
+		err = report.WriteCSV(w)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "benchcmp:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,102 @@
+package loggo
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+)
+
+// BlobEncoding selects how Blob fields encode binary data.
+type BlobEncoding int
+
+// Blob encodings.
+const (
+	BlobBase64    BlobEncoding = iota // Standard base64 with padding
+	BlobBase64URL                     // URL-safe base64 without padding
+	BlobHex                           // Lower-case hexadecimal
+)
+
+// String returns the name of the encoding.
+func (enc BlobEncoding) String() string {
+	switch enc {
+	case BlobBase64:
+		return "base64"
+	case BlobBase64URL:
+		return "base64url"
+	case BlobHex:
+		return "hex"
+	}
+	return "BlobEncoding(" + strconv.Itoa(int(enc)) + ")"
+}
+
+// DefaultBlobLimit is the number of bytes of a blob encoded by default.
+const DefaultBlobLimit = 1024
+
+// Suffixes of the fields added next to truncated blobs.
+const (
+	BlobSizeSuffix      = ".size"      // Length of the data before truncation
+	BlobTruncatedSuffix = ".truncated" // Set to true when the data was truncated
+	BlobChunksSuffix    = ".chunks"    // Number of chunks of a blob paged with SetBlobPaging
+)
+
+// Blob adds binary data, such as a protocol dump or a payload snippet,
+// encoded as a string. Only the first bytes up to the logger's blob limit
+// are encoded; when data is longer, fields named key plus BlobSizeSuffix
+// and BlobTruncatedSuffix record its full length and the truncation.
+// With SetBlobPaging, the rest of the data is written in continuation
+// records instead of being dropped. The data is encoded immediately, so
+// the caller may reuse it.
+func (e *Event) Blob(key string, data []byte, encoding BlobEncoding) *Event {
+	if e == nil {
+		return nil
+	}
+	limit := int(e.logger.blobLimit.Load())
+	if limit == 0 {
+		limit = DefaultBlobLimit
+	}
+	size := len(data)
+	truncated := limit > 0 && size > limit
+	if truncated && e.logger.blobPaging.Load() {
+		e.page(key, encodeBlob(data, encoding), encodedLen(limit, encoding))
+		e.fields = append(e.fields, Int(key+BlobSizeSuffix, size))
+		return e
+	}
+	if truncated {
+		data = data[:limit]
+	}
+	e.fields = append(e.fields, Str(key, encodeBlob(data, encoding)))
+	if truncated {
+		e.fields = append(e.fields, Int(key+BlobSizeSuffix, size), Bool(key+BlobTruncatedSuffix, true))
+	}
+	return e
+}
+
+// encodeBlob encodes data as a string
+func encodeBlob(data []byte, encoding BlobEncoding) string {
+	switch encoding {
+	case BlobBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data)
+	case BlobHex:
+		return hex.EncodeToString(data)
+	default:
+		return base64.StdEncoding.EncodeToString(data)
+	}
+}
+
+// encodedLen returns the length of n bytes encoded with encoding
+func encodedLen(n int, encoding BlobEncoding) int {
+	switch encoding {
+	case BlobBase64URL:
+		return base64.RawURLEncoding.EncodedLen(n)
+	case BlobHex:
+		return hex.EncodedLen(n)
+	default:
+		return base64.StdEncoding.EncodedLen(n)
+	}
+}
+
+// SetBlobLimit sets how many bytes of each Blob field are encoded.
+// 0 restores DefaultBlobLimit and a negative limit encodes blobs whole.
+func (l *Logger) SetBlobLimit(limit int) {
+	l.blobLimit.Store(int64(limit))
+}
@@ -0,0 +1,94 @@
+package loggo
+
+import (
+	"sync"
+	"time"
+)
+
+// Keys of the fields of the entries marking a level boost
+const (
+	BoostLevelKey    = "boost.level"    // Level in effect during the boost
+	BoostPreviousKey = "boost.previous" // Level restored when the boost ends
+	BoostDurationKey = "boost.duration" // Requested length of the boost
+)
+
+// levelBoost is the level boost in effect, if any
+type levelBoost struct {
+	mu       sync.Mutex
+	timer    *time.Timer // Ends the boost; nil when none is in effect
+	level    Level       // Level set by the boost
+	previous Level       // Level before the first of overlapping boosts
+	gen      uint64      // Incremented by every boost, so stale ends are ignored
+}
+
+// BoostLevel sets the logger's level to level for d and then restores the
+// previous one, so debug output enabled during an incident cannot be
+// forgotten. Entries mark the start and the end of the boost. A new boost
+// replaces the one in effect, and the level from before the first is
+// restored. If the level is changed with SetLevel during the boost, it is
+// left unchanged when the boost ends. The returned function ends the boost
+// early.
+func (l *Logger) BoostLevel(level Level, d time.Duration) (end func()) {
+	b := &l.boost
+	b.mu.Lock()
+	if b.timer != nil {
+		b.timer.Stop()
+	} else {
+		b.previous = l.Level()
+	}
+	b.gen++
+	gen, previous := b.gen, b.previous
+	b.level = level
+	b.timer = time.AfterFunc(d, func() { l.endBoost(gen) })
+	b.mu.Unlock()
+
+	l.SetLevel(level)
+	l.boostNotice(level, "level boosted to "+level.String()+" for "+d.String(),
+		Str(BoostPreviousKey, previous.String()), Dur(BoostDurationKey, d))
+	return func() { l.endBoost(gen) }
+}
+
+// endBoost ends the boost of generation gen, if still in effect
+func (l *Logger) endBoost(gen uint64) {
+	b := &l.boost
+	b.mu.Lock()
+	if b.timer == nil || b.gen != gen {
+		b.mu.Unlock()
+		return
+	}
+	b.timer.Stop()
+	b.timer = nil
+	level, previous := b.level, b.previous
+	b.mu.Unlock()
+
+	if current := l.Level(); current != level {
+		l.boostNotice(level, "level boost ended, leaving the level at "+current.String(),
+			Str(BoostPreviousKey, previous.String()))
+		return
+	}
+	l.boostNotice(level, "level boost ended, restoring "+previous.String(),
+		Str(BoostPreviousKey, previous.String()))
+	l.SetLevel(previous)
+}
+
+// boostNotice writes an entry marking a boost, at a level the boost lets through
+func (l *Logger) boostNotice(level Level, msg string, fields ...Field) {
+	e := l.newEvent(max(level, INFO))
+	if e == nil {
+		return
+	}
+	e.internal = true
+	e.fields = append(e.fields, Str(BoostLevelKey, level.String()))
+	e.fields = append(e.fields, fields...)
+	e.Msg(msg)
+}
+
+// stop cancels the pending end of a boost, leaving the level as it is
+func (b *levelBoost) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
@@ -0,0 +1,206 @@
+package loggo
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EntryFilter selects entries delivered to a subscriber.
+type EntryFilter struct {
+	MinLevel Level             // Lowest level delivered
+	Fields   map[string]string // Fields that must be present with these values
+}
+
+// Match reports whether the entry passes the filter.
+func (f *EntryFilter) Match(entry *Entry) bool {
+	if entry.Level < f.MinLevel {
+		return false
+	}
+	for key, value := range f.Fields {
+		field, ok := entry.Field(key)
+		if !ok || field.ValueString() != value {
+			return false
+		}
+	}
+	return true
+}
+
+// subscriber is a single consumer of a BroadcastSink
+type subscriber struct {
+	filter  EntryFilter
+	entries chan *Entry
+}
+
+// BroadcastSink fans entries out to in-process subscribers, such as live
+// tail handlers. Delivery never blocks logging: entries for a subscriber
+// that is not keeping up are dropped and counted.
+type BroadcastSink struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	bufferSize  int
+	closed      bool
+	dropped     atomic.Int64
+}
+
+// NewBroadcastSink creates a broadcast sink buffering up to bufferSize
+// entries per subscriber (default 256).
+func NewBroadcastSink(bufferSize int) *BroadcastSink {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	return &BroadcastSink{
+		subscribers: make(map[*subscriber]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers a subscriber and returns its entry channel together
+// with a function that unsubscribes and closes the channel.
+func (b *BroadcastSink) Subscribe(filter EntryFilter) (<-chan *Entry, func()) {
+	sub := &subscriber{filter: filter, entries: make(chan *Entry, b.bufferSize)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.entries)
+		return sub.entries, func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+
+	var once sync.Once
+	return sub.entries, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub.entries)
+			}
+		})
+	}
+}
+
+// Dropped returns the number of entries dropped because a subscriber was slow.
+func (b *BroadcastSink) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// WriteEntry delivers a copy of the entry to every matching subscriber.
+func (b *BroadcastSink) WriteEntry(entry *Entry) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var clone *Entry
+	for sub := range b.subscribers {
+		if !sub.filter.Match(entry) {
+			continue
+		}
+		if clone == nil {
+			clone = entry.Clone()
+		}
+		select {
+		case sub.entries <- clone:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Close disconnects all subscribers.
+func (b *BroadcastSink) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.entries)
+	}
+	return nil
+}
+
+// LiveTailHandler returns an http.Handler streaming entries from b to the
+// client as Server-Sent Events, one JSON entry per event. Query parameters
+// filter the stream: "level" sets the minimum level and each
+// "field=key:value" requires a field value, e.g.
+//
+//	/debug/tail?level=warn&field=tenant:acme
+func LiveTailHandler(b *BroadcastSink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		filter, err := parseEntryFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, cancel := b.Subscribe(filter)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(15 * time.Second)
+		defer heartbeat.Stop()
+		var buf []byte
+		for {
+			select {
+			case entry, ok := <-entries:
+				if !ok {
+					return
+				}
+				buf = append(buf[:0], "data: "...)
+				buf = AppendJSON(buf, entry)
+				buf = append(buf, '\n', '\n')
+				if _, err := w.Write(buf); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-heartbeat.C:
+				if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
+
+// parseEntryFilter builds an EntryFilter from request query parameters
+func parseEntryFilter(r *http.Request) (EntryFilter, error) {
+	query := r.URL.Query()
+	filter := EntryFilter{MinLevel: DEBUG}
+	if name := query.Get("level"); name != "" {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinLevel = level
+	}
+	for _, pair := range query["field"] {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			continue
+		}
+		if filter.Fields == nil {
+			filter.Fields = make(map[string]string)
+		}
+		filter.Fields[key] = value
+	}
+	return filter, nil
+}
@@ -0,0 +1,124 @@
+package loggo
+
+import "sync"
+
+// DefaultBufferSize is the initial capacity of the buffers records are
+// formatted in.
+const DefaultBufferSize = 1024
+
+// BufferGrowth selects how event buffers grow for records larger than
+// their capacity.
+type BufferGrowth int
+
+// Buffer growth policies.
+const (
+	GrowDouble BufferGrowth = iota // At least double the capacity, so buffers settle at the usual record size
+	GrowExact                      // Allocate exactly what the record needs, for rare large records
+)
+
+// SetBufferSize sets the initial capacity of the buffers records are
+// formatted in; 0 restores DefaultBufferSize. Size it to hold typical
+// records, so they are formatted without growing the buffer.
+func (l *Logger) SetBufferSize(size int) {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	l.bufSize.Store(int64(size))
+}
+
+// SetMaxBufferSize sets the capacity above which buffers are released
+// instead of being returned to the pool, so that one huge record does not
+// pin its memory for the life of the process; 0 restores the default of
+// four times the buffer size.
+func (l *Logger) SetMaxBufferSize(size int) {
+	l.maxBufSize.Store(int64(max(size, 0)))
+}
+
+// SetBufferGrowth sets how buffers grow for records larger than their
+// capacity.
+func (l *Logger) SetBufferGrowth(growth BufferGrowth) {
+	l.bufGrowth.Store(int32(growth))
+}
+
+// maxPooledFields is the field capacity above which entries are released
+// instead of being returned to the pool
+const maxPooledFields = 64
+
+// entryPool recycles the entries delivered to sinks, with their fields
+var entryPool = sync.Pool{New: func() any { return new(Entry) }}
+
+// getEntry returns an empty entry from the pool
+func getEntry() *Entry {
+	return entryPool.Get().(*Entry)
+}
+
+// putEntry clears the entry and returns it to the pool. Sinks, dead-letter
+// queues and sink wrappers copy the entries they retain, so an entry is
+// free once they have returned.
+func putEntry(entry *Entry) {
+	if cap(entry.Fields) > maxPooledFields {
+		return
+	}
+	clear(entry.Fields)
+	*entry = Entry{Fields: entry.Fields[:0]}
+	entryPool.Put(entry)
+}
+
+// maxPooledSize returns the capacity above which buffers are not pooled
+func (l *Logger) maxPooledSize() int {
+	if size := l.maxBufSize.Load(); size > 0 {
+		return int(size)
+	}
+	return int(l.bufSize.Load()) * 4
+}
+
+// getBuffer returns an empty buffer with at least size bytes of capacity,
+// from the pool unless it is too large to be pooled
+func (l *Logger) getBuffer(size int) *[]byte {
+	if size > l.maxPooledSize() {
+		buf := make([]byte, 0, size)
+		return &buf
+	}
+	buf := l.pool.Get().(*[]byte)
+	if cap(*buf) < size {
+		// Pooled before SetBufferSize raised the size
+		*buf = make([]byte, 0, size)
+	}
+	*buf = (*buf)[:0]
+	return buf
+}
+
+// putBuffer returns a buffer to the pool, or releases it if it grew
+// beyond the maximum pooled size
+func (l *Logger) putBuffer(buf *[]byte) {
+	if buf == nil || cap(*buf) > l.maxPooledSize() {
+		return
+	}
+	l.pool.Put(buf)
+}
+
+// reserve ensures the event buffer can hold size bytes without growing,
+// following the growth policy. The buffer replaced goes back to the pool.
+func (e *Event) reserve(size int) {
+	if cap(*e.buf) >= size {
+		return
+	}
+	if BufferGrowth(e.logger.bufGrowth.Load()) == GrowDouble {
+		size = max(size, 2*cap(*e.buf))
+	}
+	var buf []byte
+	if len(*e.buf) == 0 {
+		buf = make([]byte, 0, size)
+	} else {
+		buf = append(make([]byte, 0, size), *e.buf...)
+	}
+	e.logger.putBuffer(e.buf)
+	e.buf = &buf
+}
+
+// release returns the event buffer to the pool and frees the event once
+// the record has been written
+func (e *Event) release() {
+	e.logger.putBuffer(e.buf)
+	freeEvent(e)
+}
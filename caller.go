@@ -0,0 +1,92 @@
+package loggo
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Keys of the fields added by SetReportCaller.
+const (
+	CallerKey     = "caller"      // File and line of the log call, e.g. "server.go:42"
+	CallerFuncKey = "caller.func" // Function of the log call, with SetReportCallerFunc
+)
+
+// packageDir is the source directory of this package, used to skip its
+// own frames when looking for the caller
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerFrame returns the first stack frame outside this package, i.e.
+// the application's log call site. Frames from this package's tests count
+// as application frames.
+func callerFrame() (runtime.Frame, bool) {
+	return callerFrameSkip(0)
+}
+
+// callerFrameSkip returns the frame skip frames above the first one
+// outside this package
+func callerFrameSkip(skip int) (runtime.Frame, bool) {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	found := false
+	for {
+		frame, more := frames.Next()
+		if !found {
+			// Frames of the standard log package belong to StdLogger
+			found = (filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go")) &&
+				!strings.HasPrefix(frame.Function, "log.")
+		}
+		if found {
+			if skip == 0 {
+				return frame, frame.PC != 0
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// SetReportCaller adds the file and line of the log call to every record
+// as a CallerKey field, e.g. caller=server.go:42, in text lines and in
+// the entries passed to sinks. Finding the caller costs a stack walk per
+// record written, so it is off by default.
+func (l *Logger) SetReportCaller(enabled bool) {
+	l.reportCaller.Store(enabled)
+}
+
+// SetReportCallerFunc also adds the function of the log call, e.g.
+// caller.func=main.(*Server).handle, while SetReportCaller is enabled.
+func (l *Logger) SetReportCallerFunc(enabled bool) {
+	l.callerFuncs.Store(enabled)
+}
+
+// WithCallerSkip returns a logger reporting the caller skip frames further
+// up the stack, for wrapper packages logging on behalf of their callers:
+// a helper calling the logger directly passes 1. Skips add up when
+// loggers are derived from one another.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	return &Logger{loggerCore: l.loggerCore, bound: l.bound, callerSkip: l.callerSkip + skip}
+}
+
+// addCaller adds the caller fields if enabled
+func (e *Event) addCaller() {
+	l := e.logger
+	if !l.reportCaller.Load() {
+		return
+	}
+	frame, ok := callerFrameSkip(l.callerSkip)
+	if !ok {
+		return
+	}
+	e.fields = append(e.fields, Str(CallerKey, filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line)))
+	if l.callerFuncs.Load() {
+		e.fields = append(e.fields, Str(CallerFuncKey, frame.Function))
+	}
+}
@@ -0,0 +1,197 @@
+package loggo
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Keys of the fields of the entries written when a Circuit trips or closes
+const (
+	CircuitLinesKey   = "circuit.lines"   // Lines per second offered when the circuit tripped
+	CircuitBytesKey   = "circuit.bytes"   // Bytes per second when the circuit tripped
+	CircuitDroppedKey = "circuit.dropped" // Records dropped while the circuit was tripped
+)
+
+// CircuitConfig configures a Circuit. Zero values select the defaults.
+type CircuitConfig struct {
+	MaxLines     int64             // Lines per second above which output is runaway (0 disables the check)
+	MaxBytes     int64             // Bytes per second above which output is runaway (0 disables the check)
+	Sustain      time.Duration     // How long a limit must be exceeded before the circuit trips (default 10s)
+	SurvivalRate int64             // Lines per second written while tripped (default 10)
+	Cooldown     time.Duration     // Time within the limits after which the circuit closes (default 1m)
+	OnTrip       func(CircuitTrip) // Called when the circuit trips, e.g. to page an operator
+}
+
+// CircuitTrip describes the output that tripped a Circuit.
+type CircuitTrip struct {
+	Time  time.Time // When the circuit tripped
+	Lines int64     // Lines offered in the last second
+	Bytes int64     // Bytes written in the last second
+}
+
+// Circuit is a safety valve for the total output of a logger: when it
+// exceeds MaxLines or MaxBytes per second for Sustain, the circuit trips
+// and only SurvivalRate lines per second are written, protecting disks
+// and collectors from runaway logging bugs. Tripping writes one CRITICAL
+// entry and calls OnTrip; once the offered output stays within the limits
+// for Cooldown, the circuit closes with a WARN entry counting the records
+// dropped. FATAL and PANIC records are always written. Rates are measured
+// over whole seconds; while tripped, the bytes of dropped records are
+// estimated from the size of the written ones. Install it with
+// Logger.SetCircuit.
+type Circuit struct {
+	config CircuitConfig
+	now    func() time.Time
+	mu     sync.Mutex
+
+	second    int64 // Unix second being counted
+	lines     int64 // Lines offered in the second
+	written   int64 // Lines written in the second
+	bytes     int64 // Bytes written in the second
+	overSince int64 // First second of the current run over the limits; 0 if within them
+	calmSince int64 // First second of the current run within the limits while tripped
+	tripped   bool
+	dropped   int64 // Records dropped since the circuit tripped
+}
+
+// NewCircuit creates a circuit with the given configuration.
+func NewCircuit(config CircuitConfig) *Circuit {
+	if config.Sustain <= 0 {
+		config.Sustain = 10 * time.Second
+	}
+	if config.SurvivalRate <= 0 {
+		config.SurvivalRate = 10
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = time.Minute
+	}
+	return &Circuit{config: config, now: time.Now}
+}
+
+// SetCircuit installs a circuit, or removes it if c is nil.
+func (l *Logger) SetCircuit(c *Circuit) {
+	l.circuit.Store(c)
+}
+
+// Tripped reports whether the circuit is throttling output.
+func (c *Circuit) Tripped() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tripped
+}
+
+// checkCircuit returns the logger's circuit and whether the event may be
+// written. Internal entries are neither counted nor throttled.
+func (e *Event) checkCircuit() (*Circuit, bool) {
+	c := e.logger.circuit.Load()
+	if c == nil || e.internal {
+		return nil, true
+	}
+	return c, c.allow(e.logger, e.level)
+}
+
+// allow counts an offered record and reports whether it may be written
+func (c *Circuit) allow(l *Logger, level Level) bool {
+	c.mu.Lock()
+	trip, closed := c.advance(c.now())
+	dropped := c.dropped
+	c.lines++
+	keep := level >= FATAL || !c.tripped || c.written < c.config.SurvivalRate
+	if !keep {
+		c.dropped++
+	}
+	c.mu.Unlock()
+
+	if trip != nil {
+		c.alert(l, *trip)
+	}
+	if closed {
+		if e := l.newEvent(WARN); e != nil {
+			e.internal = true
+			e.fields = append(e.fields, Int64(CircuitDroppedKey, dropped))
+			e.Msg("log rate back within limits; " + strconv.FormatInt(dropped, 10) + " records were dropped")
+		}
+	}
+	return keep
+}
+
+// account adds the size of a written record
+func (c *Circuit) account(size int) {
+	c.mu.Lock()
+	c.written++
+	c.bytes += int64(size)
+	c.mu.Unlock()
+}
+
+// advance evaluates the finished seconds when a new one starts, returning
+// the trip to report or whether the circuit closed; c.mu must be held
+func (c *Circuit) advance(now time.Time) (trip *CircuitTrip, closed bool) {
+	second := now.Unix()
+	if second == c.second {
+		return nil, false
+	}
+	prev, lines, bytes := c.second, c.lines, c.bytes
+	if c.tripped && c.written > 0 {
+		// Estimate the bytes of the dropped records
+		bytes = c.bytes / c.written * lines
+	}
+	c.second, c.lines, c.written, c.bytes = second, 0, 0, 0
+	if prev == 0 {
+		return nil, false
+	}
+
+	over := c.config.MaxLines > 0 && lines > c.config.MaxLines ||
+		c.config.MaxBytes > 0 && bytes > c.config.MaxBytes
+	trip, closed = c.evaluate(prev, over)
+	if trip == nil && !closed && second > prev+1 {
+		// The seconds without records were within the limits
+		if trip, closed = c.evaluate(prev+1, false); !closed {
+			trip, closed = c.evaluate(second-1, false)
+		}
+	}
+	if trip != nil {
+		*trip = CircuitTrip{Time: now, Lines: lines, Bytes: bytes}
+	}
+	return trip, closed
+}
+
+// evaluate updates the state with a finished second; c.mu must be held
+func (c *Circuit) evaluate(second int64, over bool) (trip *CircuitTrip, closed bool) {
+	switch {
+	case !c.tripped && over:
+		if c.overSince == 0 {
+			c.overSince = second
+		}
+		if time.Duration(second-c.overSince+1)*time.Second >= c.config.Sustain {
+			c.tripped, c.calmSince, c.dropped = true, 0, 0
+			return &CircuitTrip{}, false
+		}
+	case !c.tripped:
+		c.overSince = 0
+	case over:
+		c.calmSince = 0
+	default:
+		if c.calmSince == 0 {
+			c.calmSince = second
+		}
+		if time.Duration(second-c.calmSince+1)*time.Second >= c.config.Cooldown {
+			c.tripped, c.overSince = false, 0
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// alert writes the CRITICAL entry of a trip and calls OnTrip
+func (c *Circuit) alert(l *Logger, trip CircuitTrip) {
+	if e := l.newEvent(CRITICAL); e != nil {
+		e.internal = true
+		e.fields = append(e.fields, Int64(CircuitLinesKey, trip.Lines), Int64(CircuitBytesKey, trip.Bytes))
+		e.Msg("log output exceeded its rate limits for " + c.config.Sustain.String() +
+			"; throttling to " + strconv.FormatInt(c.config.SurvivalRate, 10) + " lines per second")
+	}
+	if c.config.OnTrip != nil {
+		c.config.OnTrip(trip)
+	}
+}
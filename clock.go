@@ -0,0 +1,79 @@
+package loggo
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ClockSkewKey is the key of the field added to records stamped right
+// after the wall clock jumped, holding the size of the jump, e.g. "-2.5s".
+const ClockSkewKey = "clock.skew"
+
+// TimeSource provides the timestamps of records. Now returns the current
+// time and, if the source detected a jump of the wall clock since its
+// previous call, the size of the jump; it must be safe for concurrent
+// use. Records stamped with a nonzero skew carry a ClockSkewKey field.
+type TimeSource interface {
+	Now() (now time.Time, skew time.Duration)
+}
+
+// SetTimeSource sets the source of record timestamps, e.g. a SkewClock
+// or a fake clock in tests. nil restores time.Now. Records given a time
+// with Event.Time do not use the source.
+func (l *Logger) SetTimeSource(source TimeSource) {
+	if source == nil {
+		l.timeSource.Store(nil)
+		return
+	}
+	l.timeSource.Store(&source)
+}
+
+// SkewClock is a TimeSource detecting jumps of the wall clock, such as
+// NTP steps and VM suspends, by comparing it with the monotonic clock,
+// which does not jump:
+//
+//	logger.SetTimeSource(loggo.NewSkewClock(time.Second))
+//
+// The first record stamped after the wall clock moved by at least the
+// threshold relative to the monotonic clock carries the size of the move
+// in ClockSkewKey, so out-of-order timestamps can be explained and the
+// records after it corrected in aggregation. Slow drift is reported once
+// it adds up to the threshold.
+type SkewClock struct {
+	threshold time.Duration
+	start     time.Time                                      // Wall clock at creation
+	read      func() (wall time.Time, elapsed time.Duration) // Wall clock and monotonic time since start
+	offset    atomic.Int64                                   // Wall minus monotonic time since start, as last reported
+}
+
+// NewSkewClock returns a clock reporting wall clock jumps of at least
+// threshold.
+func NewSkewClock(threshold time.Duration) *SkewClock {
+	start := time.Now()
+	return &SkewClock{
+		threshold: threshold,
+		start:     start.Round(0),
+		read: func() (time.Time, time.Duration) {
+			now := time.Now()
+			return now, now.Sub(start)
+		},
+	}
+}
+
+// Now returns the current time and the size of a wall clock jump since
+// the previous jump was reported, or 0.
+func (c *SkewClock) Now() (time.Time, time.Duration) {
+	wall, elapsed := c.read()
+	offset := wall.Sub(c.start) - elapsed
+	for {
+		last := c.offset.Load()
+		skew := offset - time.Duration(last)
+		if skew.Abs() < c.threshold {
+			return wall, 0
+		}
+		// Only one of the records racing past a jump reports it
+		if c.offset.CompareAndSwap(last, int64(offset)) {
+			return wall, skew
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package loggo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Codec compresses encoded batches before they are sent by a network sink.
+// Implementations must be safe for concurrent use.
+type Codec interface {
+	// Name returns the registry name, also used as the HTTP Content-Encoding.
+	// The "none" codec sends no Content-Encoding header.
+	Name() string
+	// Compress appends the compressed form of src to dst.
+	Compress(dst, src []byte) ([]byte, error)
+}
+
+// codecRegistry holds the codecs selectable by name
+var codecRegistry = struct {
+	sync.RWMutex
+	codecs map[string]Codec
+}{
+	codecs: map[string]Codec{
+		"none": noneCodec{},
+		"gzip": &gzipCodec{level: gzip.DefaultCompression},
+	},
+}
+
+// RegisterCodec makes a codec selectable by name, replacing any codec
+// registered under the same name. The built-in codecs are "none" and
+// "gzip"; importing github.com/milsoncodes/loggo/codecs registers "zstd"
+// and "snappy", keeping loggo itself free of dependencies.
+func RegisterCodec(codec Codec) {
+	codecRegistry.Lock()
+	defer codecRegistry.Unlock()
+	codecRegistry.codecs[codec.Name()] = codec
+}
+
+// LookupCodec returns the codec registered under name.
+// An empty name selects the "none" codec.
+func LookupCodec(name string) (Codec, error) {
+	if name == "" {
+		name = "none"
+	}
+	codecRegistry.RLock()
+	defer codecRegistry.RUnlock()
+	codec, ok := codecRegistry.codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("loggo: codec %q is not registered", name)
+	}
+	return codec, nil
+}
+
+// Codecs returns the sorted names of all registered codecs.
+func Codecs() []string {
+	codecRegistry.RLock()
+	defer codecRegistry.RUnlock()
+	names := make([]string, 0, len(codecRegistry.codecs))
+	for name := range codecRegistry.codecs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// noneCodec passes data through unchanged
+type noneCodec struct{}
+
+func (noneCodec) Name() string { return "none" }
+
+func (noneCodec) Compress(dst, src []byte) ([]byte, error) {
+	return append(dst, src...), nil
+}
+
+// gzipCodec compresses with gzip, pooling writers between calls
+type gzipCodec struct {
+	level int
+	pool  sync.Pool
+}
+
+func (c *gzipCodec) Name() string { return "gzip" }
+
+func (c *gzipCodec) Compress(dst, src []byte) ([]byte, error) {
+	out := bytes.NewBuffer(dst)
+	w, _ := c.pool.Get().(*gzip.Writer)
+	if w == nil {
+		var err error
+		if w, err = gzip.NewWriterLevel(out, c.level); err != nil {
+			return dst, err
+		}
+	} else {
+		w.Reset(out)
+	}
+	defer c.pool.Put(w)
+
+	if _, err := w.Write(src); err != nil {
+		return dst, err
+	}
+	if err := w.Close(); err != nil {
+		return dst, err
+	}
+	return out.Bytes(), nil
+}
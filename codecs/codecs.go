@@ -0,0 +1,60 @@
+// Package codecs registers the zstd and snappy codecs with loggo, for
+// network sinks whose collectors expect them. They live in their own
+// module so the core stays free of dependencies; import the package for
+// its side effect:
+//
+//	import _ "github.com/milsoncodes/loggo/codecs"
+//
+//	sink, err := loggo.NewHTTPSink(loggo.HTTPConfig{URL: url, Codec: "zstd"}, loggo.BatchConfig{})
+//
+// Snappy uses the block format, as in Prometheus remote write.
+package codecs
+
+import (
+	"slices"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/milsoncodes/loggo"
+)
+
+func init() {
+	loggo.RegisterCodec(NewZstd(zstd.SpeedDefault))
+	loggo.RegisterCodec(Snappy{})
+}
+
+// Zstd compresses with zstd.
+type Zstd struct {
+	encoder *zstd.Encoder
+}
+
+// NewZstd returns a zstd codec compressing at level. Registering it
+// replaces the codec registered by this package, e.g. to trade ratio for
+// speed.
+func NewZstd(level zstd.EncoderLevel) *Zstd {
+	// Only fails for invalid options
+	encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+	return &Zstd{encoder: encoder}
+}
+
+// Name returns "zstd".
+func (*Zstd) Name() string { return "zstd" }
+
+// Compress appends the zstd frame of src to dst.
+func (c *Zstd) Compress(dst, src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, dst), nil
+}
+
+// Snappy compresses with the snappy block format.
+type Snappy struct{}
+
+// Name returns "snappy".
+func (Snappy) Name() string { return "snappy" }
+
+// Compress appends the snappy block of src to dst.
+func (Snappy) Compress(dst, src []byte) ([]byte, error) {
+	n := len(dst)
+	dst = slices.Grow(dst, snappy.MaxEncodedLen(len(src)))
+	block := snappy.Encode(dst[n:cap(dst)], src)
+	return dst[:n+len(block)], nil
+}
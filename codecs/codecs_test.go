@@ -0,0 +1,41 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/milsoncodes/loggo"
+)
+
+func TestCodecs(t *testing.T) {
+	src := bytes.Repeat([]byte(`{"level":"info","msg":"request served"}`+"\n"), 100)
+	prefix := []byte("kept")
+
+	codec, err := loggo.LookupCodec("zstd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := codec.Compress(bytes.Clone(prefix), src)
+	if err != nil || !bytes.HasPrefix(out, prefix) || len(out) >= len(src) {
+		t.Fatalf("Expected compressed data after dst, got %d bytes (%v)", len(out), err)
+	}
+	decoder, _ := zstd.NewReader(nil)
+	defer decoder.Close()
+	if got, err := decoder.DecodeAll(out[len(prefix):], nil); err != nil || !bytes.Equal(got, src) {
+		t.Errorf("Expected zstd to round-trip, got %d bytes (%v)", len(got), err)
+	}
+
+	codec, err = loggo.LookupCodec("snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = codec.Compress(bytes.Clone(prefix), src)
+	if err != nil || !bytes.HasPrefix(out, prefix) || len(out) >= len(src) {
+		t.Fatalf("Expected compressed data after dst, got %d bytes (%v)", len(out), err)
+	}
+	if got, err := snappy.Decode(nil, out[len(prefix):]); err != nil || !bytes.Equal(got, src) {
+		t.Errorf("Expected snappy to round-trip, got %d bytes (%v)", len(got), err)
+	}
+}
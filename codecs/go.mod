@@ -0,0 +1,10 @@
+module github.com/milsoncodes/loggo/codecs
+
+go 1.24.1
+
+require (
+	github.com/klauspost/compress v1.17.4
+	github.com/milsoncodes/loggo v0.0.0
+)
+
+replace github.com/milsoncodes/loggo => ../
@@ -0,0 +1,105 @@
+package loggo
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// ComponentKey is the key of the field naming the component a logger
+// belongs to, e.g. logger.With(loggo.ComponentKey, "payments").
+const ComponentKey = "component"
+
+// compLevels maps components to their level overrides
+type compLevels map[string]Level
+
+// LevelProvider returns the current per-component level overrides, e.g.
+// evaluated from a feature-flag system. Components missing from the map
+// use the logger's level.
+type LevelProvider func(ctx context.Context) (map[string]Level, error)
+
+// SetComponentLevels overrides the level of loggers whose ComponentKey
+// field, bound with With, names one of the components. An override may
+// be lower or higher than the logger's level, so verbosity can be raised
+// for a single component without a redeploy. The map replaces any
+// previous overrides; nil or an empty map removes them. Feature-flag
+// systems that push changes can call it from their change callback.
+func (l *Logger) SetComponentLevels(levels map[string]Level) {
+	if len(levels) == 0 {
+		l.components.Store(nil)
+		return
+	}
+	overrides := compLevels(maps.Clone(levels))
+	l.components.Store(&overrides)
+}
+
+// ComponentLevels returns the current per-component level overrides.
+func (l *Logger) ComponentLevels() map[string]Level {
+	if overrides := l.components.Load(); overrides != nil {
+		return maps.Clone(*overrides)
+	}
+	return nil
+}
+
+// PollComponentLevels calls provider every interval and applies the
+// overrides it returns with SetComponentLevels, for feature-flag systems
+// that are polled. The first call completes before PollComponentLevels
+// returns. When the provider fails, the error is reported like a sink
+// error and the previous overrides are kept. The returned function stops
+// polling and cancels the context of a running call; the overrides in
+// effect are kept.
+func (l *Logger) PollComponentLevels(interval time.Duration, provider LevelProvider) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	poll := func() {
+		levels, err := provider(ctx)
+		switch {
+		case ctx.Err() != nil:
+			// Stopped while the provider ran
+		case err != nil:
+			l.reportError("Level provider error", err)
+		default:
+			l.SetComponentLevels(levels)
+		}
+	}
+	poll()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				poll()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// threshold returns the lowest level written by l, taking the overrides
+// of its component and namespace into account
+func (l *Logger) threshold() Level {
+	if overrides := l.components.Load(); overrides != nil {
+		for i := len(l.bound) - 1; i >= 0; i-- {
+			if l.bound[i].Key == ComponentKey {
+				if level, ok := (*overrides)[l.bound[i].ValueString()]; ok {
+					return level
+				}
+				break
+			}
+		}
+	}
+	if overrides := l.namespaces.Load(); overrides != nil {
+		if level, ok := overrides.namespaceLevel(l.name()); ok {
+			return level
+		}
+	}
+	return Level(l.level.Load())
+}
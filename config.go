@@ -0,0 +1,350 @@
+package loggo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is a declarative logger configuration, usually decoded from a
+// file with LoadProfiles or read from the environment with ConfigFromEnv.
+// Empty and nil values are unset: they keep the logger's default, or the
+// value inherited from a lower layer.
+type Config struct {
+	Level             string            `json:"level,omitempty"`              // Level name accepted by ParseLevel
+	Format            string            `json:"format,omitempty"`             // "text" (the default), "json" or "logfmt"
+	TimeFormat        string            `json:"time_format,omitempty"`        // Layout for SetTimeFormat
+	Outputs           []string          `json:"outputs,omitempty"`            // "stdout" (the default) and/or "stderr"
+	Colors            *bool             `json:"colors,omitempty"`             // SetColors
+	Fingerprints      *bool             `json:"fingerprints,omitempty"`       // SetFingerprints
+	EntryHashes       *bool             `json:"entry_hashes,omitempty"`       // SetEntryHashes
+	Probes            *bool             `json:"probes,omitempty"`             // SetProbes
+	ShutdownReport    *bool             `json:"shutdown_report,omitempty"`    // SetShutdownReport
+	Sampling          *int              `json:"sampling,omitempty"`           // Lines per second of an AdaptiveSampler; 0 disables sampling
+	SamplingSummaries string            `json:"sampling_summaries,omitempty"` // Interval for SetSamplingSummaries, e.g. "30s"
+	Fields            map[string]string `json:"fields,omitempty"`             // Fields bound to every record
+}
+
+// Merge returns c overridden by every value set in override. Fields are
+// merged key by key, with override winning; a field set to "" in
+// override removes the field.
+func (c Config) Merge(override Config) Config {
+	merged := c
+	for _, s := range []struct{ dst, src *string }{
+		{&merged.Level, &override.Level},
+		{&merged.Format, &override.Format},
+		{&merged.TimeFormat, &override.TimeFormat},
+		{&merged.SamplingSummaries, &override.SamplingSummaries},
+	} {
+		if *s.src != "" {
+			*s.dst = *s.src
+		}
+	}
+	for _, b := range []struct{ dst, src **bool }{
+		{&merged.Colors, &override.Colors},
+		{&merged.Fingerprints, &override.Fingerprints},
+		{&merged.EntryHashes, &override.EntryHashes},
+		{&merged.Probes, &override.Probes},
+		{&merged.ShutdownReport, &override.ShutdownReport},
+	} {
+		if *b.src != nil {
+			*b.dst = *b.src
+		}
+	}
+	if len(override.Outputs) > 0 {
+		merged.Outputs = override.Outputs
+	}
+	if override.Sampling != nil {
+		merged.Sampling = override.Sampling
+	}
+	if len(override.Fields) > 0 {
+		merged.Fields = maps.Clone(c.Fields)
+		if merged.Fields == nil {
+			merged.Fields = make(map[string]string, len(override.Fields))
+		}
+		for key, value := range override.Fields {
+			if value == "" {
+				delete(merged.Fields, key)
+			} else {
+				merged.Fields[key] = value
+			}
+		}
+	}
+	return merged
+}
+
+// Validate reports every invalid value of the configuration.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch c.Format {
+	case "", "text", "json", "logfmt":
+	default:
+		errs = append(errs, fmt.Errorf("loggo: unknown format %q", c.Format))
+	}
+	if c.TimeFormat != "" {
+		if err := validateTimeFormat(c.TimeFormat); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, output := range c.Outputs {
+		if _, err := configOutput(output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.Sampling != nil && *c.Sampling < 0 {
+		errs = append(errs, fmt.Errorf("loggo: negative sampling budget %d", *c.Sampling))
+	}
+	if c.SamplingSummaries != "" {
+		if _, err := time.ParseDuration(c.SamplingSummaries); err != nil {
+			errs = append(errs, fmt.Errorf("loggo: invalid sampling_summaries: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configOutput returns the writer of an output name
+func configOutput(name string) (io.Writer, error) {
+	switch name {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	return nil, fmt.Errorf("loggo: unknown output %q", name)
+}
+
+// NewLogger validates c and creates a logger configured by it.
+func (c Config) NewLogger() (*Logger, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	logger := New()
+	if c.Level != "" {
+		level, _ := ParseLevel(c.Level)
+		logger.SetLevel(level)
+	}
+	if c.TimeFormat != "" {
+		logger.SetTimeFormat(c.TimeFormat)
+	}
+	if len(c.Outputs) > 0 {
+		outputs := make([]io.Writer, len(c.Outputs))
+		for i, name := range c.Outputs {
+			outputs[i], _ = configOutput(name)
+		}
+		logger.SetOutputs(outputs...)
+	}
+	switch c.Format {
+	case "json":
+		logger.SetFormatter(JSONFormatter{})
+	case "logfmt":
+		logger.SetFormatter(LogfmtFormatter{})
+	}
+	if c.Sampling != nil && *c.Sampling > 0 {
+		logger.SetSampler(NewAdaptiveSampler(*c.Sampling))
+	}
+	if c.SamplingSummaries != "" {
+		interval, _ := time.ParseDuration(c.SamplingSummaries)
+		logger.SetSamplingSummaries(interval)
+	}
+	if c.Colors != nil {
+		logger.SetColors(*c.Colors)
+	}
+	if c.Fingerprints != nil {
+		logger.SetFingerprints(*c.Fingerprints)
+	}
+	if c.EntryHashes != nil {
+		logger.SetEntryHashes(*c.EntryHashes)
+	}
+	if c.Probes != nil {
+		logger.SetProbes(*c.Probes)
+	}
+	if c.ShutdownReport != nil {
+		logger.SetShutdownReport(*c.ShutdownReport)
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.Fields)) {
+		logger = logger.With(key, c.Fields[key])
+	}
+	return logger, nil
+}
+
+// Profiles is a layered configuration: a base used in every environment
+// and per-environment overrides, so one file serves dev, staging and prod:
+//
+//	{
+//	  "base": {"level": "info", "fields": {"service": "billing"}},
+//	  "profiles": {
+//	    "dev":  {"level": "debug", "colors": true},
+//	    "prod": {"format": "json", "outputs": ["stderr"], "shutdown_report": true}
+//	  }
+//	}
+type Profiles struct {
+	Base     Config            `json:"base"`
+	Profiles map[string]Config `json:"profiles,omitempty"`
+}
+
+// LoadProfiles reads profiles from a JSON file. Unknown keys are
+// rejected, so misspelled settings are not silently ignored.
+func LoadProfiles(path string) (*Profiles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeProfiles(f)
+}
+
+// DecodeProfiles reads profiles in the format of LoadProfiles from r.
+func DecodeProfiles(r io.Reader) (*Profiles, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var p Profiles
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("loggo: invalid config: %w", err)
+	}
+	return &p, nil
+}
+
+// Resolve returns the configuration of an environment: the base
+// overridden by the profile of that name (see Config.Merge). An empty
+// name selects the base alone; an unknown name is an error, so a typo in
+// a deployment does not fall back to the base unnoticed.
+func (p *Profiles) Resolve(profile string) (Config, error) {
+	if profile == "" {
+		return p.Base, nil
+	}
+	override, ok := p.Profiles[profile]
+	if !ok {
+		return Config{}, fmt.Errorf("loggo: unknown profile %q", profile)
+	}
+	return p.Base.Merge(override), nil
+}
+
+// envSetters maps the LOGGO_* environment variables to the configuration
+var envSetters = map[string]func(c *Config, value string) error{
+	"LOGGO_LEVEL":              func(c *Config, v string) error { c.Level = v; return nil },
+	"LOGGO_FORMAT":             func(c *Config, v string) error { c.Format = v; return nil },
+	"LOGGO_TIME_FORMAT":        func(c *Config, v string) error { c.TimeFormat = v; return nil },
+	"LOGGO_OUTPUTS":            func(c *Config, v string) error { c.Outputs = splitList(v); return nil },
+	"LOGGO_COLORS":             func(c *Config, v string) error { return parseEnvBool(&c.Colors, v) },
+	"LOGGO_FINGERPRINTS":       func(c *Config, v string) error { return parseEnvBool(&c.Fingerprints, v) },
+	"LOGGO_ENTRY_HASHES":       func(c *Config, v string) error { return parseEnvBool(&c.EntryHashes, v) },
+	"LOGGO_PROBES":             func(c *Config, v string) error { return parseEnvBool(&c.Probes, v) },
+	"LOGGO_SHUTDOWN_REPORT":    func(c *Config, v string) error { return parseEnvBool(&c.ShutdownReport, v) },
+	"LOGGO_SAMPLING_SUMMARIES": func(c *Config, v string) error { c.SamplingSummaries = v; return nil },
+	"LOGGO_SAMPLING": func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		c.Sampling = &n
+		return err
+	},
+	"LOGGO_FIELDS": func(c *Config, v string) error {
+		c.Fields = make(map[string]string)
+		for _, pair := range splitList(v) {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			c.Fields[key] = value
+		}
+		return nil
+	},
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEnvBool sets dst to the boolean value of s
+func parseEnvBool(dst **bool, s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*dst = &b
+	return nil
+}
+
+// ConfigFromEnv reads the configuration from LOGGO_* environment
+// variables, for deployments configured without files. Each variable sets
+// the Config value of the same name:
+//
+//	LOGGO_LEVEL               debug, info, warn, error, crit, fatal or panic
+//	LOGGO_FORMAT              text, json or logfmt
+//	LOGGO_TIME_FORMAT         Go time layout, e.g. 2006-01-02T15:04:05Z07:00
+//	LOGGO_OUTPUTS             comma-separated stdout and stderr
+//	LOGGO_SAMPLING            lines per second kept by an adaptive sampler
+//	LOGGO_SAMPLING_SUMMARIES  interval of sampling summaries, e.g. 30s
+//	LOGGO_FIELDS              comma-separated key=value fields
+//	LOGGO_COLORS, LOGGO_FINGERPRINTS, LOGGO_ENTRY_HASHES,
+//	LOGGO_PROBES, LOGGO_SHUTDOWN_REPORT
+//	                          true or false
+//
+// If LOGGO_CONFIG names a profiles file, the variables override the
+// profile selected by LOGGO_PROFILE (the base if unset), so precedence
+// from lowest to highest is base, profile, environment. Empty variables
+// are ignored. Unknown LOGGO_* variables and invalid values are reported
+// together in the returned error.
+func ConfigFromEnv() (Config, error) {
+	var base Config
+	if path := os.Getenv("LOGGO_CONFIG"); path != "" {
+		profiles, err := LoadProfiles(path)
+		if err == nil {
+			base, err = profiles.Resolve(os.Getenv("LOGGO_PROFILE"))
+		}
+		if err != nil {
+			return Config{}, err
+		}
+	} else if profile := os.Getenv("LOGGO_PROFILE"); profile != "" {
+		return Config{}, fmt.Errorf("loggo: LOGGO_PROFILE %q is set without LOGGO_CONFIG", profile)
+	}
+
+	var env Config
+	var errs []error
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, "LOGGO_") || name == "LOGGO_CONFIG" || name == "LOGGO_PROFILE" || value == "" {
+			continue
+		}
+		set, ok := envSetters[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("loggo: unknown variable %s", name))
+			continue
+		}
+		if err := set(&env, value); err != nil {
+			errs = append(errs, fmt.Errorf("loggo: invalid %s: %w", name, err))
+		}
+	}
+	config := base.Merge(env)
+	if err := errors.Join(append(errs, config.Validate())...); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// NewFromEnv creates a logger configured by ConfigFromEnv, failing on
+// invalid configuration rather than starting with defaults.
+func NewFromEnv() (*Logger, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return config.NewLogger()
+}
@@ -0,0 +1,242 @@
+package loggo
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ColorScope selects which part of a text line is colored by level.
+type ColorScope int
+
+// Color scopes.
+const (
+	ScopeLevel   ColorScope = iota // Only the level tag, the default
+	ScopeMessage                   // The level tag and the message
+	ScopeLine                      // The whole line, fields included
+)
+
+// String returns the name of the scope.
+func (s ColorScope) String() string {
+	switch s {
+	case ScopeLevel:
+		return "level"
+	case ScopeMessage:
+		return "message"
+	case ScopeLine:
+		return "line"
+	}
+	return "ColorScope(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ColorMode selects when text lines are colored.
+type ColorMode int32
+
+// Color modes.
+const (
+	ColorModeAuto   ColorMode = iota // Only while every output is a terminal and NO_COLOR is unset, the default
+	ColorModeAlways                  // Always, e.g. for output piped to a pager that renders colors
+	ColorModeNever                   // Never
+)
+
+// String returns the name of the mode.
+func (m ColorMode) String() string {
+	switch m {
+	case ColorModeAuto:
+		return "auto"
+	case ColorModeAlways:
+		return "always"
+	case ColorModeNever:
+		return "never"
+	}
+	return "ColorMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// SetColorMode sets when text lines are colored. With ColorModeAuto, the
+// default, lines are colored only while every output is a terminal and
+// the NO_COLOR environment variable is unset or empty, so output piped to
+// files, journald or other programs has no escape sequences. While colors
+// are off, no ANSI escape sequences are emitted at all: escape sequences
+// contained in messages and field values are removed from the text lines
+// and from the messages passed to hooks and sinks too.
+func (l *Logger) SetColorMode(mode ColorMode) {
+	l.colorMode.Store(int32(mode))
+}
+
+// colors reports whether text lines are colored
+func (l *Logger) colors() bool {
+	switch ColorMode(l.colorMode.Load()) {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+	return !l.noColorEnv && l.output.terminal.Load()
+}
+
+// SetColorScope sets how much of each text line is colored by level:
+// ScopeLine makes errors stand out when scanning dense console output.
+// It has no effect while colors are disabled.
+func (l *Logger) SetColorScope(scope ColorScope) {
+	l.colorScope.Store(int32(scope))
+}
+
+// Columns aligns text lines into columns, so that the output of several
+// components lines up when read in a terminal. The zero value writes
+// lines unaligned.
+type Columns struct {
+	CallerWidth  int // Write the caller from SetReportCaller as a column of this width before the message instead of with the fields; 0 disables the column
+	MessageWidth int // Pad messages followed by fields to this width so the fields line up; 0 disables padding
+}
+
+// SetColumns sets how text lines are aligned into columns, e.g.
+// Columns{CallerWidth: 20, MessageWidth: 40}.
+func (l *Logger) SetColumns(columns Columns) {
+	if columns == (Columns{}) {
+		l.columns.Store(nil)
+		return
+	}
+	l.columns.Store(&columns)
+}
+
+// appendCaller appends the caller column, padded to its width
+func (c *Columns) appendCaller(buf []byte, value string) []byte {
+	if needsEscape(value) {
+		value = EscapeControl.Apply(value)
+	}
+	buf = append(buf, value...)
+	return appendPadding(buf, max(c.CallerWidth-utf8.RuneCountInString(value), 0)+1)
+}
+
+// appendPadding appends n spaces
+func appendPadding(buf []byte, n int) []byte {
+	const spaces = "                                "
+	for n > len(spaces) {
+		buf = append(buf, spaces...)
+		n -= len(spaces)
+	}
+	if n > 0 {
+		buf = append(buf, spaces[:n]...)
+	}
+	return buf
+}
+
+// caller returns the file and line added by addCaller, or "" without one
+func (e *Event) caller() string {
+	for i := len(e.fields) - 1; i >= 0; i-- {
+		if e.fields[i].Key == CallerKey {
+			return e.fields[i].ValueString()
+		}
+	}
+	return ""
+}
+
+// inColumn reports whether field is written as the caller column
+func (c *Columns) inColumn(field Field) bool {
+	return c.CallerWidth > 0 && field.Key == CallerKey
+}
+
+// hasFields reports whether fields has any written after the message
+func (c *Columns) hasFields(fields []Field) bool {
+	for i := range fields {
+		if !c.inColumn(fields[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendFields appends the fields other than the caller column
+func (c *Columns) appendFields(buf []byte, fields []Field) []byte {
+	for i := range fields {
+		if !c.inColumn(fields[i]) {
+			buf = appendTextFields(buf, fields[i:i+1])
+		}
+	}
+	return buf
+}
+
+// isTerminal reports whether w is a terminal, i.e. a character device
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// lineColors are the escape sequences written into a text line
+type lineColors struct {
+	tag, tagReset string // Around the level tag
+	msg, msgReset string // Around the message
+	end           string // Before the newline
+}
+
+// lineColors returns the escape sequences of a text line at level
+func (l *Logger) lineColors(level Level) lineColors {
+	color := l.levelColor(level)
+	if color == "" || !l.colors() {
+		return lineColors{}
+	}
+	switch ColorScope(l.colorScope.Load()) {
+	case ScopeMessage:
+		return lineColors{tag: color, tagReset: colorReset, msg: color, msgReset: colorReset}
+	case ScopeLine:
+		return lineColors{tag: color, end: colorReset}
+	}
+	return lineColors{tag: color, tagReset: colorReset}
+}
+
+// beginLine writes the level tag and timestamp that start a text line,
+// reserving room for a message of about msgLen bytes, and returns the
+// line's colors and the offset the message starts at
+func (e *Event) beginLine(timestamp string, msgLen int) (lineColors, int) {
+	colors := e.logger.lineColors(e.level)
+	tag := e.logger.levelTag(e.level)
+	e.reserve(len(colors.tag) + len(tag) + len(colors.tagReset) + 1 + len(timestamp) + 2 +
+		len(colors.msg) + msgLen + len(colors.msgReset) + len(colors.end) + 1)
+
+	buf := append(*e.buf, colors.tag...)
+	buf = append(buf, tag...)
+	buf = append(buf, colors.tagReset...)
+	buf = append(buf, ' ')
+	buf = append(buf, timestamp...)
+	buf = append(buf, ": "...)
+	if columns := e.logger.columns.Load(); columns != nil && columns.CallerWidth > 0 {
+		buf = columns.appendCaller(buf, e.caller())
+	}
+	buf = append(buf, colors.msg...)
+	*e.buf = buf
+	return colors, len(buf)
+}
+
+// endLine checks and escapes the message written from start, then writes
+// the fields and ends the line
+func (e *Event) endLine(colors lineColors, start int) {
+	l := e.logger
+	buf := l.validateMessage(*e.buf, start)
+	buf = l.escapeMessage(buf, start)
+	buf = append(buf, colors.msgReset...)
+	envFields := l.loadEnvFields()
+	if columns := l.columns.Load(); columns == nil || columns.CallerWidth <= 0 && columns.MessageWidth <= 0 {
+		buf = appendTextFields(buf, envFields)
+		buf = appendTextFields(buf, e.textFields())
+	} else {
+		fields := e.textFields()
+		if columns.MessageWidth > 0 && (columns.hasFields(envFields) || columns.hasFields(fields)) {
+			n := utf8.RuneCount(buf[start:]) - len(colors.msgReset)
+			buf = appendPadding(buf, columns.MessageWidth-n)
+		}
+		buf = columns.appendFields(buf, envFields)
+		buf = columns.appendFields(buf, fields)
+	}
+	buf = append(buf, colors.end...)
+	buf = append(buf, '\n')
+	buf = e.appendStackBlock(buf)
+	if !l.colors() {
+		buf = stripANSIInPlace(buf)
+	}
+	*e.buf = buf
+}
@@ -0,0 +1,46 @@
+package loggo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ContainerKey is the field naming the container a record came from.
+const ContainerKey = "container.name"
+
+// ContainerLogger turns the output of a container into records, one per
+// line, with a ContainerKey field and a StreamKey field of "stdout" or
+// "stderr", so container logs of integration tests read like the
+// application's own. The logconsumer module adapts it to the LogConsumer
+// interface of testcontainers-go. It is safe for concurrent use.
+type ContainerLogger struct {
+	stdout *LineWriter
+	stderr *LineWriter
+}
+
+// NewContainerLogger returns a logger for the output of the named
+// container, writing its lines at level with the given fields.
+func (l *Logger) NewContainerLogger(container string, level Level, fields ...Field) *ContainerLogger {
+	stream := func(name string) *LineWriter {
+		all := make([]Field, 0, len(fields)+2)
+		all = append(all, Str(ContainerKey, container), Str(StreamKey, name))
+		return l.NewLineWriter(level, append(all, fields...)...)
+	}
+	return &ContainerLogger{stdout: stream("stdout"), stderr: stream("stderr")}
+}
+
+// Log logs content written by the container to stream, "STDOUT" or
+// "STDERR" as testcontainers-go reports them; other streams count as
+// standard output. Lines split across calls are joined.
+func (c *ContainerLogger) Log(stream string, content []byte) {
+	w := c.stdout
+	if strings.EqualFold(stream, "stderr") {
+		w = c.stderr
+	}
+	w.Write(content)
+}
+
+// Close logs the final partial lines, if any.
+func (c *ContainerLogger) Close() error {
+	return errors.Join(c.stdout.Close(), c.stderr.Close())
+}
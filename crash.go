@@ -0,0 +1,39 @@
+package loggo
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// crashStderr is where crash mirroring always writes; a variable for tests
+var crashStderr io.Writer = os.Stderr
+
+// crashFacility forwards a final message to the operating system's crash
+// or event facility; replaced in tests
+var crashFacility = writeOSFacility
+
+// SetCrashMirror enables or disables mirroring of FATAL and PANIC records
+// to the operating system, in addition to the configured outputs and
+// sinks. Mirrored records are always written to stderr and also to the
+// Windows Event Log, macOS unified logging or syslog, so the final words of
+// a dying process land somewhere visible even if its outputs are broken.
+func (l *Logger) SetCrashMirror(enabled bool) {
+	l.crashMirror.Store(enabled)
+}
+
+// mirrorCrash writes a FATAL or PANIC line to stderr and the OS facility.
+// Errors are ignored: there is nowhere left to report them.
+func mirrorCrash(level Level, line []byte) {
+	crashStderr.Write(line)
+	crashFacility(level, crashTag(), strings.TrimRight(StripANSI(string(line)), "\n"))
+}
+
+// crashTag returns the program name used as the event source
+func crashTag() string {
+	if len(os.Args) > 0 && os.Args[0] != "" {
+		return filepath.Base(os.Args[0])
+	}
+	return "loggo"
+}
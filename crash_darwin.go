@@ -0,0 +1,11 @@
+//go:build darwin
+
+package loggo
+
+import "os/exec"
+
+// writeOSFacility sends the message to macOS unified logging through
+// logger(1), avoiding a cgo dependency on os_log
+func writeOSFacility(level Level, tag, msg string) {
+	exec.Command("/usr/bin/logger", "-p", "user.crit", "-t", tag, msg).Run()
+}
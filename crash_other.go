@@ -0,0 +1,7 @@
+//go:build plan9 || js || wasip1
+
+package loggo
+
+// writeOSFacility is a no-op on platforms without a system log; the
+// record is still mirrored to stderr
+func writeOSFacility(level Level, tag, msg string) {}
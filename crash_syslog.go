@@ -0,0 +1,20 @@
+//go:build !windows && !darwin && !plan9 && !js && !wasip1
+
+package loggo
+
+import "log/syslog"
+
+// writeOSFacility sends the message to the local syslog daemon, which
+// forwards it to the journal on systemd hosts
+func writeOSFacility(level Level, tag, msg string) {
+	w, err := syslog.New(syslog.LOG_CRIT|syslog.LOG_USER, tag)
+	if err != nil {
+		return
+	}
+	defer w.Close()
+	if level == PANIC {
+		w.Alert(msg)
+		return
+	}
+	w.Crit(msg)
+}
@@ -0,0 +1,50 @@
+//go:build windows
+
+package loggo
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Event log entry types
+const (
+	eventlogErrorType = 0x0001
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+)
+
+// writeOSFacility reports the message as an error in the Windows Event Log
+func writeOSFacility(level Level, tag, msg string) {
+	source, err := syscall.UTF16PtrFromString(tag)
+	if err != nil {
+		return
+	}
+	text, err := syscall.UTF16PtrFromString(msg)
+	if err != nil {
+		return
+	}
+	handle, _, _ := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(source)))
+	if handle == 0 {
+		return
+	}
+	defer procDeregisterEventSource.Call(handle)
+
+	strs := []*uint16{text}
+	procReportEventW.Call(
+		handle,
+		eventlogErrorType,
+		0,             // category
+		uintptr(1000), // event ID
+		0,             // user SID
+		1,             // number of strings
+		0,             // raw data size
+		uintptr(unsafe.Pointer(&strs[0])),
+		0, // raw data
+	)
+}
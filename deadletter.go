@@ -0,0 +1,119 @@
+package loggo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Fields added to dead-lettered entries.
+const (
+	DeadLetterSinkKey   = "dead_letter.sink"   // Type of the sink that failed
+	DeadLetterReasonKey = "dead_letter.reason" // Delivery error
+	DeadLetterTimeKey   = "dead_letter.time"   // When the entry was given up on
+)
+
+// errQueueFull is the reason for entries dropped by a full sink queue
+var errQueueFull = errors.New("loggo: sink queue full")
+
+// DeadLetter receives entries that could not be delivered, so that
+// nothing disappears without a trace. It is set on a logger with
+// SetDeadLetter for sinks that fail synchronously, and in BatchConfig and
+// GRPCConfig for entries dropped after retries or by backpressure.
+type DeadLetter interface {
+	// DeadLetter stores entries that sink failed to deliver because of
+	// reason. The entries must not be retained after it returns.
+	DeadLetter(sink string, reason error, entries ...*Entry)
+}
+
+// DeadLetterStats reports the activity of a DeadLetterFile.
+type DeadLetterStats struct {
+	Entries int64 // Entries written to the file
+	Errors  int64 // Entries that could not be written
+}
+
+// DeadLetterFile appends undeliverable entries to a local file as JSON
+// lines, with the failing sink, the error and the time recorded in
+// DeadLetterSinkKey, DeadLetterReasonKey and DeadLetterTimeKey fields.
+// The lines can be read back with ParseJSON for replay.
+type DeadLetterFile struct {
+	mu    sync.Mutex
+	file  *os.File
+	buf   []byte
+	stats DeadLetterStats
+}
+
+// NewDeadLetterFile opens or creates a dead-letter file for appending.
+func NewDeadLetterFile(path string) (*DeadLetterFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetterFile{file: file}, nil
+}
+
+// DeadLetter appends the entries with their failure metadata, in a
+// single write.
+func (d *DeadLetterFile) DeadLetter(sink string, reason error, entries ...*Entry) {
+	meta := []Field{
+		Str(DeadLetterSinkKey, sink),
+		Str(DeadLetterReasonKey, fmt.Sprint(reason)),
+		Str(DeadLetterTimeKey, time.Now().Format(time.RFC3339Nano)),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	buf := d.buf[:0]
+	for _, entry := range entries {
+		record := *entry
+		record.Fields = append(entry.Fields[:len(entry.Fields):len(entry.Fields)], meta...)
+		buf = append(AppendJSON(buf, &record), '\n')
+	}
+	d.buf = buf
+	if d.file == nil {
+		d.stats.Errors += int64(len(entries))
+		return
+	}
+	if _, err := d.file.Write(buf); err != nil {
+		d.stats.Errors += int64(len(entries))
+		return
+	}
+	d.stats.Entries += int64(len(entries))
+}
+
+// Stats returns a snapshot of the file's counters.
+func (d *DeadLetterFile) Stats() DeadLetterStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// Close syncs and closes the file. Entries dead-lettered afterwards are
+// counted as errors.
+func (d *DeadLetterFile) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.file == nil {
+		return nil
+	}
+	err := errors.Join(d.file.Sync(), d.file.Close())
+	d.file = nil
+	return err
+}
+
+// SetDeadLetter sets where entries go when a sink's WriteEntry fails, or
+// removes it if dl is nil. The failure is still reported as a sink error.
+func (l *Logger) SetDeadLetter(dl DeadLetter) {
+	if dl == nil {
+		l.deadLetter.Store(nil)
+		return
+	}
+	l.deadLetter.Store(&dl)
+}
+
+// sinkName identifies a sink or sender in dead-letter records
+func sinkName(v any) string {
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,154 @@
+package loggo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// DebugInfo is a snapshot of a logger's configuration and health,
+// as shown by DebugHandler.
+type DebugInfo struct {
+	Level        string          `json:"level"`
+	TimeFormat   string          `json:"time_format"`
+	Outputs      int             `json:"outputs"`
+	Hooks        []int           `json:"hook_priorities"`
+	HookQueue    int             `json:"hook_queue"`
+	HookCapacity int             `json:"hook_queue_capacity"`
+	Sinks        []SinkInfo      `json:"sinks"`
+	ErrorCount   int64           `json:"error_count"`
+	RecentErrors []InternalError `json:"recent_errors"`
+}
+
+// SinkInfo describes a registered sink on the debug page.
+type SinkInfo struct {
+	Type  string `json:"type"`
+	Stats any    `json:"stats,omitempty"`
+}
+
+// DebugInfo returns a snapshot of the logger's configuration and health.
+func (l *Logger) DebugInfo() DebugInfo {
+	info := DebugInfo{
+		Level:      l.Level().String(),
+		TimeFormat: l.timeCache.Load().format,
+		Outputs:    l.output.count(),
+	}
+	l.mu.Lock()
+	for _, hook := range l.hooks {
+		info.Hooks = append(info.Hooks, hook.priority)
+	}
+	l.mu.Unlock()
+
+	if l.workerPool != nil {
+		info.HookQueue, info.HookCapacity = l.workerPool.queued()
+	}
+	for _, sink := range l.loadSinks() {
+		info.Sinks = append(info.Sinks, SinkInfo{Type: fmt.Sprintf("%T", sink), Stats: sinkStats(sink)})
+	}
+	info.RecentErrors, info.ErrorCount = l.errors.snapshot()
+	return info
+}
+
+// sinkStats returns the counters of the built-in sinks that expose them
+func sinkStats(sink Sink) any {
+	switch s := sink.(type) {
+	case *BatchSink:
+		return s.Stats()
+	case *JSONLFileSink:
+		return s.Stats()
+	case *GRPCSink:
+		return s.Stats()
+	case *SocketSink:
+		return s.Stats()
+	case *StatsDSink:
+		return s.Stats()
+	case *AlertSink:
+		return s.Stats()
+	case *EmailSink:
+		return s.Stats()
+	case *BroadcastSink:
+		return map[string]int64{"dropped": s.Dropped()}
+	default:
+		return nil
+	}
+}
+
+// debugTemplate renders the debug page
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html><head><title>/debug/loggo</title></head>
+<body>
+<h1>loggo</h1>
+<form method="post">
+Level: <select name="level">
+{{range .Levels}}<option{{if eq . $.Info.Level}} selected{{end}}>{{.}}</option>{{end}}
+</select>
+<input type="submit" value="Set level">
+</form>
+<table>
+<tr><td>Time format</td><td>{{.Info.TimeFormat}}</td></tr>
+<tr><td>Outputs</td><td>{{.Info.Outputs}}</td></tr>
+<tr><td>Hooks</td><td>{{len .Info.Hooks}} (priorities {{.Info.Hooks}})</td></tr>
+<tr><td>Hook queue</td><td>{{.Info.HookQueue}} / {{.Info.HookCapacity}}</td></tr>
+</table>
+<h2>Sinks</h2>
+<ul>{{range .Info.Sinks}}<li>{{.Type}}{{if .Stats}}: {{printf "%+v" .Stats}}{{end}}</li>{{end}}</ul>
+<h2>Recent errors ({{.Info.ErrorCount}} total)</h2>
+<ul>{{range .Info.RecentErrors}}<li>{{.Time.Format "2006-01-02 15:04:05"}} {{.Source}}: {{.Message}}</li>{{end}}</ul>
+<p><a href="?format=json">JSON</a></p>
+</body></html>
+`))
+
+// DebugHandler returns an http.Handler showing the logger's configuration,
+// hooks, sink statistics and recent internal errors. GET renders an HTML
+// page (or JSON with ?format=json); POST with a "level" form value changes
+// the logging level. Mount it on a debug-only port, e.g. with HandleDebug.
+func DebugHandler(l *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			level, err := ParseLevel(r.FormValue("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetLevel(level)
+			if r.URL.Query().Get("format") == "json" {
+				break
+			}
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		info := l.DebugInfo()
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(info)
+			return
+		}
+
+		levels := make([]string, 0, PANIC-DEBUG+1)
+		for level := DEBUG; level <= PANIC; level++ {
+			levels = append(levels, level.String())
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, struct {
+			Info   DebugInfo
+			Levels []string
+		}{info, levels})
+	})
+}
+
+// HandleDebug registers DebugHandler for l at /debug/loggo on mux,
+// or on http.DefaultServeMux if mux is nil, mirroring net/http/pprof.
+func HandleDebug(mux *http.ServeMux, l *Logger) {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.Handle("/debug/loggo", DebugHandler(l))
+}
@@ -0,0 +1,137 @@
+package loggo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RepeatedKey is the field holding the number of repeats in the record
+// ending a burst of identical records.
+const RepeatedKey = "repeated"
+
+// dedupeState tracks the last record written to collapse the identical
+// records following it
+type dedupeState struct {
+	window  atomic.Int64 // Nanoseconds a burst lasts at most; 0 disables deduplication
+	mu      sync.Mutex
+	key     string    // Level, message and fields of the last record written
+	start   time.Time // Time the last record was written
+	repeats int       // Identical records dropped since
+	level   Level
+	message string
+	fields  []Field
+	timer   *time.Timer // Ends the burst at the end of the window
+}
+
+// repeatedBurst is a burst of identical records to report
+type repeatedBurst struct {
+	level   Level
+	message string
+	fields  []Field
+	repeats int
+}
+
+// SetDedupe collapses consecutive identical records, with the same level,
+// message and fields, within window of the first: the first is written
+// and the others are dropped until the burst ends, when a different
+// record is logged, the window is over, or on Flush and Close. One record
+// then reports the burst with the message followed by " (repeated N
+// times)" and the number of dropped records in a RepeatedKey field,
+// much like the kernel log does for retry loops. A window of 0, the
+// default, disables deduplication. FATAL and PANIC records are never
+// dropped.
+func (l *Logger) SetDedupe(window time.Duration) {
+	l.dedupe.window.Store(int64(max(window, 0)))
+	if window <= 0 {
+		l.flushDedupe()
+	}
+}
+
+// dedupe reports whether the event should be written, or is dropped as a
+// repeat of the last record; it is called once the fields are resolved
+func (e *Event) dedupe(format string, args []any) bool {
+	d := &e.logger.dedupe
+	window := time.Duration(d.window.Load())
+	if window == 0 || e.internal || e.level >= FATAL {
+		return true
+	}
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	key := dedupeKey(e.level, message, e.fields)
+	now := time.Now()
+
+	d.mu.Lock()
+	if key == d.key && now.Sub(d.start) < window {
+		if d.repeats == 0 {
+			d.level, d.message, d.fields = e.level, message, slices.Clone(e.fields)
+			d.timer = time.AfterFunc(d.start.Add(window).Sub(now), e.logger.flushDedupe)
+		}
+		d.repeats++
+		d.mu.Unlock()
+		return false
+	}
+	burst := d.take()
+	d.key, d.start = key, now
+	d.mu.Unlock()
+
+	e.logger.reportRepeated(burst)
+	return true
+}
+
+// take returns the current burst, if any records were dropped, and
+// forgets the last record; d.mu must be held
+func (d *dedupeState) take() *repeatedBurst {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	var burst *repeatedBurst
+	if d.repeats > 0 {
+		burst = &repeatedBurst{level: d.level, message: d.message, fields: d.fields, repeats: d.repeats}
+	}
+	d.key, d.repeats, d.message, d.fields = "", 0, "", nil
+	return burst
+}
+
+// flushDedupe ends the current burst
+func (l *Logger) flushDedupe() {
+	d := &l.dedupe
+	d.mu.Lock()
+	burst := d.take()
+	d.mu.Unlock()
+	l.reportRepeated(burst)
+}
+
+// reportRepeated writes the record ending a burst
+func (l *Logger) reportRepeated(burst *repeatedBurst) {
+	if burst == nil {
+		return
+	}
+	e := l.newEvent(burst.level)
+	if e == nil {
+		return
+	}
+	e.internal = true
+	e.fields = append(append(e.fields[:0], burst.fields...), Int(RepeatedKey, burst.repeats))
+	e.Msg(burst.message + " (repeated " + strconv.Itoa(burst.repeats) + " times)")
+}
+
+// dedupeKey returns the identity of a record
+func dedupeKey(level Level, message string, fields []Field) string {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, byte(level))
+	buf = append(buf, message...)
+	for _, f := range fields {
+		buf = append(buf, 0)
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = append(buf, f.ValueString()...)
+	}
+	return string(buf)
+}
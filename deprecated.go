@@ -0,0 +1,34 @@
+package loggo
+
+import "sync"
+
+// Keys of the fields added by Deprecated.
+const (
+	DeprecatedKey       = "deprecated"        // Name of the deprecated feature
+	DeprecatedSunsetKey = "deprecated.sunset" // Version the feature is removed in
+)
+
+// deprecations holds the features already reported by Deprecated
+var deprecations sync.Map
+
+// Deprecated logs a WARN record about the use of a deprecated feature, at
+// most once per feature per process, for libraries giving deprecation
+// notices that do not flood their users' logs:
+//
+//	logger.Deprecated("Client.Fetch", "v3.0.0", "use Client.Get instead")
+//
+// The record carries the feature in DeprecatedKey and the version it is
+// removed in in DeprecatedSunsetKey. A notice filtered by the level is
+// not counted, so it is written once WARN is enabled.
+func (l *Logger) Deprecated(feature, sunsetVersion, msg string) {
+	if _, reported := deprecations.LoadOrStore(feature, struct{}{}); reported {
+		return
+	}
+	e := l.newEvent(WARN)
+	if e == nil {
+		deprecations.Delete(feature)
+		return
+	}
+	e.fields = append(e.fields, Str(DeprecatedKey, feature), Str(DeprecatedSunsetKey, sunsetVersion))
+	e.Msg(msg)
+}
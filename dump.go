@@ -0,0 +1,107 @@
+package loggo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DumpState writes a snapshot for debugging a stuck process to
+// emergency: the stacks of all goroutines, the logger's configuration
+// and sink health as returned by DebugInfo, and the records kept by
+// SetReplayBuffer, oldest first. emergency is written directly, not
+// through the logger's outputs, sinks or asynchronous queue, which may be
+// what is stuck; the stacks come first so they are written even if the
+// logger itself does not respond.
+func (l *Logger) DumpState(emergency io.Writer) error {
+	var errs []error
+	write := func(p []byte) {
+		_, err := emergency.Write(p)
+		errs = append(errs, err)
+	}
+
+	write(fmt.Appendf(nil, "=== loggo state dump at %s, pid %d ===\n", time.Now().Format(time.RFC3339Nano), os.Getpid()))
+	write(append([]byte("\n--- goroutines ---\n"), allStacks()...))
+
+	info, err := json.MarshalIndent(l.DebugInfo(), "", "  ")
+	errs = append(errs, err)
+	write(append(append([]byte("\n--- configuration and sinks ---\n"), info...), '\n'))
+
+	l.mu.Lock()
+	records := l.replay.recent(int(l.replay.size.Load()))
+	l.mu.Unlock()
+	buf := fmt.Appendf(nil, "\n--- recent records (%d) ---\n", len(records))
+	for _, r := range records {
+		buf = append(buf, r.level.String()...)
+		buf = append(buf, ' ')
+		buf = append(buf, r.msg...)
+		buf = append(buf, '\n')
+	}
+	write(buf)
+	return errors.Join(errs...)
+}
+
+// DumpOnSignal calls DumpState with emergency, or stderr if it is nil,
+// whenever one of the signals arrives, for a one-signal snapshot of a
+// stuck process. On Unix the default is SIGQUIT:
+//
+//	stop := logger.DumpOnSignal(os.Stderr) // kill -QUIT <pid>
+//	defer stop()
+//
+// Handling SIGQUIT replaces the Go runtime's default of printing the
+// stacks and exiting, so the process keeps running. Other platforms have
+// no default, and DumpOnSignal without signals does nothing there. Errors
+// writing the dump are listed on the debug page. The returned function
+// removes the handler.
+func (l *Logger) DumpOnSignal(emergency io.Writer, signals ...os.Signal) (stop func()) {
+	if emergency == nil {
+		emergency = os.Stderr
+	}
+	if len(signals) == 0 {
+		signals = defaultDumpSignals
+	}
+	if len(signals) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := l.DumpState(emergency); err != nil {
+					l.errors.add("State dump", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// allStacks returns the stacks of all goroutines
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
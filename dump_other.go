@@ -0,0 +1,9 @@
+//go:build !unix
+
+package loggo
+
+import "os"
+
+// defaultDumpSignals is empty on platforms without SIGQUIT, so
+// DumpOnSignal does nothing unless given signals
+var defaultDumpSignals = []os.Signal{}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package loggo
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultDumpSignals are the signals DumpOnSignal handles by default
+var defaultDumpSignals = []os.Signal{syscall.SIGQUIT}
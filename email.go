@@ -0,0 +1,279 @@
+package loggo
+
+import (
+	"cmp"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailConfig configures an EmailSink. Zero values select the defaults.
+type EmailConfig struct {
+	Addr     string   // SMTP server "host:port"
+	Username string   // Enables PLAIN authentication (only over TLS or to localhost)
+	Password string   // Password for Username
+	From     string   // Envelope and header sender
+	To       []string // Recipients
+	Subject  string   // Subject prefix (default "[loggo]")
+
+	ImmediateLevel Level         // Entries at or above this level are mailed at once (default FATAL)
+	DigestLevel    Level         // Entries from this level up to ImmediateLevel go to the digest (default ERROR)
+	DigestInterval time.Duration // How often the digest is mailed (default 1h)
+
+	ImplicitTLS bool          // Connect with TLS (port 465) instead of upgrading with STARTTLS
+	TLS         *TLSConfig    // TLS settings (default: system roots, server name from Addr)
+	Timeout     time.Duration // Timeout for delivering one mail (default 30s)
+	OnError     func(error)   // Called when a mail cannot be delivered
+}
+
+// EmailStats reports the activity of an EmailSink.
+type EmailStats struct {
+	Immediate int64 // Immediate mails sent
+	Digests   int64 // Digest mails sent
+	Errors    int64 // Mails that could not be delivered
+}
+
+// digestItem aggregates the entries sharing a fingerprint
+type digestItem struct {
+	level   Level
+	example string
+	count   int
+	last    time.Time
+}
+
+// EmailSink mails severe entries for small deployments without a paging
+// system: FATAL entries (by default) immediately, and ERROR entries as a
+// periodic digest counting entries by fingerprint, so a flood of
+// identical errors produces one line rather than one mail each. It can
+// also be used as a hook via Hook.
+type EmailSink struct {
+	config    EmailConfig
+	tlsConfig *tls.Config
+	host      string
+	mu        sync.Mutex
+	digest    map[string]*digestItem
+	stats     EmailStats
+	wg        sync.WaitGroup
+	stopChan  chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewEmailSink creates an email sink and starts its digest timer.
+func NewEmailSink(config EmailConfig) (*EmailSink, error) {
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("loggo: invalid SMTP address: %w", err)
+	}
+	if config.From == "" || len(config.To) == 0 {
+		return nil, fmt.Errorf("loggo: email sink requires a sender and recipients")
+	}
+	if config.Subject == "" {
+		config.Subject = "[loggo]"
+	}
+	if config.ImmediateLevel == DEBUG {
+		config.ImmediateLevel = FATAL
+	}
+	if config.DigestLevel == DEBUG {
+		config.DigestLevel = ERROR
+	}
+	if config.DigestInterval <= 0 {
+		config.DigestInterval = time.Hour
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if config.TLS != nil {
+		if tlsConfig, err = config.TLS.Build(); err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+	}
+
+	s := &EmailSink{
+		config:    config,
+		tlsConfig: tlsConfig,
+		host:      host,
+		digest:    make(map[string]*digestItem),
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// WriteEntry mails the entry immediately or adds it to the digest,
+// depending on its level.
+func (s *EmailSink) WriteEntry(entry *Entry) error {
+	switch {
+	case entry.Level >= s.config.ImmediateLevel:
+		var body strings.Builder
+		fmt.Fprintf(&body, "Time:    %s\r\nLevel:   %s\r\nMessage: %s\r\n", entry.Time.Format(time.RFC3339), entry.Level, entry.Message)
+		for _, f := range entry.Fields {
+			fmt.Fprintf(&body, "%s: %s\r\n", f.Key, f.ValueString())
+		}
+		subject := fmt.Sprintf("%s %s: %s", s.config.Subject, entry.Level, truncate(entry.Message, 100))
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.deliver(subject, body.String(), &s.stats.Immediate)
+		}()
+	case entry.Level >= s.config.DigestLevel:
+		sig := entry.Level.String() + " " + entryFingerprint(entry)
+		s.mu.Lock()
+		item, ok := s.digest[sig]
+		if !ok {
+			item = &digestItem{level: entry.Level, example: entry.Message}
+			s.digest[sig] = item
+		}
+		item.count++
+		item.last = entry.Time
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Hook adapts the sink to the hook signature, for use with AddHook.
+func (s *EmailSink) Hook(level Level, msg string) error {
+	return s.WriteEntry(&Entry{Time: time.Now(), Level: level, Message: msg})
+}
+
+// run mails the digest on every interval
+func (s *EmailSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sendDigest()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// sendDigest mails and resets the collected digest, if not empty
+func (s *EmailSink) sendDigest() {
+	s.mu.Lock()
+	items := make([]*digestItem, 0, len(s.digest))
+	total := 0
+	for _, item := range s.digest {
+		items = append(items, item)
+		total += item.count
+	}
+	clear(s.digest)
+	s.mu.Unlock()
+	if total == 0 {
+		return
+	}
+
+	slices.SortFunc(items, func(a, b *digestItem) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), strings.Compare(a.example, b.example))
+	})
+	var body strings.Builder
+	fmt.Fprintf(&body, "%d entries in the last %s:\r\n\r\n", total, s.config.DigestInterval)
+	for _, item := range items {
+		fmt.Fprintf(&body, "%6dx %-8s %s (last %s)\r\n", item.count, item.level, item.example, item.last.Format(time.RFC3339))
+	}
+	subject := fmt.Sprintf("%s %d entries in the last %s", s.config.Subject, total, s.config.DigestInterval)
+	s.deliver(subject, body.String(), &s.stats.Digests)
+}
+
+// deliver sends a mail and updates the given counter or the error count
+func (s *EmailSink) deliver(subject, body string, counter *int64) {
+	err := s.sendMail(subject, body)
+	s.mu.Lock()
+	if err != nil {
+		s.stats.Errors++
+	} else {
+		*counter++
+	}
+	s.mu.Unlock()
+	if err != nil && s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// sendMail delivers one message over SMTP
+func (s *EmailSink) sendMail(subject, body string) error {
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var conn net.Conn
+	var err error
+	if s.config.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.config.Addr, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", s.config.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(s.config.Timeout))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if !s.config.ImplicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(s.tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if s.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.config.From); err != nil {
+		return err
+	}
+	for _, to := range s.config.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	headerSafe := strings.NewReplacer("\r", " ", "\n", " ")
+	fmt.Fprintf(w, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n", s.config.From, strings.Join(s.config.To, ", "),
+		headerSafe.Replace(subject), time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(w, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s", body)
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (s *EmailSink) Stats() EmailStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops the digest timer, mails the pending digest and waits for
+// immediate mails in flight.
+func (s *EmailSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopChan)
+		<-s.done
+		s.sendDigest()
+		s.wg.Wait()
+	})
+	return nil
+}
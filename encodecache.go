@@ -0,0 +1,84 @@
+package loggo
+
+import "sync"
+
+// encoding identifies an entry encoder
+type encoding uint8
+
+// Entry encoders whose output is cached.
+const (
+	encodingJSON encoding = iota
+	encodingLogfmt
+	encodingProto
+)
+
+// encodingCache keeps the encodings of an entry made while it is delivered
+// to the sinks, so sinks sharing a format, e.g. several JSON sinks with
+// the same keys, encode the entry once. It is only attached to the entry
+// the logger passes to its sinks: copies of it, such as those made by
+// EscapeSink and MappingSink, do not match and are encoded on their own.
+type encodingCache struct {
+	entry     *Entry // Entry the encodings belong to
+	encodings []cachedEncoding
+}
+
+// cachedEncoding is an entry encoded by one encoder with one set of keys
+type cachedEncoding struct {
+	encoding encoding
+	keys     JSONKeys
+	data     []byte // Reused across entries
+	valid    bool   // Whether data holds the current entry's encoding
+}
+
+// encodingCaches recycles caches and their buffers across entries
+var encodingCaches = sync.Pool{New: func() any { return new(encodingCache) }}
+
+// attachEncodingCache gives the entry a cache for its delivery to sinks
+func attachEncodingCache(entry *Entry) {
+	c := encodingCaches.Get().(*encodingCache)
+	c.entry = entry
+	entry.cache = c
+}
+
+// detachEncodingCache recycles the entry's cache once the sinks have
+// returned
+func detachEncodingCache(entry *Entry) {
+	c := entry.cache
+	entry.cache = nil
+	c.entry = nil
+	for i := range c.encodings {
+		c.encodings[i].valid = false
+	}
+	encodingCaches.Put(c)
+}
+
+// appendEncoded appends the entry encoded by encode, reusing the encoding
+// made for an earlier sink if the entry has a cache
+func appendEncoded(buf []byte, entry *Entry, enc encoding, keys JSONKeys, encode func([]byte, *Entry, JSONKeys) []byte) []byte {
+	c := entry.cache
+	if c == nil || c.entry != entry {
+		return encode(buf, entry, keys)
+	}
+	slot := -1
+	for i := range c.encodings {
+		cached := &c.encodings[i]
+		if cached.encoding != enc || cached.keys != keys {
+			continue
+		}
+		if cached.valid {
+			return append(buf, cached.data...)
+		}
+		slot = i
+		break
+	}
+	start := len(buf)
+	buf = encode(buf, entry, keys)
+	if slot < 0 {
+		c.encodings = append(c.encodings, cachedEncoding{encoding: enc, keys: keys})
+		slot = len(c.encodings) - 1
+	}
+	cached := &c.encodings[slot]
+	cached.data = append(cached.data[:0], buf[start:]...)
+	cached.valid = true
+	return buf
+}
@@ -0,0 +1,149 @@
+package loggo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// EncryptedPrefix starts the values of encrypted fields, so tools can
+// tell them from plain values.
+const EncryptedPrefix = "enc:v1:"
+
+// encryptionInfo binds derived keys to this scheme
+const encryptionInfo = "loggo field encryption v1"
+
+// encryptionFailed replaces a value that could not be encrypted, so the
+// plain value is never written
+const encryptionFailed = "[encryption failed]"
+
+// errEncryptedValue is returned by DecryptField for malformed values
+var errEncryptedValue = errors.New("loggo: malformed encrypted field value")
+
+// Encryptor encrypts the values of selected fields with a public key, so
+// they stay opaque through the log pipeline but can be recovered with
+// DecryptField by whoever holds the private key. Unlike removing or
+// masking a value, nothing is lost.
+//
+// Every value is encrypted with its own ephemeral ECDH key: the shared
+// secret with the recipient's key is expanded with HKDF-SHA256 into an
+// AES-256-GCM key, and the field key is authenticated, so a value cannot
+// be moved to another field unnoticed. Encrypted values are
+// EncryptedPrefix followed by the base64 of the ephemeral public key's
+// length, the key and the sealed value.
+type Encryptor struct {
+	recipient *ecdh.PublicKey
+	keys      map[string]bool
+}
+
+// NewEncryptor returns an encryptor for the fields named keys, with
+// the public key of the recipient, e.g. one generated with
+// ecdh.X25519().GenerateKey.
+func NewEncryptor(recipient *ecdh.PublicKey, keys ...string) *Encryptor {
+	enc := &Encryptor{recipient: recipient, keys: make(map[string]bool, len(keys))}
+	for _, key := range keys {
+		enc.keys[key] = true
+	}
+	return enc
+}
+
+// SetFieldEncryption encrypts the fields selected by enc in every record,
+// bound fields included, before the record is formatted or passed to
+// hooks and sinks. Values of any type are encrypted as text. nil turns
+// encryption off.
+func (l *Logger) SetFieldEncryption(enc *Encryptor) {
+	l.encryption.Store(enc)
+}
+
+// encryptFields encrypts the event's fields selected by the logger's
+// encryptor, if any
+func (e *Event) encryptFields() {
+	if enc := e.logger.encryption.Load(); enc != nil {
+		enc.apply(e.fields)
+	}
+}
+
+// apply replaces the selected fields with their encrypted values
+func (enc *Encryptor) apply(fields []Field) {
+	for i := range fields {
+		f := &fields[i]
+		if enc.keys[f.Key] {
+			*f = Str(f.Key, enc.encrypt(f.Key, f.ValueString()))
+		}
+	}
+}
+
+// encrypt returns the encrypted value of the field key
+func (enc *Encryptor) encrypt(key, value string) string {
+	ephemeral, err := enc.recipient.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return encryptionFailed
+	}
+	shared, err := ephemeral.ECDH(enc.recipient)
+	if err != nil {
+		return encryptionFailed
+	}
+	public := ephemeral.PublicKey().Bytes()
+	aead, err := fieldCipher(shared, public, enc.recipient.Bytes())
+	if err != nil {
+		return encryptionFailed
+	}
+
+	// Every key seals one value only, so a zero nonce is safe
+	out := make([]byte, 0, 1+len(public)+len(value)+aead.Overhead())
+	out = append(out, byte(len(public)))
+	out = append(out, public...)
+	out = aead.Seal(out, make([]byte, aead.NonceSize()), []byte(value), []byte(key))
+	return EncryptedPrefix + base64.RawStdEncoding.EncodeToString(out)
+}
+
+// DecryptField returns the plain value of an encrypted field named key,
+// using the recipient's private key.
+func DecryptField(recipient *ecdh.PrivateKey, key, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return "", errEncryptedValue
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 || len(data) < 1+int(data[0]) {
+		return "", errEncryptedValue
+	}
+	public, sealed := data[1:1+data[0]], data[1+data[0]:]
+	ephemeral, err := recipient.Curve().NewPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	shared, err := recipient.ECDH(ephemeral)
+	if err != nil {
+		return "", err
+	}
+	aead, err := fieldCipher(shared, public, recipient.PublicKey().Bytes())
+	if err != nil {
+		return "", err
+	}
+	plain, err := aead.Open(nil, make([]byte, aead.NonceSize()), sealed, []byte(key))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// fieldCipher derives the AES-GCM cipher of one value from the shared
+// secret and both public keys
+func fieldCipher(shared, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	secret, err := hkdf.Key(sha256.New, shared, append(ephemeral[:len(ephemeral):len(ephemeral)], recipient...), encryptionInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,58 @@
+package loggo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+)
+
+// EntryHashKey is the field holding an entry's content hash.
+const EntryHashKey = "entry.hash"
+
+// EntryHash returns a stable hash of an entry's time, level, message and
+// fields, as 32 hex digits. Copies of an entry delivered twice, through
+// retries or by fanning out to several sinks, hash the same, so
+// downstream consumers can drop duplicates. An existing EntryHashKey
+// field is not part of the hash.
+func EntryHash(entry *Entry) string {
+	h := fnv.New128a()
+	var scratch [8]byte
+	writeInt := func(v uint64) {
+		binary.BigEndian.PutUint64(scratch[:], v)
+		h.Write(scratch[:])
+	}
+	writeString := func(s string) {
+		writeInt(uint64(len(s)))
+		h.Write([]byte(s))
+	}
+
+	writeInt(uint64(entry.Time.UnixNano()))
+	writeInt(uint64(entry.Level))
+	writeString(entry.Message)
+	for _, f := range entry.Fields {
+		if f.Key == EntryHashKey {
+			continue
+		}
+		writeString(f.Key)
+		switch f.Type {
+		case IntType, BoolType:
+			writeInt(uint64(f.Type))
+			writeInt(uint64(f.Int))
+		case FloatType:
+			writeInt(uint64(f.Type))
+			writeInt(math.Float64bits(f.Float()))
+		default:
+			writeInt(uint64(StringType))
+			writeString(f.ValueString())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SetEntryHashes enables or disables content hashes. When enabled, the
+// entries passed to sinks carry an EntryHashKey field computed by
+// EntryHash. Text lines are written without it.
+func (l *Logger) SetEntryHashes(enabled bool) {
+	l.entryHashes.Store(enabled)
+}
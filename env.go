@@ -0,0 +1,162 @@
+package loggo
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Paths consulted when detecting the runtime environment.
+// They are variables so tests can point them at fixture files.
+var (
+	serviceAccountNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	cgroupPath                  = "/proc/self/cgroup"
+	mountInfoPath               = "/proc/self/mountinfo"
+	dockerEnvPath               = "/.dockerenv"
+)
+
+// RuntimeEnvironment returns fields describing the container or Kubernetes
+// environment the process runs in. Kubernetes metadata is read from the
+// downward-API variables POD_NAME, POD_NAMESPACE and NODE_NAME (with the
+// service account namespace file and HOSTNAME as fallbacks), and the
+// container ID is taken from the cgroup hierarchy or, with cgroup v2,
+// from the mounts the runtime sets up.
+// It returns nil when no container environment is detected.
+func RuntimeEnvironment() []Field {
+	var fields []Field
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" || os.Getenv("POD_NAME") != "" {
+		pod := os.Getenv("POD_NAME")
+		if pod == "" {
+			pod = os.Getenv("HOSTNAME")
+		}
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			if data, err := os.ReadFile(serviceAccountNamespacePath); err == nil {
+				namespace = strings.TrimSpace(string(data))
+			}
+		}
+		if pod != "" {
+			fields = append(fields, Str("k8s.pod", pod))
+		}
+		if namespace != "" {
+			fields = append(fields, Str("k8s.namespace", namespace))
+		}
+		if node := os.Getenv("NODE_NAME"); node != "" {
+			fields = append(fields, Str("k8s.node", node))
+		}
+	}
+
+	if id := containerID(); id != "" {
+		fields = append(fields, Str("container.id", id))
+	} else if _, err := os.Stat(dockerEnvPath); err == nil {
+		fields = append(fields, Str("container.runtime", "docker"))
+	}
+
+	return fields
+}
+
+// containerID returns the ID of the container the process runs in, from
+// the cgroup file or else the mount table
+func containerID() string {
+	if id := cgroupContainerID(); id != "" {
+		return id
+	}
+	return mountContainerID()
+}
+
+// cgroupContainerID extracts the container ID from the cgroup file.
+// Both cgroup v1 (".../docker/<id>") and systemd style
+// (".../docker-<id>.scope") entries are recognised. With cgroup v2 and a
+// cgroup namespace, the default on current distributions, the only entry
+// is "0::/" and no ID is found.
+func cgroupContainerID() string {
+	f, err := os.Open(cgroupPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Format: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, segment := range strings.Split(parts[2], "/") {
+			segment = strings.TrimSuffix(segment, ".scope")
+			if i := strings.LastIndexByte(segment, '-'); i >= 0 {
+				segment = segment[i+1:]
+			}
+			if isContainerID(segment) {
+				return segment
+			}
+		}
+	}
+	return ""
+}
+
+// mountContainerID extracts the container ID from the mount table, where
+// runtimes mount files such as /etc/hostname from the container's
+// directory, e.g. "/var/lib/docker/containers/<id>/hostname" or
+// "/var/lib/containers/storage/overlay-containers/<id>/userdata/hostname"
+func mountContainerID() string {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Format: mount-ID parent-ID major:minor root mount-point ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		segments := strings.Split(fields[3], "/")
+		for i := 1; i < len(segments); i++ {
+			if strings.HasSuffix(segments[i-1], "containers") && isContainerID(segments[i]) {
+				return segments[i]
+			}
+		}
+	}
+	return ""
+}
+
+// isContainerID reports whether s looks like a 64 character hex container ID
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRuntimeEnvironment enables or disables runtime environment enrichment.
+// When enabled, the container and Kubernetes metadata returned by
+// RuntimeEnvironment is detected once and attached to every record.
+func (l *Logger) SetRuntimeEnvironment(enabled bool) {
+	if !enabled {
+		l.envFields.Store(nil)
+		return
+	}
+	fields := RuntimeEnvironment()
+	l.envFields.Store(&fields)
+}
+
+// loadEnvFields returns the runtime environment fields attached to every
+// record
+func (l *Logger) loadEnvFields() []Field {
+	if fields := l.envFields.Load(); fields != nil {
+		return *fields
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package loggo
+
+import "unicode/utf8"
+
+// EscapePolicy controls how control characters in user-supplied messages
+// and field values are written, to prevent log injection: without
+// escaping, a value containing a newline can forge a whole log line and
+// one containing ANSI sequences can rewrite the operator's terminal.
+type EscapePolicy int
+
+// Escape policies.
+const (
+	EscapeNone      EscapePolicy = iota // Write content unchanged
+	EscapeControl                       // Escape control characters, e.g. "\n" and "\x1b", leaving escape sequences visible but inert
+	EscapeStripANSI                     // Remove ANSI escape sequences, then escape remaining control characters
+)
+
+// Apply returns s escaped according to the policy. Control characters
+// (C0, DEL and C1) and the Unicode line and paragraph separators are
+// written as Go-style escapes: \n, \r and \t, \xNN for other bytes and
+// \uNNNN for the rest. Backslashes are left as they are, so Windows paths
+// stay readable. Strings that need no escaping are returned unchanged
+// without allocating.
+func (p EscapePolicy) Apply(s string) string {
+	if p == EscapeNone || !needsEscape(s) {
+		return s
+	}
+	return string(appendEscaped(make([]byte, 0, len(s)+8), s, p))
+}
+
+// needsEscape reports whether s contains characters that are escaped
+func needsEscape[T string | []byte](s T) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c < 0x20 || c == 0x7f:
+			return true
+		case c == 0xc2 && i+1 < len(s) && s[i+1] < 0xa0:
+			// C1 control, U+0080 to U+009F
+			return true
+		case c == 0xe2 && i+2 < len(s) && s[i+1] == 0x80 && (s[i+2] == 0xa8 || s[i+2] == 0xa9):
+			// U+2028 and U+2029
+			return true
+		}
+	}
+	return false
+}
+
+// appendEscaped appends s to buf escaped according to policy
+func appendEscaped(buf []byte, s string, policy EscapePolicy) []byte {
+	if policy == EscapeStripANSI {
+		s = StripANSI(s)
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c < 0x7f {
+			buf = append(buf, c)
+			i++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			switch c {
+			case '\n':
+				buf = append(buf, `\n`...)
+			case '\r':
+				buf = append(buf, `\r`...)
+			case '\t':
+				buf = append(buf, `\t`...)
+			default:
+				buf = append(buf, '\\', 'x', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r >= 0x80 && r <= 0x9f) || r == '\u2028' || r == '\u2029' {
+			buf = append(buf, '\\', 'u',
+				hexDigits[r>>12&0xf], hexDigits[r>>8&0xf], hexDigits[r>>4&0xf], hexDigits[r&0xf])
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return buf
+}
+
+// SetEscapePolicy sets how control characters in messages are written to
+// the text output. Field values in text lines are always quoted with
+// control characters escaped. Sinks receive entries unchanged; wrap them
+// with NewEscapeSink to escape their content.
+func (l *Logger) SetEscapePolicy(policy EscapePolicy) {
+	l.escape.Store(int32(policy))
+}
+
+// escapeMessage escapes the message written to the buffer from start on
+func (l *Logger) escapeMessage(buf []byte, start int) []byte {
+	policy := EscapePolicy(l.escape.Load())
+	if policy == EscapeNone || !needsEscape(buf[start:]) {
+		return buf
+	}
+	return appendEscaped(buf[:start], string(buf[start:]), policy)
+}
+
+// EscapeSink escapes the message, field keys and string field values of
+// every entry before passing it on, for sinks whose output is read as
+// text, such as syslog, sockets or files tailed by people.
+type EscapeSink struct {
+	sink   Sink
+	policy EscapePolicy
+}
+
+// NewEscapeSink wraps sink so that it receives entries escaped by policy.
+func NewEscapeSink(sink Sink, policy EscapePolicy) *EscapeSink {
+	return &EscapeSink{sink: sink, policy: policy}
+}
+
+// WriteEntry escapes a copy of the entry and writes it to the wrapped sink.
+func (s *EscapeSink) WriteEntry(entry *Entry) error {
+	escaped := getEntry()
+	defer putEntry(escaped)
+	escaped.Time, escaped.Level = entry.Time, entry.Level
+	escaped.Message = s.policy.Apply(entry.Message)
+	escaped.Fields = append(escaped.Fields, entry.Fields...)
+	for i := range escaped.Fields {
+		f := &escaped.Fields[i]
+		f.Key = s.policy.Apply(f.Key)
+		if f.Type == StringType {
+			f.Str = s.policy.Apply(f.Str)
+		}
+	}
+	return s.sink.WriteEntry(escaped)
+}
+
+// Close closes the wrapped sink.
+func (s *EscapeSink) Close() error {
+	return s.sink.Close()
+}
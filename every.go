@@ -0,0 +1,111 @@
+package loggo
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// periodicTasks are the tasks scheduled with Every and EnforceRetention,
+// stopped on Close
+type periodicTasks struct {
+	mu     sync.Mutex
+	tasks  map[*periodicTask]struct{}
+	closed bool
+}
+
+// periodicTask is a function run on the worker pool every interval
+type periodicTask struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	running atomic.Bool // Set from submitting a run until it returns
+	stopped atomic.Bool
+}
+
+// Every calls fn every interval with an INFO event to fill in and write,
+// for periodic status records such as queue depths and counters:
+//
+//	stop := logger.Every(time.Minute, func(e *loggo.Event) {
+//		e.Int("queue.depth", queue.Len()).Msg("status")
+//	})
+//
+// fn runs on the logger's worker pool, like asynchronous hooks, and must
+// finish the event with Msg or Msgf. It is not called while INFO is
+// disabled, and a tick is skipped if the previous call has not returned.
+// The returned function stops the task; Close stops every task, after
+// the calls already queued have run. Every panics if interval is not
+// positive.
+func (l *Logger) Every(interval time.Duration, fn func(e *Event)) (stop func()) {
+	if interval <= 0 {
+		panic("loggo: non-positive interval for Every")
+	}
+	return l.schedule(interval, func() {
+		if e := l.newEvent(INFO); e != nil {
+			fn(e)
+		}
+	})
+}
+
+// schedule runs job on the worker pool every interval until the returned
+// function is called or the logger is closed, skipping a tick if the
+// previous run has not returned
+func (l *Logger) schedule(interval time.Duration, job func()) (stop func()) {
+	t := &periodicTask{}
+	p := &l.periodic
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return func() {}
+	}
+	if p.tasks == nil {
+		p.tasks = make(map[*periodicTask]struct{})
+	}
+	p.tasks[t] = struct{}{}
+
+	run := func() {
+		defer t.running.Store(false)
+		if !t.stopped.Load() {
+			job()
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer = time.AfterFunc(interval, func() {
+		t.mu.Lock()
+		if t.stopped.Load() {
+			t.mu.Unlock()
+			return
+		}
+		t.timer.Reset(interval)
+		t.mu.Unlock()
+		if t.running.CompareAndSwap(false, true) && !l.workerPool.submit(run, false) {
+			t.running.Store(false)
+		}
+	})
+
+	return func() {
+		p.mu.Lock()
+		delete(p.tasks, t)
+		p.mu.Unlock()
+		t.stop()
+	}
+}
+
+// stop cancels the task's future runs
+func (t *periodicTask) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped.Store(true)
+	t.timer.Stop()
+}
+
+// close stops every task and rejects new ones
+func (p *periodicTasks) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for t := range p.tasks {
+		t.stop()
+	}
+	p.tasks = nil
+}
@@ -0,0 +1,202 @@
+package loggo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is the error returned by injected failures.
+var ErrInjectedFault = errors.New("loggo: injected fault")
+
+// FaultConfig configures the faults injected by FaultyWriter, FaultyConn
+// and FaultySender, for testing how applications and loggo's retry and
+// backpressure paths behave when outputs fail. Rates are probabilities
+// from 0 to 1 per operation.
+type FaultConfig struct {
+	ErrorRate     float64       // Fail the operation with ErrInjectedFault
+	PartialRate   float64       // Write only part of the data and fail with io.ErrShortWrite
+	ShortReadRate float64       // Return at most half the bytes requested from a read (FaultyConn)
+	Latency       time.Duration // Delay every operation by this much
+	Jitter        time.Duration // Add a random delay of up to Jitter
+	Seed          uint64        // Seed of the random source, for reproducible runs; 0 picks one
+}
+
+// FaultStats counts the faults injected.
+type FaultStats struct {
+	Operations int64 // Writes, reads and sends seen
+	Errors     int64 // Operations failed with ErrInjectedFault
+	Partial    int64 // Writes cut short
+	ShortReads int64 // Reads returning fewer bytes than requested
+}
+
+// faults decides which operations fail
+type faults struct {
+	config FaultConfig
+	mu     sync.Mutex
+	rand   *rand.Rand
+	stats  FaultStats
+}
+
+// newFaults creates the fault source of a wrapper
+func newFaults(config FaultConfig) *faults {
+	seed := config.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &faults{config: config, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// fault is the outcome of an operation
+type fault int
+
+const (
+	faultNone fault = iota
+	faultError
+	faultPartial
+	faultShortRead
+)
+
+// next sleeps for the configured latency and picks the outcome of an
+// operation among the given faults, checked in order
+func (f *faults) next(kinds ...fault) fault {
+	f.mu.Lock()
+	f.stats.Operations++
+	delay := f.config.Latency
+	if f.config.Jitter > 0 {
+		delay += time.Duration(f.rand.Int64N(int64(f.config.Jitter)))
+	}
+	outcome := faultNone
+	for _, kind := range kinds {
+		if f.rand.Float64() < f.rate(kind) {
+			outcome = kind
+			break
+		}
+	}
+	switch outcome {
+	case faultError:
+		f.stats.Errors++
+	case faultPartial:
+		f.stats.Partial++
+	case faultShortRead:
+		f.stats.ShortReads++
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return outcome
+}
+
+// rate returns the configured probability of a fault
+func (f *faults) rate(kind fault) float64 {
+	switch kind {
+	case faultError:
+		return f.config.ErrorRate
+	case faultPartial:
+		return f.config.PartialRate
+	case faultShortRead:
+		return f.config.ShortReadRate
+	}
+	return 0
+}
+
+// write writes p to w unless a fault is injected
+func (f *faults) write(w io.Writer, p []byte) (int, error) {
+	switch f.next(faultError, faultPartial) {
+	case faultError:
+		return 0, ErrInjectedFault
+	case faultPartial:
+		if len(p) > 1 {
+			n, err := w.Write(p[:len(p)/2])
+			if err == nil {
+				err = io.ErrShortWrite
+			}
+			return n, err
+		}
+	}
+	return w.Write(p)
+}
+
+// Stats returns the faults injected so far.
+func (f *faults) Stats() FaultStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// FaultyWriter wraps a writer with injected errors, latency and partial
+// writes, e.g. as a logger output or a file sink's destination. It is
+// safe for concurrent use if the wrapped writer is.
+type FaultyWriter struct {
+	*faults
+	w io.Writer
+}
+
+// NewFaultyWriter returns w with faults injected as configured.
+func NewFaultyWriter(w io.Writer, config FaultConfig) *FaultyWriter {
+	return &FaultyWriter{faults: newFaults(config), w: w}
+}
+
+// Write writes p, or fails as configured.
+func (w *FaultyWriter) Write(p []byte) (int, error) {
+	return w.write(w.w, p)
+}
+
+// FaultyConn wraps a connection with injected write faults and short
+// reads, e.g. to split the acknowledgements a collector sends after a
+// reconnect across several reads.
+type FaultyConn struct {
+	net.Conn
+	*faults
+}
+
+// NewFaultyConn returns conn with faults injected as configured.
+func NewFaultyConn(conn net.Conn, config FaultConfig) *FaultyConn {
+	return &FaultyConn{Conn: conn, faults: newFaults(config)}
+}
+
+// Write writes p to the connection, or fails as configured.
+func (c *FaultyConn) Write(p []byte) (int, error) {
+	return c.write(c.Conn, p)
+}
+
+// Read reads from the connection, or fails or returns less than it could
+// as configured.
+func (c *FaultyConn) Read(p []byte) (int, error) {
+	switch c.next(faultError, faultShortRead) {
+	case faultError:
+		return 0, ErrInjectedFault
+	case faultShortRead:
+		if len(p) > 1 {
+			p = p[:len(p)/2]
+		}
+	}
+	return c.Conn.Read(p)
+}
+
+// FaultySender wraps a BatchSender with injected errors and latency, to
+// exercise the retries, dead-lettering and backpressure of a BatchSink.
+type FaultySender struct {
+	*faults
+	sender BatchSender
+}
+
+// NewFaultySender returns sender with faults injected as configured.
+// PartialRate and ShortReadRate do not apply to batches.
+func NewFaultySender(sender BatchSender, config FaultConfig) *FaultySender {
+	return &FaultySender{faults: newFaults(config), sender: sender}
+}
+
+// Send sends the batch, or fails as configured.
+func (s *FaultySender) Send(ctx context.Context, batch *Batch) error {
+	if s.next(faultError) == faultError {
+		return ErrInjectedFault
+	}
+	return s.sender.Send(ctx, batch)
+}
@@ -0,0 +1,334 @@
+package loggo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldType identifies how the value of a Field is stored and encoded.
+type FieldType uint8
+
+// Supported field types.
+const (
+	UnknownType    FieldType = iota // Zero value, encoded as an empty string
+	StringType                      // Value stored in Field.Str
+	StringerType                    // fmt.Stringer stored in Field.Value, evaluated at encode time
+	LazyStringType                  // func() string stored in Field.Value, evaluated at encode time
+	IntType                         // Value stored in Field.Int
+	FloatType                       // Value stored as math.Float64bits in Field.Int
+	BoolType                        // Value stored in Field.Int as 1 or 0
+)
+
+// Field is a key/value pair attached to a log record.
+// Fields are typed so that encoders can write values without reflection.
+type Field struct {
+	Key   string    // Name of the field
+	Type  FieldType // How the value is stored
+	Int   int64     // Value for integer, float and bool fields
+	Str   string    // Value for string fields
+	Value any       // Value for stringer and lazy fields
+}
+
+// Str returns a string field.
+func Str(key, val string) Field {
+	return Field{Key: key, Type: StringType, Str: val}
+}
+
+// Int returns an integer field.
+func Int(key string, val int) Field {
+	return Field{Key: key, Type: IntType, Int: int64(val)}
+}
+
+// Int64 returns an integer field.
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Type: IntType, Int: val}
+}
+
+// Float64 returns a floating point field.
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Type: FloatType, Int: int64(math.Float64bits(val))}
+}
+
+// Bool returns a boolean field.
+func Bool(key string, val bool) Field {
+	f := Field{Key: key, Type: BoolType}
+	if val {
+		f.Int = 1
+	}
+	return f
+}
+
+// Err returns an ErrorKey field with the error's message.
+func Err(err error) Field {
+	return Str(ErrorKey, err.Error())
+}
+
+// Dur returns a duration field written as text, e.g. "1.5s".
+func Dur(key string, val time.Duration) Field {
+	return Str(key, val.String())
+}
+
+// Time returns a time field written in RFC 3339 format with nanoseconds.
+func Time(key string, val time.Time) Field {
+	return Str(key, val.Format(time.RFC3339Nano))
+}
+
+// Float returns the value of a FloatType field.
+func (f Field) Float() float64 {
+	return math.Float64frombits(uint64(f.Int))
+}
+
+// Stringer returns a field whose value is obtained by calling val.String().
+// The call is deferred until the record is encoded.
+func Stringer(key string, val fmt.Stringer) Field {
+	return Field{Key: key, Type: StringerType, Value: val}
+}
+
+// LazyStr returns a field whose value is obtained by calling fn.
+// The call is deferred until the record is encoded.
+func LazyStr(key string, fn func() string) Field {
+	return Field{Key: key, Type: LazyStringType, Value: fn}
+}
+
+// Any returns a field for a value of any type, choosing the field type
+// from the value's dynamic type. Errors are stored as their message,
+// fmt.Stringer values are evaluated at encode time, and other types are
+// formatted with fmt.Sprint.
+func Any(key string, val any) Field {
+	switch v := val.(type) {
+	case string:
+		return Str(key, v)
+	case int:
+		return Int(key, v)
+	case int8:
+		return Int64(key, int64(v))
+	case int16:
+		return Int64(key, int64(v))
+	case int32:
+		return Int64(key, int64(v))
+	case int64:
+		return Int64(key, v)
+	case uint8:
+		return Int64(key, int64(v))
+	case uint16:
+		return Int64(key, int64(v))
+	case uint32:
+		return Int64(key, int64(v))
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return Int64(key, int64(v))
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return Int64(key, int64(v))
+		}
+	case float32:
+		return Float64(key, float64(v))
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case error:
+		return Str(key, v.Error())
+	case fmt.Stringer:
+		return Stringer(key, v)
+	}
+	return Str(key, fmt.Sprint(val))
+}
+
+// Str adds a string field.
+func (e *Event) Str(key, val string) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Str(key, val))
+	return e
+}
+
+// Int adds an integer field.
+func (e *Event) Int(key string, val int) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Int(key, val))
+	return e
+}
+
+// Int64 adds an integer field.
+func (e *Event) Int64(key string, val int64) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Int64(key, val))
+	return e
+}
+
+// Float64 adds a floating point field.
+func (e *Event) Float64(key string, val float64) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Float64(key, val))
+	return e
+}
+
+// Bool adds a boolean field.
+func (e *Event) Bool(key string, val bool) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Bool(key, val))
+	return e
+}
+
+// Err adds an ErrorKey field with the error's message. A nil error adds
+// nothing.
+func (e *Event) Err(err error) *Event {
+	if e == nil || err == nil {
+		return e
+	}
+	e.fields = append(e.fields, Err(err))
+	return e
+}
+
+// Dur adds a duration field written as text, e.g. "1.5s".
+func (e *Event) Dur(key string, val time.Duration) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Dur(key, val))
+	return e
+}
+
+// TimeField adds a time field written in RFC 3339 format with
+// nanoseconds. Time, by contrast, sets the timestamp of the record.
+func (e *Event) TimeField(key string, val time.Time) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Time(key, val))
+	return e
+}
+
+// Any adds a field for a value of any type, as the Any field constructor.
+func (e *Event) Any(key string, val any) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Any(key, val))
+	return e
+}
+
+// Stringer adds a field whose value is obtained from val.String().
+// String is only called if the event is actually written, so expensive
+// implementations cost nothing for filtered entries.
+func (e *Event) Stringer(key string, val fmt.Stringer) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Stringer(key, val))
+	return e
+}
+
+// LazyStr adds a field whose value is computed by fn.
+// fn is only called if the event is actually written.
+func (e *Event) LazyStr(key string, fn func() string) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, LazyStr(key, fn))
+	return e
+}
+
+// ValueString returns the field's value as text, as it would appear
+// unquoted in the text format.
+func (f Field) ValueString() string {
+	switch f.Type {
+	case StringType:
+		return f.Str
+	case StringerType, LazyStringType:
+		return f.resolveString()
+	case IntType, FloatType, BoolType:
+		return string(appendTextValue(nil, f))
+	default:
+		return ""
+	}
+}
+
+// resolveString evaluates a stringer or lazy field.
+// A nil value or a panicking implementation yields a placeholder
+// instead of crashing the logging call.
+func (f Field) resolveString() (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	switch v := f.Value.(type) {
+	case fmt.Stringer:
+		return v.String()
+	case func() string:
+		if v == nil {
+			return "<nil>"
+		}
+		return v()
+	default:
+		return "<nil>"
+	}
+}
+
+// appendTextFields appends fields to buf as space separated key=value pairs.
+// Values are quoted only when they would otherwise be ambiguous.
+func appendTextFields(buf []byte, fields []Field) []byte {
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = appendTextValue(buf, f)
+	}
+	return buf
+}
+
+// appendTextValue appends the value of a single field in text form
+func appendTextValue(buf []byte, f Field) []byte {
+	switch f.Type {
+	case StringType:
+		return appendTextString(buf, f.Str)
+	case StringerType, LazyStringType:
+		return appendTextString(buf, f.resolveString())
+	case IntType:
+		return appendInt(buf, f.Int)
+	case FloatType:
+		return strconv.AppendFloat(buf, f.Float(), 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(buf, f.Int != 0)
+	default:
+		return buf
+	}
+}
+
+// appendTextString appends s, quoting it if it is empty or contains
+// characters that would break key=value parsing.
+func appendTextString(buf []byte, s string) []byte {
+	if needsQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsQuote reports whether a text value must be quoted
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return true
+		}
+	}
+	return !utf8.ValidString(s)
+}
@@ -0,0 +1,82 @@
+package loggo
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// FingerprintKey is the field holding an entry's fingerprint.
+const FingerprintKey = "fingerprint"
+
+// Fingerprint returns a short stable identifier for a message template,
+// such as a format string. Records sharing a fingerprint are "the same"
+// message with different arguments, which dashboards, alerting and
+// digests use for grouping.
+func Fingerprint(template string) string {
+	h := fnv.New64a()
+	h.Write([]byte(template))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// SetFingerprints enables or disables automatic fingerprints. When
+// enabled, every record without an explicit fingerprint gets a
+// FingerprintKey field computed from its format string (Msgf) or from its
+// message with numbers masked out (Msg).
+func (l *Logger) SetFingerprints(enabled bool) {
+	l.fingerprints.Store(enabled)
+}
+
+// Fingerprint sets the record's fingerprint explicitly, overriding the
+// automatic one. The key is used verbatim, e.g. "db-timeout".
+func (e *Event) Fingerprint(key string) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Str(FingerprintKey, key))
+	return e
+}
+
+// addFingerprint appends the automatic fingerprint unless one is set
+func (e *Event) addFingerprint(template string, masked bool) {
+	if !e.logger.fingerprints.Load() {
+		return
+	}
+	for i := range e.fields {
+		if e.fields[i].Key == FingerprintKey {
+			return
+		}
+	}
+	if masked {
+		template = messageSignature(template)
+	}
+	e.fields = append(e.fields, Str(FingerprintKey, Fingerprint(template)))
+}
+
+// entryFingerprint returns the entry's fingerprint field, or one derived
+// from its message
+func entryFingerprint(entry *Entry) string {
+	if f, ok := entry.Field(FingerprintKey); ok {
+		return f.ValueString()
+	}
+	return Fingerprint(messageSignature(entry.Message))
+}
+
+// messageSignature groups messages differing only in numbers, e.g. IDs,
+// ports or durations
+func messageSignature(msg string) string {
+	var b strings.Builder
+	digits := false
+	for _, r := range msg {
+		if r >= '0' && r <= '9' {
+			if !digits {
+				b.WriteByte('#')
+			}
+			digits = true
+			continue
+		}
+		digits = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package loggo
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// FluentConfig configures a FluentSender.
+type FluentConfig struct {
+	Address    string     // Forward input address (default "localhost:24224")
+	Network    string     // "tcp" (default) or "unix"
+	Tag        string     // Fluent tag; a non-empty batch stream is appended as ".stream" (default "loggo")
+	Keys       JSONKeys   // Record keys for level and message (default DefaultJSONKeys)
+	RequireAck bool       // Wait for the receiver to acknowledge each chunk
+	TLS        *TLSConfig // Connect with TLS (Fluent Bit/Fluentd "transport tls")
+
+	// DialTimeout bounds connection setup (default 5s). Writes and ack
+	// waits are bounded by the batch send timeout.
+	DialTimeout time.Duration
+}
+
+// FluentSender is a BatchSender speaking the Fluentd/Fluent Bit Forward
+// protocol. Each batch is sent as one Forward mode message, optionally
+// acknowledged by the receiver (at-least-once delivery together with
+// BatchSink retries). Shared-key authentication is not supported.
+type FluentSender struct {
+	config    FluentConfig
+	tlsConfig *tls.Config
+	mu        sync.Mutex
+	conn      net.Conn
+	reader    *bufio.Reader
+	rand      *randSource // Source of the logger; nil for crypto/rand
+}
+
+// NewFluentSender creates a sender for the given configuration.
+// The connection is opened on the first send.
+func NewFluentSender(config FluentConfig) (*FluentSender, error) {
+	if config.Address == "" {
+		config.Address = "localhost:24224"
+	}
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Tag == "" {
+		config.Tag = "loggo"
+	}
+	config.Keys = config.Keys.withDefaults()
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+
+	s := &FluentSender{config: config}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			if host, _, err := net.SplitHostPort(config.Address); err == nil {
+				tlsConfig.ServerName = host
+			}
+		}
+		s.tlsConfig = tlsConfig
+	}
+	return s, nil
+}
+
+// setRand sets the source of the chunk IDs
+func (s *FluentSender) setRand(r *randSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rand = r
+}
+
+// NewFluentSink creates a BatchSink delivering batches through a FluentSender.
+func NewFluentSink(config FluentConfig, batch BatchConfig) (*BatchSink, error) {
+	sender, err := NewFluentSender(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatchSink(sender, batch), nil
+}
+
+// Send writes the batch as a Forward mode message and, with RequireAck,
+// waits for the matching acknowledgement. On any error the connection is
+// dropped and re-established by the next attempt.
+func (s *FluentSender) Send(ctx context.Context, batch *Batch) error {
+	tag := s.config.Tag
+	if batch.Stream != "" {
+		tag += "." + batch.Stream
+	}
+	var chunk string
+	if s.config.RequireAck {
+		var id [16]byte
+		s.mu.Lock()
+		s.rand.Read(id[:])
+		s.mu.Unlock()
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	msg := s.appendForward(nil, tag, batch.Entries, chunk)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.send(ctx, msg, chunk); err != nil {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		return err
+	}
+	return nil
+}
+
+// send writes msg on the connection and reads the ack; s.mu must be held
+func (s *FluentSender) send(ctx context.Context, msg []byte, chunk string) error {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: s.config.DialTimeout}
+		var conn net.Conn
+		var err error
+		if s.tlsConfig != nil {
+			conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, s.config.Network, s.config.Address)
+		} else {
+			conn, err = dialer.DialContext(ctx, s.config.Network, s.config.Address)
+		}
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.reader = bufio.NewReader(conn)
+	}
+
+	deadline, _ := ctx.Deadline()
+	s.conn.SetDeadline(deadline)
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	resp, err := readMsgpack(s.reader)
+	if err != nil {
+		return fmt.Errorf("loggo: reading Fluent ack: %w", err)
+	}
+	if m, ok := resp.(map[string]any); !ok || m["ack"] != chunk {
+		return errors.New("loggo: Fluent receiver sent a mismatched ack")
+	}
+	return nil
+}
+
+// appendForward encodes [tag, [[time, record], ...], option]
+func (s *FluentSender) appendForward(buf []byte, tag string, entries []*Entry, chunk string) []byte {
+	buf = appendMsgpackArray(buf, 3)
+	buf = appendMsgpackString(buf, tag)
+	buf = appendMsgpackArray(buf, len(entries))
+	for _, entry := range entries {
+		buf = appendMsgpackArray(buf, 2)
+		buf = appendFluentTime(buf, entry.Time)
+		buf = appendMsgpackMap(buf, 2+len(entry.Fields))
+		buf = appendMsgpackString(buf, s.config.Keys.Level)
+		buf = appendMsgpackString(buf, jsonLevelStrings[entry.Level])
+		buf = appendMsgpackString(buf, s.config.Keys.Message)
+		buf = appendMsgpackString(buf, entry.Message)
+		for _, f := range entry.Fields {
+			buf = appendMsgpackString(buf, f.Key)
+			buf = appendMsgpackValue(buf, f)
+		}
+	}
+
+	options := 1
+	if chunk != "" {
+		options++
+	}
+	buf = appendMsgpackMap(buf, options)
+	buf = appendMsgpackString(buf, "size")
+	buf = appendMsgpackInt(buf, int64(len(entries)))
+	if chunk != "" {
+		buf = appendMsgpackString(buf, "chunk")
+		buf = appendMsgpackString(buf, chunk)
+	}
+	return buf
+}
+
+// appendFluentTime appends an EventTime (ext type 0) with nanosecond precision
+func appendFluentTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, 0x00)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+}
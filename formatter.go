@@ -0,0 +1,76 @@
+package loggo
+
+import "time"
+
+// Formatter lays out the lines a logger writes to its outputs, replacing
+// the built-in "[LEVEL] timestamp: msg fields" layout. Format appends one
+// record, including the trailing newline, to *buf. fields holds the
+// runtime environment, bound and event fields in output order; msg is the
+// formatted message, unescaped, and escaping is left to the formatter.
+// Format is called concurrently and must not retain fields or buf.
+//
+// Sinks, hooks and tees receive entries as before; the formatter only
+// changes the lines.
+type Formatter interface {
+	Format(level Level, ts time.Time, msg string, fields []Field, buf *[]byte) error
+}
+
+// SetFormatter makes the logger lay out its lines with f. If f fails, the
+// error is reported like a sink error and the record is written in the
+// built-in layout. nil restores the built-in layout.
+func (l *Logger) SetFormatter(f Formatter) {
+	if f == nil {
+		l.formatter.Store(nil)
+		return
+	}
+	l.formatter.Store(&f)
+}
+
+// formatLine lays out the event's line with f
+func (e *Event) formatLine(f Formatter, now time.Time, msg string) {
+	l := e.logger
+	start := len(*e.buf)
+	fields := getEntry()
+	fields.Fields = append(fields.Fields, l.loadEnvFields()...)
+	fields.Fields = append(fields.Fields, e.fields...)
+	err := f.Format(e.level, now, msg, fields.Fields, e.buf)
+	putEntry(fields)
+	if err == nil {
+		return
+	}
+	l.reportError("Formatter error", err)
+	*e.buf = (*e.buf)[:start]
+	colors, offset := e.beginLine(l.getFormattedTime(now), len(msg))
+	*e.buf = append(*e.buf, msg...)
+	e.endLine(colors, offset)
+}
+
+// LogfmtFormatter writes lines in logfmt, as AppendLogfmtKeys.
+type LogfmtFormatter struct {
+	Keys JSONKeys // Keys for the built-in attributes (default DefaultLogfmtKeys)
+}
+
+// Format appends the record as a logfmt line.
+func (f LogfmtFormatter) Format(level Level, ts time.Time, msg string, fields []Field, buf *[]byte) error {
+	entry := getEntry()
+	entry.Time, entry.Level, entry.Message = ts, level, msg
+	entry.Fields = append(entry.Fields, fields...)
+	*buf = append(AppendLogfmtKeys(*buf, entry, f.Keys), '\n')
+	putEntry(entry)
+	return nil
+}
+
+// JSONFormatter writes lines as JSON objects, as AppendJSONKeys.
+type JSONFormatter struct {
+	Keys JSONKeys // Keys for the built-in attributes (default DefaultJSONKeys)
+}
+
+// Format appends the record as a JSON line.
+func (f JSONFormatter) Format(level Level, ts time.Time, msg string, fields []Field, buf *[]byte) error {
+	entry := getEntry()
+	entry.Time, entry.Level, entry.Message = ts, level, msg
+	entry.Fields = append(entry.Fields, fields...)
+	*buf = append(AppendJSONKeys(*buf, entry, f.Keys), '\n')
+	putEntry(entry)
+	return nil
+}
@@ -1,7 +1,10 @@
 package loggo
 
 import (
+	"errors"
 	"io"
+	"slices"
+	"sync"
 )
 
 // Global logging functions that use the default logger instance.
@@ -86,6 +89,11 @@ func SetLevel(level Level) {
 	globalLogger.SetLevel(level)
 }
 
+// OnLevelChange registers a callback called when the global logger's level changes.
+func OnLevelChange(fn func(old, new Level)) {
+	globalLogger.OnLevelChange(fn)
+}
+
 // SetOutputs sets multiple output destinations for the global logger.
 // All log messages will be written to all specified outputs.
 func SetOutputs(outputs ...io.Writer) {
@@ -99,13 +107,79 @@ func SetOutput(output io.Writer) {
 }
 
 // SetTimeFormat sets the time format for the global logger.
-func SetTimeFormat(format string) {
-	globalLogger.SetTimeFormat(format)
+// Returns an error if the format is not a valid layout.
+func SetTimeFormat(format string) error {
+	return globalLogger.SetTimeFormat(format)
+}
+
+// SetTimePreset selects a named timestamp format for the global logger.
+func SetTimePreset(preset TimePreset) error {
+	return globalLogger.SetTimePreset(preset)
 }
 
 // AddHook adds a new hook to the global logger.
-func AddHook(hook func(level Level, msg string) error, priority int) {
-	globalLogger.AddHook(hook, priority)
+// Returns an error if the maximum number of hooks is reached.
+func AddHook(hook func(level Level, msg string) error, priority int, opts ...HookOption) error {
+	return globalLogger.AddHook(hook, priority, opts...)
+}
+
+// AddHookSync adds a synchronous hook to the global logger.
+// Returns an error if the maximum number of hooks is reached.
+func AddHookSync(hook func(level Level, msg string) error, priority int, opts ...HookOption) error {
+	return globalLogger.AddHookSync(hook, priority, opts...)
+}
+
+// With returns a logger derived from the global logger that writes
+// key=value with every record.
+func With(key string, value any) *Logger {
+	return globalLogger.With(key, value)
+}
+
+// WithFields returns a logger derived from the global logger that writes
+// the fields with every record.
+func WithFields(fields map[string]any) *Logger {
+	return globalLogger.WithFields(fields)
+}
+
+// registry holds the loggers returned by GetLogger
+var registry sync.Map // map[string]*Logger
+
+// GetLogger returns the logger named name, derived from the global logger
+// with Named. Calls with the same name return the same logger, so
+// packages can keep theirs in a variable:
+//
+//	var log = loggo.GetLogger("db")
+func GetLogger(name string) *Logger {
+	if logger, ok := registry.Load(name); ok {
+		return logger.(*Logger)
+	}
+	logger, _ := registry.LoadOrStore(name, globalLogger.Named(name))
+	return logger.(*Logger)
+}
+
+// SetNamespaceLevel sets the level of the global logger's named loggers
+// in namespace, as Logger.SetNamespaceLevel.
+func SetNamespaceLevel(namespace string, level Level) {
+	globalLogger.SetNamespaceLevel(namespace, level)
+}
+
+// ClearNamespaceLevel removes the level set for namespace on the global
+// logger.
+func ClearNamespaceLevel(namespace string) {
+	globalLogger.ClearNamespaceLevel(namespace)
+}
+
+// Flush waits for the global logger's queued hooks and flushes its
+// buffered sinks and outputs.
+func Flush() error {
+	return globalLogger.Flush()
+}
+
+// Close stops the global logger, running queued hooks and closing its
+// sinks. Records logged through the global functions afterwards are
+// still written to the outputs, but hooks no longer run.
+func Close() error {
+	return globalLogger.Close()
 }
 
 // SetExitFunc allows overriding the exit function for testing.
@@ -122,20 +196,75 @@ func SetPanicFunc(fn func(string)) {
 	panicFunc = fn
 }
 
+// Flush writes pending sampling summaries and repeated records, waits for
+// queued hooks to run and flushes buffered data: sinks with a Flush or
+// Sync method (such as BatchSink and JSONLFileSink) and outputs with a
+// Flush method (such as *bufio.Writer). It returns the joined errors.
+func (l *Logger) Flush() error {
+	l.flushDedupe()
+	if l.sampled.interval.Load() != 0 {
+		l.emitSamplingSummaries()
+	}
+	l.hookJobs.wait()
+	if q := l.output.async.Load(); q != nil {
+		q.drain()
+	}
+
+	var errs []error
+	for _, sink := range l.loadSinks() {
+		switch s := sink.(type) {
+		case interface{ Flush() error }:
+			errs = append(errs, s.Flush())
+		case interface{ Sync() error }:
+			errs = append(errs, s.Sync())
+		}
+	}
+
+	l.output.mu.Lock()
+	defer l.output.mu.Unlock()
+	for _, w := range slices.Concat(l.output.writers, l.output.tees) {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Close stops the logger and cleans up resources.
 // This should be called when the logger is no longer needed.
-func (l *Logger) Close() {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// It first writes the shutdown report, if enabled with SetShutdownReport.
+// It returns the errors of closing the sinks.
+func (l *Logger) Close() error {
+	// Write the shutdown report while hooks and sinks still accept it
+	l.flushDedupe()
+	l.emitShutdownReport()
+	l.boost.stop()
+	l.periodic.close()
 
-	// Stop the worker pool
+	// Stop the worker pool once the queued hooks have run. The lock is
+	// not held here since failing hooks take it to remove themselves.
 	if l.workerPool != nil {
 		l.workerPool.stop()
 	}
 
 	// Wait for any pending hooks to complete
-	l.wg.Wait()
+	l.hookJobs.wait()
 
 	// Clear hooks
+	l.mu.Lock()
 	l.hooks = nil
+	l.hookCount.Store(0)
+	l.mu.Unlock()
+
+	// Write the queued lines and return to synchronous writes
+	if q := l.output.async.Swap(nil); q != nil {
+		q.stop()
+	}
+
+	// Flush and close structured sinks
+	err := l.closeSinks()
+	if err != nil {
+		l.reportError("Sink error", err)
+	}
+	return err
 }
@@ -0,0 +1,211 @@
+package loggo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Interface is the logging API shared by *Logger and *LoggerGroup, for
+// code that should not care whether it logs to one logger or several.
+type Interface interface {
+	Debug(msg string)
+	Debugf(msg string, args ...any)
+	Info(msg string)
+	Infof(msg string, args ...any)
+	Warn(msg string)
+	Warnf(msg string, args ...any)
+	Error(msg string)
+	Errorf(msg string, args ...any)
+	Critical(msg string)
+	Criticalf(msg string, args ...any)
+	Fatal(msg string)
+	Fatalf(msg string, args ...any)
+	Panic(msg string)
+	Panicf(msg string, args ...any)
+	Flush() error
+	Close() error
+}
+
+// LoggerGroup fans out every call to a set of independent loggers, each
+// with its own level, outputs and sinks, e.g. while migrating from one
+// logging stack to another and both must receive every record.
+type LoggerGroup struct {
+	loggers []*Logger
+}
+
+// Group returns a group logging to all the given loggers, in order.
+func Group(loggers ...*Logger) *LoggerGroup {
+	return &LoggerGroup{loggers: loggers}
+}
+
+// Loggers returns the loggers of the group.
+func (g *LoggerGroup) Loggers() []*Logger {
+	return g.loggers
+}
+
+// With returns a group of the loggers with the field bound, as Logger.With.
+func (g *LoggerGroup) With(key string, value any) *LoggerGroup {
+	loggers := make([]*Logger, len(g.loggers))
+	for i, l := range g.loggers {
+		loggers[i] = l.With(key, value)
+	}
+	return &LoggerGroup{loggers: loggers}
+}
+
+// WithFields returns a group of the loggers with the fields bound, as
+// Logger.WithFields.
+func (g *LoggerGroup) WithFields(fields map[string]any) *LoggerGroup {
+	loggers := make([]*Logger, len(g.loggers))
+	for i, l := range g.loggers {
+		loggers[i] = l.WithFields(fields)
+	}
+	return &LoggerGroup{loggers: loggers}
+}
+
+// SetLevel sets the level of every logger of the group.
+func (g *LoggerGroup) SetLevel(level Level) {
+	for _, l := range g.loggers {
+		l.SetLevel(level)
+	}
+}
+
+// Debug logs a debug message to every logger.
+func (g *LoggerGroup) Debug(msg string) {
+	for _, l := range g.loggers {
+		l.Debug(msg)
+	}
+}
+
+// Debugf logs a formatted debug message to every logger.
+func (g *LoggerGroup) Debugf(msg string, args ...any) {
+	for _, l := range g.loggers {
+		l.Debugf(msg, args...)
+	}
+}
+
+// Info logs an info message to every logger.
+func (g *LoggerGroup) Info(msg string) {
+	for _, l := range g.loggers {
+		l.Info(msg)
+	}
+}
+
+// Infof logs a formatted info message to every logger.
+func (g *LoggerGroup) Infof(msg string, args ...any) {
+	for _, l := range g.loggers {
+		l.Infof(msg, args...)
+	}
+}
+
+// Warn logs a warning message to every logger.
+func (g *LoggerGroup) Warn(msg string) {
+	for _, l := range g.loggers {
+		l.Warn(msg)
+	}
+}
+
+// Warnf logs a formatted warning message to every logger.
+func (g *LoggerGroup) Warnf(msg string, args ...any) {
+	for _, l := range g.loggers {
+		l.Warnf(msg, args...)
+	}
+}
+
+// Error logs an error message to every logger.
+func (g *LoggerGroup) Error(msg string) {
+	for _, l := range g.loggers {
+		l.Error(msg)
+	}
+}
+
+// Errorf logs a formatted error message to every logger.
+func (g *LoggerGroup) Errorf(msg string, args ...any) {
+	for _, l := range g.loggers {
+		l.Errorf(msg, args...)
+	}
+}
+
+// Critical logs a critical message to every logger.
+func (g *LoggerGroup) Critical(msg string) {
+	for _, l := range g.loggers {
+		l.Critical(msg)
+	}
+}
+
+// Criticalf logs a formatted critical message to every logger.
+func (g *LoggerGroup) Criticalf(msg string, args ...any) {
+	for _, l := range g.loggers {
+		l.Criticalf(msg, args...)
+	}
+}
+
+// Fatal logs a fatal message to every logger, flushes them and exits.
+// Unlike calling Fatal on each logger, every logger receives the record
+// before the program exits.
+func (g *LoggerGroup) Fatal(msg string) {
+	g.crash(FATAL, msg, nil)
+}
+
+// Fatalf logs a formatted fatal message to every logger, flushes them
+// and exits.
+func (g *LoggerGroup) Fatalf(msg string, args ...any) {
+	g.crash(FATAL, msg, args)
+}
+
+// Panic logs a panic message to every logger, flushes them and panics.
+func (g *LoggerGroup) Panic(msg string) {
+	g.crash(PANIC, msg, nil)
+}
+
+// Panicf logs a formatted panic message to every logger, flushes them and
+// panics.
+func (g *LoggerGroup) Panicf(msg string, args ...any) {
+	g.crash(PANIC, msg, args)
+}
+
+// crash writes a FATAL or PANIC record to every logger before exiting or
+// panicking once, if any logger wrote it
+func (g *LoggerGroup) crash(level Level, format string, args []any) {
+	written := false
+	for _, l := range g.loggers {
+		if e := l.newEvent(level); e != nil {
+			e.grouped = true
+			e.Msgf(format, args...)
+			written = true
+		}
+	}
+	if !written {
+		return
+	}
+	for _, l := range g.loggers {
+		l.Flush()
+		l.workerPool.stop()
+	}
+	if level == FATAL {
+		exitFunc(1)
+		return
+	}
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	panicFunc(message)
+}
+
+// Flush flushes every logger and returns the joined errors.
+func (g *LoggerGroup) Flush() error {
+	var errs []error
+	for _, l := range g.loggers {
+		errs = append(errs, l.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every logger and returns the joined errors.
+func (g *LoggerGroup) Close() error {
+	var errs []error
+	for _, l := range g.loggers {
+		errs = append(errs, l.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,462 @@
+package loggo
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GRPCConfig configures a GRPCSink. Zero values select the defaults.
+type GRPCConfig struct {
+	// Endpoints are the base URLs of the ingestion service, e.g.
+	// "https://ingest-1:443". Plain "http://" endpoints use HTTP/2 without
+	// TLS (h2c). Streams are opened on the endpoints in round-robin order.
+	Endpoints []string
+
+	Method           string            // Full RPC method (default "/loggo.v1.Ingest/Push")
+	Headers          map[string]string // Metadata sent with every stream
+	TLS              *TLSConfig        // TLS settings for https endpoints (default: system roots)
+	Auth             Authenticator     // Credentials added when a stream is opened
+	MaxInFlight      int               // Unacknowledged entries allowed on a stream (default 1000)
+	QueueSize        int               // Entries waiting to be sent before new ones are dropped (default 10000)
+	ReconnectBackoff time.Duration     // Initial delay after a failed stream, doubled up to 30s (default 100ms)
+	StreamLifetime   time.Duration     // Reopen streams this often to spread load across endpoints (default 5m)
+	CloseTimeout     time.Duration     // How long Close waits for outstanding acks (default 5s)
+	OnError          func(error)       // Called when a stream fails
+	DeadLetter       DeadLetter        // Receives entries dropped by a full queue or on Close
+
+	// Client opens the streams. It must support HTTP/2 and have no
+	// Timeout, as streams are long-lived. The default client speaks HTTP/2
+	// over TLS and h2c.
+	Client *http.Client
+}
+
+// GRPCStats reports the activity of a GRPCSink.
+type GRPCStats struct {
+	Sent    int64 // Entries written to a stream, including resends
+	Acked   int64 // Entries acknowledged by the collector
+	Resent  int64 // Unacknowledged entries resent after a reconnect
+	Dropped int64 // Entries dropped because the queue was full or Close timed out
+	Streams int64 // Streams opened
+	Errors  int64 // Streams that ended with an error
+}
+
+// grpcMessage is an encoded, framed entry
+type grpcMessage struct {
+	seq  uint64
+	data []byte
+}
+
+// GRPCSink streams entries as protobuf messages (see proto/loggo.proto)
+// over a gRPC bidirectional stream. The collector acknowledges entries by
+// sequence number; at most MaxInFlight entries are outstanding at a time,
+// so a slow collector applies backpressure to the sink's queue rather
+// than to the network. Entries still unacknowledged when a stream fails
+// are resent on the next endpoint, giving at-least-once delivery.
+//
+// The sink speaks the gRPC wire protocol directly over net/http and has no
+// dependency on grpc-go.
+type GRPCSink struct {
+	config  GRPCConfig
+	client  *http.Client
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []grpcMessage // Waiting to be sent
+	unacked []grpcMessage // Sent on the current stream, awaiting ack
+	seq     uint64
+	next    int // Index of the next endpoint
+	stats   GRPCStats
+	closed  bool
+	expired bool // CloseTimeout elapsed
+	broken  bool // Current stream ended
+	rotate  bool // Current stream reached StreamLifetime
+	abort   func()
+
+	stopChan  chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewGRPCSink creates a sink streaming to the configured endpoints.
+// Streams are opened lazily when the first entry is written.
+func NewGRPCSink(config GRPCConfig) (*GRPCSink, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("loggo: gRPC sink requires at least one endpoint")
+	}
+	for _, endpoint := range config.Endpoints {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("loggo: invalid gRPC endpoint %q", endpoint)
+		}
+	}
+	if config.Method == "" {
+		config.Method = "/loggo.v1.Ingest/Push"
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 1000
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = 100 * time.Millisecond
+	}
+	if config.StreamLifetime <= 0 {
+		config.StreamLifetime = 5 * time.Minute
+	}
+	if config.CloseTimeout <= 0 {
+		config.CloseTimeout = 5 * time.Second
+	}
+
+	client := config.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+		client = &http.Client{Transport: transport}
+	}
+	client, err := clientWithTLS(client, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &GRPCSink{
+		config:   config,
+		client:   client,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s, nil
+}
+
+// WriteEntry encodes the entry and queues it for streaming.
+// If the queue is full the entry is dropped, counted in Stats and passed
+// to the dead-letter queue.
+func (s *GRPCSink) WriteEntry(entry *Entry) error {
+	data := AppendProto(make([]byte, 5, 128), entry)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errSinkClosed
+	}
+	if len(s.queue) >= s.config.QueueSize {
+		s.stats.Dropped++
+		s.mu.Unlock()
+		if s.config.DeadLetter != nil {
+			s.config.DeadLetter.DeadLetter(sinkName(s), errQueueFull, entry)
+		}
+		return nil
+	}
+	s.seq++
+	data = appendProtoVarint(data, 5, s.seq)
+	binary.BigEndian.PutUint32(data[1:5], uint32(len(data)-5))
+	s.queue = append(s.queue, grpcMessage{seq: s.seq, data: data})
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	return nil
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (s *GRPCSink) Stats() GRPCStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops accepting entries and waits up to CloseTimeout for queued
+// entries to be sent and acknowledged. Entries still outstanding after
+// that are dropped and reported in the returned error.
+func (s *GRPCSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		timer := time.AfterFunc(s.config.CloseTimeout, s.expire)
+		<-s.done
+		timer.Stop()
+
+		s.mu.Lock()
+		lost := append(s.unacked, s.queue...)
+		s.stats.Dropped += int64(len(lost))
+		s.queue, s.unacked = nil, nil
+		s.mu.Unlock()
+		if len(lost) > 0 {
+			err = fmt.Errorf("loggo: gRPC sink closed with %d unacknowledged entries", len(lost))
+			s.deadLetter(err, lost)
+		}
+	})
+	return err
+}
+
+// deadLetter decodes undelivered messages for the dead-letter queue
+func (s *GRPCSink) deadLetter(reason error, messages []grpcMessage) {
+	if s.config.DeadLetter == nil {
+		return
+	}
+	entries := make([]*Entry, 0, len(messages))
+	for _, msg := range messages {
+		if entry, err := ParseProto(msg.data[5:]); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	s.config.DeadLetter.DeadLetter(sinkName(s), reason, entries...)
+}
+
+// expire gives up on outstanding entries and aborts the current stream
+func (s *GRPCSink) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expired = true
+	if s.abort != nil {
+		s.abort()
+	}
+	close(s.stopChan)
+	s.cond.Broadcast()
+}
+
+// finished reports whether the sink has nothing left to do; s.mu must be held
+func (s *GRPCSink) finished() bool {
+	return s.expired || (s.closed && len(s.queue) == 0 && len(s.unacked) == 0)
+}
+
+// run opens streams on the endpoints in turn, backing off after failures
+func (s *GRPCSink) run() {
+	defer close(s.done)
+	backoff := s.config.ReconnectBackoff
+
+	for {
+		s.mu.Lock()
+		for !s.finished() && len(s.queue) == 0 && len(s.unacked) == 0 {
+			s.cond.Wait()
+		}
+		if s.finished() {
+			s.mu.Unlock()
+			return
+		}
+		endpoint := s.config.Endpoints[s.next%len(s.config.Endpoints)]
+		s.next++
+		s.mu.Unlock()
+
+		err := s.stream(endpoint)
+		if err == nil {
+			backoff = s.config.ReconnectBackoff
+			continue
+		}
+		s.mu.Lock()
+		s.stats.Errors++
+		s.mu.Unlock()
+		if s.config.OnError != nil {
+			s.config.OnError(err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stopChan:
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// stream runs a single call: it resends unacknowledged entries, then
+// writes queued entries as the window allows until the call fails, its
+// lifetime ends or the sink is drained
+func (s *GRPCSink) stream(endpoint string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	body, writer := io.Pipe()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+s.config.Method, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	if s.config.Auth != nil {
+		if err := s.config.Auth.Authenticate(req, nil); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if s.expired {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stats.Streams++
+	s.stats.Resent += int64(len(s.unacked))
+	s.queue = slices.Concat(s.unacked, s.queue)
+	s.unacked = nil
+	s.broken, s.rotate = false, false
+	s.abort = func() {
+		cancel()
+		body.CloseWithError(context.Canceled)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.abort = nil
+		s.mu.Unlock()
+	}()
+
+	readErr := make(chan error, 1)
+	go func() {
+		err := s.readAcks(req)
+		// Unblock a writer stuck on a call that has ended
+		body.CloseWithError(io.ErrClosedPipe)
+		s.mu.Lock()
+		s.broken = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+		readErr <- err
+	}()
+
+	lifetime := time.AfterFunc(s.config.StreamLifetime, func() {
+		s.mu.Lock()
+		s.rotate = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer lifetime.Stop()
+
+	s.mu.Lock()
+	for {
+		for !s.broken && !s.rotate && !s.finished() &&
+			(len(s.queue) == 0 || len(s.unacked) >= s.config.MaxInFlight) {
+			s.cond.Wait()
+		}
+		if s.broken || s.rotate || s.finished() {
+			break
+		}
+		n := min(len(s.queue), s.config.MaxInFlight-len(s.unacked))
+		batch := s.queue[:n:n]
+		s.queue = s.queue[n:]
+		s.unacked = append(s.unacked, batch...)
+		s.mu.Unlock()
+
+		var buf []byte
+		for _, msg := range batch {
+			buf = append(buf, msg.data...)
+		}
+		_, err := writer.Write(buf)
+
+		s.mu.Lock()
+		if err != nil {
+			break
+		}
+		s.stats.Sent += int64(n)
+	}
+	broken := s.broken
+	s.mu.Unlock()
+
+	// Half-close so the collector acknowledges what it has and ends the call
+	writer.Close()
+	if broken {
+		return <-readErr
+	}
+	select {
+	case err = <-readErr:
+	case <-time.After(s.config.CloseTimeout):
+		cancel()
+		body.CloseWithError(context.Canceled)
+		err = <-readErr
+	}
+	return err
+}
+
+// readAcks performs the call and applies acknowledgements until the
+// collector ends it
+func (s *GRPCSink) readAcks(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("loggo: gRPC endpoint responded with %s", resp.Status)
+	}
+	if resp.Header.Get("Grpc-Status") != "" {
+		// Trailers-only response: the call failed before any message
+		return s.grpcStatus(resp.Header)
+	}
+
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(resp.Body, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return s.grpcStatus(resp.Trailer)
+			}
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[1:])
+		if header[0] != 0 {
+			return errors.New("loggo: compressed gRPC acks are not supported")
+		}
+		if size > 1<<20 {
+			return fmt.Errorf("loggo: gRPC ack of %d bytes is too large", size)
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(resp.Body, msg); err != nil {
+			return err
+		}
+
+		var acked uint64
+		err := walkProto(msg, func(num int, wire int, v uint64, _ []byte) error {
+			if num == 1 && wire == protoVarint {
+				acked = v
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		s.ack(acked)
+	}
+}
+
+// ack releases every unacknowledged entry up to seq
+func (s *GRPCSink) ack(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for n < len(s.unacked) && s.unacked[n].seq <= seq {
+		n++
+	}
+	s.stats.Acked += int64(n)
+	s.unacked = s.unacked[n:]
+	s.cond.Broadcast()
+}
+
+// grpcStatus converts the grpc-status metadata of a finished call to an error
+func (s *GRPCSink) grpcStatus(h http.Header) error {
+	switch code := h.Get("Grpc-Status"); code {
+	case "0":
+		return nil
+	case "":
+		return errors.New("loggo: gRPC call ended without a status")
+	case "16": // UNAUTHENTICATED
+		if inv, ok := s.config.Auth.(invalidator); ok {
+			inv.Invalidate()
+		}
+		fallthrough
+	default:
+		message, _ := url.PathUnescape(h.Get("Grpc-Message"))
+		return fmt.Errorf("loggo: gRPC call failed with status %s: %s", code, message)
+	}
+}
@@ -0,0 +1,147 @@
+package loggo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPConfig configures an HTTPSender.
+type HTTPConfig struct {
+	URL         string            // Collector endpoint receiving POST requests
+	Codec       string            // Registered codec name (default "none")
+	ContentType string            // Request content type (default "application/x-ndjson")
+	Headers     map[string]string // Extra headers added to every request
+	Keys        JSONKeys          // JSON keys for time, level and message (default DefaultJSONKeys)
+	TLS         *TLSConfig        // TLS settings for https endpoints (default: system roots)
+	Auth        Authenticator     // Credentials added to every request
+
+	// Client is used to send requests instead of http.DefaultClient, e.g. to
+	// route through an authenticated proxy, use a custom resolver or limit
+	// connections. If TLS is also set, the client's *http.Transport is cloned
+	// and given the built TLS configuration.
+	Client *http.Client
+}
+
+// HTTPSender is a BatchSender that POSTs batches as JSON lines,
+// compressed with the configured codec.
+type HTTPSender struct {
+	config HTTPConfig
+	codec  Codec
+	client *http.Client
+}
+
+// NewHTTPSender creates a sender for the given configuration.
+// It returns an error if the URL is empty or the codec is not registered.
+func NewHTTPSender(config HTTPConfig) (*HTTPSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("loggo: HTTP sender requires a URL")
+	}
+	codec, err := LookupCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	if config.ContentType == "" {
+		config.ContentType = "application/x-ndjson"
+	}
+
+	client, err := clientWithTLS(config.Client, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPSender{
+		config: config,
+		codec:  codec,
+		client: client,
+	}, nil
+}
+
+// clientWithTLS returns client, or http.DefaultClient if nil, with its
+// transport cloned and configured for tls when tls is set
+func clientWithTLS(client *http.Client, tls *TLSConfig) (*http.Client, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if tls == nil {
+		return client, nil
+	}
+	tlsConfig, err := tls.Build()
+	if err != nil {
+		return nil, err
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, errors.New("loggo: TLS requires the client transport to be an *http.Transport")
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	withTLS := *client
+	withTLS.Transport = transport
+	return &withTLS, nil
+}
+
+// NewHTTPSink creates a BatchSink delivering batches through an HTTPSender.
+func NewHTTPSink(config HTTPConfig, batch BatchConfig) (*BatchSink, error) {
+	sender, err := NewHTTPSender(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatchSink(sender, batch), nil
+}
+
+// Send encodes and compresses the batch and POSTs it to the collector.
+// Any non-2xx response is reported as an error so the batch is retried.
+func (s *HTTPSender) Send(ctx context.Context, batch *Batch) error {
+	var raw []byte
+	for _, entry := range batch.Entries {
+		raw = AppendJSONKeys(raw, entry, s.config.Keys)
+		raw = append(raw, '\n')
+	}
+	body, err := s.codec.Compress(nil, raw)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.config.ContentType)
+	if name := s.codec.Name(); name != "none" {
+		req.Header.Set("Content-Encoding", name)
+	}
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	if s.config.Auth != nil {
+		if err := s.config.Auth.Authenticate(req, body); err != nil {
+			return err
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		// Credentials may have been rotated; refetch them before the retry
+		if inv, ok := s.config.Auth.(invalidator); ok {
+			inv.Invalidate()
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("loggo: collector responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package loggo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Fields added by HTTPRequest and HTTPResponse, named after the
+// OpenTelemetry semantic conventions. Headers are added as
+// HTTPRequestHeaderPrefix or HTTPResponseHeaderPrefix followed by the
+// lower-case header name.
+const (
+	HTTPMethodKey            = "http.request.method"
+	HTTPURLKey               = "url.full"
+	HTTPStatusKey            = "http.response.status_code"
+	HTTPRequestHeaderPrefix  = "http.request.header."
+	HTTPResponseHeaderPrefix = "http.response.header."
+	HTTPRequestBodyKey       = "http.request.body"
+	HTTPResponseBodyKey      = "http.response.body"
+)
+
+// RedactedValue replaces the values of sensitive headers.
+const RedactedValue = "[REDACTED]"
+
+// SensitiveHeaders are the headers whose values HTTPRequest and
+// HTTPResponse replace with RedactedValue. Names are canonicalized when
+// compared.
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+}
+
+// HTTPRequest adds the method, URL, headers and up to bodyLimit bytes of
+// the body of r, for client requests before they are sent and server
+// requests as received. Passwords in the URL and SensitiveHeaders are
+// redacted. The body is read and replaced by an equivalent reader, so r
+// can still be sent or handled; a bodyLimit of 0 skips it. Truncated
+// bodies are marked with a BlobTruncatedSuffix field, and a BlobSizeSuffix
+// field when the content length is known.
+func (e *Event) HTTPRequest(r *http.Request, bodyLimit int) *Event {
+	if e == nil || r == nil {
+		return e
+	}
+	e.fields = append(e.fields, Str(HTTPMethodKey, r.Method))
+	if u := requestURL(r); u != nil {
+		e.fields = append(e.fields, Str(HTTPURLKey, u.Redacted()))
+	}
+	e.appendHeaders(HTTPRequestHeaderPrefix, r.Header)
+	if bodyLimit > 0 && r.Body != nil && r.Body != http.NoBody {
+		var body []byte
+		body, r.Body = peekBody(r.Body, bodyLimit)
+		e.appendBody(HTTPRequestBodyKey, body, bodyLimit, r.ContentLength)
+	}
+	return e
+}
+
+// HTTPResponse adds the status code, headers and up to bodyLimit bytes of
+// the body of resp, like HTTPRequest. The body is replaced by an
+// equivalent reader, so resp can still be consumed.
+func (e *Event) HTTPResponse(resp *http.Response, bodyLimit int) *Event {
+	if e == nil || resp == nil {
+		return e
+	}
+	e.fields = append(e.fields, Int(HTTPStatusKey, resp.StatusCode))
+	e.appendHeaders(HTTPResponseHeaderPrefix, resp.Header)
+	if bodyLimit > 0 && resp.Body != nil && resp.Body != http.NoBody {
+		var body []byte
+		body, resp.Body = peekBody(resp.Body, bodyLimit)
+		e.appendBody(HTTPResponseBodyKey, body, bodyLimit, resp.ContentLength)
+	}
+	return e
+}
+
+// requestURL returns the full URL of r, completing the scheme and host of
+// server requests
+func requestURL(r *http.Request) *url.URL {
+	if r.URL == nil {
+		return nil
+	}
+	if r.URL.Host != "" {
+		return r.URL
+	}
+	u := *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return &u
+}
+
+// appendHeaders adds one field per header, in name order, with
+// sensitive values redacted and repeated values joined
+func (e *Event) appendHeaders(prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if isSensitiveHeader(name) {
+			value = RedactedValue
+		}
+		e.fields = append(e.fields, Str(prefix+strings.ToLower(name), value))
+	}
+}
+
+// isSensitiveHeader reports whether a header's value must be redacted
+func isSensitiveHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	for _, sensitive := range SensitiveHeaders {
+		if http.CanonicalHeaderKey(sensitive) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// appendBody adds a body read by peekBody, marking truncation like Blob
+func (e *Event) appendBody(key string, body []byte, limit int, length int64) {
+	truncated := len(body) > limit
+	if truncated {
+		body = body[:limit]
+	}
+	e.fields = append(e.fields, Str(key, string(body)))
+	if truncated {
+		if length >= 0 {
+			e.fields = append(e.fields, Int64(key+BlobSizeSuffix, length))
+		}
+		e.fields = append(e.fields, Bool(key+BlobTruncatedSuffix, true))
+	}
+}
+
+// peekBody reads up to limit+1 bytes of body and returns them with a
+// reader yielding the whole body again
+func peekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	peeked, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	rest := io.MultiReader(bytes.NewReader(peeked), body)
+	if err != nil {
+		rest = io.MultiReader(bytes.NewReader(peeked), errReader{err})
+	}
+	return peeked, struct {
+		io.Reader
+		io.Closer
+	}{rest, body}
+}
+
+// errReader returns err from every read
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
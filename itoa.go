@@ -0,0 +1,104 @@
+package loggo
+
+import (
+	"strconv"
+	"time"
+)
+
+// digitPairs holds the decimal digits of 00 to 99, so integers are written
+// two digits per division, as strconv and zerolog do
+const digitPairs = "00010203040506070809101112131415161718192021222324252627282930313233343536373839404142434445464748495051525354555657585960616263646566676869707172737475767778798081828384858687888990919293949596979899"
+
+// appendInt appends the decimal form of v. Values from 0 to 99, which
+// most counters, statuses and small fields are, are written from a table;
+// strconv is as fast as a hand-written loop for the rest.
+func appendInt(buf []byte, v int64) []byte {
+	if v >= 0 && v < 100 {
+		if v < 10 {
+			return append(buf, byte('0'+v))
+		}
+		return append(buf, digitPairs[v*2], digitPairs[v*2+1])
+	}
+	return strconv.AppendInt(buf, v, 10)
+}
+
+// appendPadded appends v zero-padded to width digits; v must fit
+func appendPadded(buf []byte, v, width int) []byte {
+	if width == 2 {
+		return append(buf, digitPairs[v*2], digitPairs[v*2+1])
+	}
+	var scratch [9]byte
+	for i := width - 1; i >= 0; i-- {
+		scratch[i] = byte('0' + v%10)
+		v /= 10
+	}
+	return append(buf, scratch[:width]...)
+}
+
+// appendTime appends t formatted with layout. DefaultTimeFormat,
+// time.DateTime and the ISO 8601 preset are written digit by digit; other
+// layouts, including RFC 3339 which the time package already special-cases,
+// and years outside 0 to 9999 go through time.AppendFormat.
+func appendTime(buf []byte, t time.Time, layout string) []byte {
+	if layout != DefaultTimeFormat && layout != time.DateTime && layout != iso8601Layout {
+		return t.AppendFormat(buf, layout)
+	}
+	year, month, day := t.Date()
+	if year < 0 || year > 9999 {
+		return t.AppendFormat(buf, layout)
+	}
+	switch layout {
+	case DefaultTimeFormat:
+		name, _ := t.Zone()
+		if name == "" {
+			return t.AppendFormat(buf, layout)
+		}
+		buf = appendDateTime(buf, t, year, month, day, ' ')
+		buf = append(buf, '.')
+		buf = appendPadded(buf, t.Nanosecond()/1e6, 3)
+		buf = append(buf, ' ')
+		return append(buf, name...)
+	case time.DateTime:
+		return appendDateTime(buf, t, year, month, day, ' ')
+	case iso8601Layout:
+		buf = appendDateTime(buf, t, year, month, day, 'T')
+		buf = append(buf, '.')
+		buf = appendPadded(buf, t.Nanosecond()/1e6, 3)
+		return appendZoneOffset(buf, t)
+	}
+	return buf
+}
+
+// appendDateTime appends "2006-01-02" and "15:04:05" joined by sep
+func appendDateTime(buf []byte, t time.Time, year int, month time.Month, day int, sep byte) []byte {
+	hour, minute, second := t.Clock()
+	buf = appendPadded(buf, year, 4)
+	buf = append(buf, '-')
+	buf = appendPadded(buf, int(month), 2)
+	buf = append(buf, '-')
+	buf = appendPadded(buf, day, 2)
+	buf = append(buf, sep)
+	buf = appendPadded(buf, hour, 2)
+	buf = append(buf, ':')
+	buf = appendPadded(buf, minute, 2)
+	buf = append(buf, ':')
+	return appendPadded(buf, second, 2)
+}
+
+// appendZoneOffset appends the zone as "Z" for UTC or "-07:00"
+func appendZoneOffset(buf []byte, t time.Time) []byte {
+	_, offset := t.Zone()
+	if offset == 0 {
+		return append(buf, 'Z')
+	}
+	minutes := offset / 60
+	if minutes < 0 {
+		buf = append(buf, '-')
+		minutes = -minutes
+	} else {
+		buf = append(buf, '+')
+	}
+	buf = appendPadded(buf, minutes/60, 2)
+	buf = append(buf, ':')
+	return appendPadded(buf, minutes%60, 2)
+}
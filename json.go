@@ -0,0 +1,278 @@
+package loggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonLevelStrings maps log levels to their JSON representations
+var jsonLevelStrings = map[Level]string{
+	DEBUG:    "debug",
+	INFO:     "info",
+	WARN:     "warn",
+	ERROR:    "error",
+	CRITICAL: "critical",
+	FATAL:    "fatal",
+	PANIC:    "panic",
+}
+
+// hexDigits is used when escaping control characters
+const hexDigits = "0123456789abcdef"
+
+// JSONKeys names the keys used for the built-in entry attributes, so JSON
+// output can match what a backend expects (e.g. "@timestamp", "message"),
+// and sets the order of the fields.
+type JSONKeys struct {
+	Time    string     // Key for the entry time (default "time")
+	Level   string     // Key for the level (default "level")
+	Message string     // Key for the message (default "msg")
+	Order   FieldOrder // Order of the fields (default FieldsInOrder)
+}
+
+// FieldOrder is the order fields are written in after the built-in
+// attributes.
+type FieldOrder int
+
+// Field orders.
+const (
+	FieldsInOrder FieldOrder = iota // The order the fields were added in, the default
+	FieldsSorted                    // Sorted by key, fields with the same key in the order added, for golden files and diffs
+)
+
+// DefaultJSONKeys are the keys used by AppendJSON.
+var DefaultJSONKeys = JSONKeys{Time: "time", Level: "level", Message: "msg"}
+
+// withDefaults fills empty keys from DefaultJSONKeys
+func (k JSONKeys) withDefaults() JSONKeys {
+	if k.Time == "" {
+		k.Time = DefaultJSONKeys.Time
+	}
+	if k.Level == "" {
+		k.Level = DefaultJSONKeys.Level
+	}
+	if k.Message == "" {
+		k.Message = DefaultJSONKeys.Message
+	}
+	return k
+}
+
+// AppendJSON appends the entry to buf as a single JSON object without a
+// trailing newline. The time, level and msg keys come first, followed by
+// the entry's fields in order.
+func AppendJSON(buf []byte, entry *Entry) []byte {
+	return AppendJSONKeys(buf, entry, DefaultJSONKeys)
+}
+
+// AppendJSONKeys is like AppendJSON but uses the given keys for the
+// built-in attributes. Empty keys fall back to DefaultJSONKeys.
+func AppendJSONKeys(buf []byte, entry *Entry, keys JSONKeys) []byte {
+	return appendEncoded(buf, entry, encodingJSON, keys.withDefaults(), appendJSONKeys)
+}
+
+// appendJSONKeys encodes the entry as AppendJSONKeys, with keys filled in
+func appendJSONKeys(buf []byte, entry *Entry, keys JSONKeys) []byte {
+	buf = append(buf, '{')
+	buf = appendJSONString(buf, keys.Time)
+	buf = append(buf, ':', '"')
+	buf = entry.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"', ',')
+	buf = appendJSONString(buf, keys.Level)
+	buf = append(buf, ':')
+	level, ok := jsonLevelStrings[entry.Level]
+	if !ok {
+		level = "unknown"
+	}
+	buf = appendJSONString(buf, level)
+	buf = append(buf, ',')
+	buf = appendJSONString(buf, keys.Message)
+	buf = append(buf, ':')
+	buf = appendJSONString(buf, entry.Message)
+	if keys.Order == FieldsSorted && !slices.IsSortedFunc(entry.Fields, compareFieldKeys) {
+		return append(appendJSONFieldsSorted(buf, entry.Fields), '}')
+	}
+	for _, f := range entry.Fields {
+		buf = appendJSONField(buf, f)
+	}
+	return append(buf, '}')
+}
+
+// appendJSONField appends a field as a member of an object after others
+func appendJSONField(buf []byte, f Field) []byte {
+	buf = append(buf, ',')
+	buf = appendJSONString(buf, f.Key)
+	buf = append(buf, ':')
+	return appendJSONValue(buf, f)
+}
+
+// appendJSONFieldsSorted appends fields sorted by key, keeping fields with
+// the same key in order, without reordering the entry's fields
+func appendJSONFieldsSorted(buf []byte, fields []Field) []byte {
+	var scratch [16]int
+	order := scratch[:0]
+	if len(fields) > len(scratch) {
+		order = make([]int, 0, len(fields))
+	}
+	for i := range fields {
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return compareFieldKeys(fields[a], fields[b])
+	})
+	for _, i := range order {
+		buf = appendJSONField(buf, fields[i])
+	}
+	return buf
+}
+
+// compareFieldKeys orders fields by key
+func compareFieldKeys(a, b Field) int {
+	return strings.Compare(a.Key, b.Key)
+}
+
+// appendJSONValue appends the value of a single field in JSON form
+func appendJSONValue(buf []byte, f Field) []byte {
+	switch f.Type {
+	case StringType:
+		return appendJSONString(buf, f.Str)
+	case StringerType, LazyStringType:
+		return appendJSONString(buf, f.resolveString())
+	case IntType:
+		return appendInt(buf, f.Int)
+	case FloatType:
+		v := f.Float()
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// JSON has no representation for these values
+			return appendJSONString(buf, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(buf, f.Int != 0)
+	default:
+		return append(buf, "null"...)
+	}
+}
+
+// appendJSONString appends s as a quoted JSON string.
+// Invalid UTF-8 is replaced with U+FFFD so the output is always valid JSON.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// ParseJSON decodes a line produced by AppendJSON back into an Entry.
+// Field order is preserved. Strings, numbers and booleans become typed
+// fields; any other value is kept as its raw JSON text.
+func ParseJSON(line []byte) (*Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("loggo: entry is not a JSON object")
+	}
+
+	entry := &Entry{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		var str string
+		isString := len(raw) > 0 && raw[0] == '"'
+		if isString {
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case key == "time" && isString:
+			if entry.Time, err = time.Parse(time.RFC3339Nano, str); err != nil {
+				return nil, err
+			}
+		case key == "level" && isString:
+			entry.Level = parseJSONLevel(str)
+		case key == "msg" && isString:
+			entry.Message = str
+		case isString:
+			entry.Fields = append(entry.Fields, Str(key, str))
+		default:
+			entry.Fields = append(entry.Fields, parseJSONScalar(key, raw))
+		}
+	}
+	return entry, nil
+}
+
+// parseJSONScalar converts a non-string JSON value into a typed field
+func parseJSONScalar(key string, raw json.RawMessage) Field {
+	text := string(raw)
+	switch text {
+	case "true":
+		return Bool(key, true)
+	case "false":
+		return Bool(key, false)
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return Int64(key, i)
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return Float64(key, f)
+	}
+	return Str(key, text)
+}
+
+// parseJSONLevel maps a JSON level name back to a Level, defaulting to INFO
+func parseJSONLevel(name string) Level {
+	for level, s := range jsonLevelStrings {
+		if s == name {
+			return level
+		}
+	}
+	return INFO
+}
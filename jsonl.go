@@ -0,0 +1,197 @@
+package loggo
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy controls when a file sink forces written data to stable storage.
+type FsyncPolicy int
+
+// Available fsync policies, from fastest to most durable.
+const (
+	FsyncNever      FsyncPolicy = iota // Leave flushing to the operating system
+	FsyncInterval                      // Fsync periodically if data was written
+	FsyncEveryEntry                    // Fsync after every entry, for audit logs
+)
+
+// FsyncStats reports fsync activity of a file sink.
+type FsyncStats struct {
+	Count        int64         // Number of fsync calls
+	Errors       int64         // Number of failed fsync calls
+	TotalLatency time.Duration // Sum of all fsync latencies
+	MaxLatency   time.Duration // Slowest fsync observed
+	LastLatency  time.Duration // Latency of the most recent fsync
+}
+
+// AverageLatency returns the mean fsync latency, or zero if none happened.
+func (s FsyncStats) AverageLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+// JSONLFileSink is an append-only sink that writes one JSON object per line.
+// Durability is selected with an FsyncPolicy.
+type JSONLFileSink struct {
+	mu       sync.Mutex
+	file     *os.File
+	buf      []byte
+	policy   FsyncPolicy
+	keys     JSONKeys
+	checksum bool // Append a crc32 field to every record
+	dirty    bool // Data written since the last fsync
+	closed   bool
+	stats    FsyncStats
+	stopChan chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewJSONLFileSink opens (or creates) path in append mode and returns a sink
+// writing JSON lines to it. The interval is only used with FsyncInterval and
+// defaults to one second when not positive.
+func NewJSONLFileSink(path string, policy FsyncPolicy, interval time.Duration) (*JSONLFileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &JSONLFileSink{
+		file:   file,
+		buf:    make([]byte, 0, 1024),
+		policy: policy,
+	}
+	if policy == FsyncInterval {
+		if interval <= 0 {
+			interval = time.Second
+		}
+		s.stopChan = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.syncLoop(interval)
+	}
+	return s, nil
+}
+
+// WriteEntry appends the entry as a JSON line.
+func (s *JSONLFileSink) WriteEntry(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSinkClosed
+	}
+
+	s.buf = AppendJSONKeys(s.buf[:0], entry, s.keys)
+	if s.checksum {
+		s.buf = appendChecksum(s.buf, 0)
+	}
+	s.buf = append(s.buf, '\n')
+	if _, err := s.file.Write(s.buf); err != nil {
+		return err
+	}
+	s.dirty = true
+
+	if s.policy == FsyncEveryEntry {
+		return s.syncLocked()
+	}
+	return nil
+}
+
+// SetKeys sets the JSON keys used for the time, level and message.
+func (s *JSONLFileSink) SetKeys(keys JSONKeys) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// SetChecksums enables or disables a per-record "crc32" field, allowing
+// VerifyJSONL and SalvageJSONL to detect records torn or corrupted by a crash.
+func (s *JSONLFileSink) SetChecksums(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checksum = enabled
+}
+
+// Sync forces buffered data to stable storage regardless of policy.
+func (s *JSONLFileSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSinkClosed
+	}
+	return s.syncLocked()
+}
+
+// Stats returns a snapshot of the sink's fsync metrics.
+func (s *JSONLFileSink) Stats() FsyncStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops the background syncer, performs a final fsync unless the
+// policy is FsyncNever, and closes the file.
+func (s *JSONLFileSink) Close() error {
+	if s.stopChan != nil {
+		s.stopOnce.Do(func() {
+			close(s.stopChan)
+			<-s.done
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var syncErr error
+	if s.policy != FsyncNever && s.dirty {
+		syncErr = s.syncLocked()
+	}
+	return errors.Join(syncErr, s.file.Close())
+}
+
+// syncLoop periodically fsyncs the file while there is unsynced data
+func (s *JSONLFileSink) syncLoop(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && !s.closed {
+				s.syncLocked()
+			}
+			s.mu.Unlock()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// syncLocked fsyncs the file and records its latency.
+// The caller must hold s.mu.
+func (s *JSONLFileSink) syncLocked() error {
+	start := time.Now()
+	err := s.file.Sync()
+	latency := time.Since(start)
+
+	s.stats.Count++
+	s.stats.TotalLatency += latency
+	s.stats.LastLatency = latency
+	if latency > s.stats.MaxLatency {
+		s.stats.MaxLatency = latency
+	}
+	if err != nil {
+		s.stats.Errors++
+		return err
+	}
+	s.dirty = false
+	return nil
+}
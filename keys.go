@@ -0,0 +1,97 @@
+package loggo
+
+import "time"
+
+// Keys of well-known fields, so records written by different teams name
+// them alike and dashboards can rely on them. The names follow the
+// OpenTelemetry conventions where one exists; errors use ErrorKey.
+const (
+	DurationKey  = "duration"     // Time an operation took, as text such as "1.5s"
+	RequestIDKey = "request.id"   // ID of the request being served
+	TraceIDKey   = "trace_id"     // Trace ID, as in the OpenTelemetry log data model
+	SpanIDKey    = "span_id"      // Span ID, as in the OpenTelemetry log data model
+	UserIDKey    = "user.id"      // ID of the user on whose behalf the work is done
+	TenantIDKey  = "tenant.id"    // ID of the tenant owning the data
+	SessionIDKey = "session.id"   // ID of the user's session
+	ServiceKey   = "service.name" // Name of the service writing the record
+)
+
+// Duration returns a DurationKey field.
+func Duration(val time.Duration) Field {
+	return Dur(DurationKey, val)
+}
+
+// RequestID returns a RequestIDKey field.
+func RequestID(id string) Field {
+	return Str(RequestIDKey, id)
+}
+
+// TraceID returns a TraceIDKey field.
+func TraceID(id string) Field {
+	return Str(TraceIDKey, id)
+}
+
+// SpanID returns a SpanIDKey field.
+func SpanID(id string) Field {
+	return Str(SpanIDKey, id)
+}
+
+// UserID returns a UserIDKey field.
+func UserID(id string) Field {
+	return Str(UserIDKey, id)
+}
+
+// TenantID returns a TenantIDKey field.
+func TenantID(id string) Field {
+	return Str(TenantIDKey, id)
+}
+
+// SessionID returns a SessionIDKey field.
+func SessionID(id string) Field {
+	return Str(SessionIDKey, id)
+}
+
+// Field adds fields built with the field constructors, e.g.
+// logger.InfoEvent().Field(loggo.RequestID(id), loggo.Duration(d)).
+func (e *Event) Field(fields ...Field) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, fields...)
+	return e
+}
+
+// Duration adds a DurationKey field.
+func (e *Event) Duration(val time.Duration) *Event {
+	return e.Field(Duration(val))
+}
+
+// RequestID adds a RequestIDKey field.
+func (e *Event) RequestID(id string) *Event {
+	return e.Field(RequestID(id))
+}
+
+// TraceID adds a TraceIDKey field.
+func (e *Event) TraceID(id string) *Event {
+	return e.Field(TraceID(id))
+}
+
+// SpanID adds a SpanIDKey field.
+func (e *Event) SpanID(id string) *Event {
+	return e.Field(SpanID(id))
+}
+
+// UserID adds a UserIDKey field.
+func (e *Event) UserID(id string) *Event {
+	return e.Field(UserID(id))
+}
+
+// TenantID adds a TenantIDKey field.
+func (e *Event) TenantID(id string) *Event {
+	return e.Field(TenantID(id))
+}
+
+// SessionID adds a SessionIDKey field.
+func (e *Event) SessionID(id string) *Event {
+	return e.Field(SessionID(id))
+}
@@ -0,0 +1,84 @@
+package loggo
+
+import "fmt"
+
+// Keyvals with a special meaning to KitLogger.
+const (
+	kitLevelKey   = "level" // Key of level.Key() in go-kit/log/level
+	kitMessageKey = "msg"   // Message key conventionally used with go-kit
+	kitMissing    = "(MISSING)"
+)
+
+// KitLogger adapts a Logger to the go-kit log.Logger interface,
+//
+//	type Logger interface {
+//		Log(keyvals ...interface{}) error
+//	}
+//
+// so services using go-kit middlewares can log through loggo without this
+// package depending on go-kit:
+//
+//	var kitLogger kitlog.Logger = logger.KitLogger(loggo.INFO)
+//	kitLogger = kitlog.With(kitLogger, "component", "transport")
+type KitLogger struct {
+	logger *Logger
+	level  Level
+}
+
+// KitLogger returns a go-kit logger writing to l. Records are logged at
+// level unless they have a "level" keyval, as added by go-kit/log/level,
+// naming another level; levels above CRITICAL are logged as CRITICAL, so
+// a dependency cannot exit or panic the process.
+func (l *Logger) KitLogger(level Level) *KitLogger {
+	return &KitLogger{logger: l, level: level}
+}
+
+// Log logs keyvals as one record. The "msg" keyval, if any, is the
+// message; the "level" keyval selects the level and the others become
+// fields, in order. Keys that are not strings are formatted with
+// fmt.Sprint, and a key without a value gets the value "(MISSING)", as
+// in go-kit. Log always returns nil.
+func (k *KitLogger) Log(keyvals ...any) error {
+	level := k.level
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if kitKey(keyvals[i]) != kitLevelKey {
+			continue
+		}
+		if parsed, err := ParseLevel(fmt.Sprint(keyvals[i+1])); err == nil {
+			level = min(parsed, CRITICAL)
+		}
+	}
+	e := k.logger.newEvent(level)
+	if e == nil {
+		return nil
+	}
+
+	var msg string
+	for i := 0; i < len(keyvals); i += 2 {
+		key := kitKey(keyvals[i])
+		var val any = kitMissing
+		if i+1 < len(keyvals) {
+			val = keyvals[i+1]
+		}
+		switch key {
+		case kitMessageKey:
+			msg = fmt.Sprint(val)
+			continue
+		case kitLevelKey:
+			if _, err := ParseLevel(fmt.Sprint(val)); err == nil {
+				continue
+			}
+		}
+		e.fields = append(e.fields, Any(key, val))
+	}
+	e.Msg(msg)
+	return nil
+}
+
+// kitKey returns a keyval key as a string
+func kitKey(key any) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,122 @@
+package loggo
+
+import (
+	"maps"
+	"strings"
+	"unicode/utf8"
+)
+
+// LevelStyle selects the level names used in text output.
+type LevelStyle int
+
+// Level name styles.
+const (
+	LevelFull   LevelStyle = iota // DEBUG, INFO, WARN, ERROR, CRIT, FATAL, PANIC
+	LevelShort                    // DBG, INF, WRN, ERR, CRT, FTL, PNC (as in zerolog)
+	LevelLetter                   // D, I, W, E, C, F, P
+)
+
+// shortLevelNames are the three-letter level names
+var shortLevelNames = map[Level]string{
+	DEBUG:    "DBG",
+	INFO:     "INF",
+	WARN:     "WRN",
+	ERROR:    "ERR",
+	CRITICAL: "CRT",
+	FATAL:    "FTL",
+	PANIC:    "PNC",
+}
+
+// LevelFormat configures the level tag at the start of text lines.
+// The zero value writes bare upper-case names padded to a common width.
+type LevelFormat struct {
+	Style     LevelStyle       // Names used for the levels
+	Labels    map[Level]string // Overrides the name of individual levels
+	Lowercase bool             // Write names in lower case
+	Brackets  bool             // Enclose names in square brackets
+	Width     int              // Pad tags with spaces to this width; 0 pads to the longest tag, negative disables padding
+}
+
+// DefaultLevelFormat is the format of the level tags written by default,
+// e.g. "[INFO] " and "[ERROR]".
+var DefaultLevelFormat = LevelFormat{Brackets: true}
+
+// levelTags are the formatted tags of the known levels
+type levelTags [PANIC + 1]string
+
+// tags formats the tag of every level
+func (f LevelFormat) tags() *levelTags {
+	var tags levelTags
+	width := f.Width
+	for level := range tags {
+		tag := Level(level).String()
+		switch f.Style {
+		case LevelShort:
+			tag = shortLevelNames[Level(level)]
+		case LevelLetter:
+			tag = tag[:1]
+		}
+		if label, ok := f.Labels[Level(level)]; ok {
+			tag = label
+		}
+		if f.Lowercase {
+			tag = strings.ToLower(tag)
+		}
+		if f.Brackets {
+			tag = "[" + tag + "]"
+		}
+		tags[level] = tag
+		if f.Width == 0 {
+			width = max(width, utf8.RuneCountInString(tag))
+		}
+	}
+	for level, tag := range tags {
+		if n := utf8.RuneCountInString(tag); n < width {
+			tags[level] = tag + strings.Repeat(" ", width-n)
+		}
+	}
+	return &tags
+}
+
+// SetLevelFormat sets how level tags are written in text lines, e.g.
+// LevelFormat{Style: LevelShort} for zerolog-style "INF" tags.
+func (l *Logger) SetLevelFormat(format LevelFormat) {
+	l.levelTags.Store(format.tags())
+}
+
+// DefaultLevelSymbols are the level markers of SetLevelSymbols.
+var DefaultLevelSymbols = map[Level]string{
+	DEBUG:    "•",
+	INFO:     "ℹ",
+	WARN:     "⚠",
+	ERROR:    "✖",
+	CRITICAL: "✖",
+	FATAL:    "✖",
+	PANIC:    "✖",
+}
+
+// SetLevelSymbols writes symbols instead of level tags, for developer
+// tools and CLIs, e.g. SetLevelSymbols(map[Level]string{INFO: "✔"}).
+// Levels without a symbol in symbols use DefaultLevelSymbols. Symbols are
+// only written while every output is a terminal; output to files, pipes
+// and buffers keeps the level tags. A nil map turns symbols off.
+func (l *Logger) SetLevelSymbols(symbols map[Level]string) {
+	if symbols == nil {
+		l.symbolTags.Store(nil)
+		return
+	}
+	labels := maps.Clone(DefaultLevelSymbols)
+	maps.Copy(labels, symbols)
+	l.symbolTags.Store(LevelFormat{Labels: labels}.tags())
+}
+
+// levelTag returns the tag written for a level
+func (l *Logger) levelTag(level Level) string {
+	if tags := l.symbolTags.Load(); tags != nil && level >= DEBUG && level <= PANIC && l.output.terminal.Load() {
+		return tags[level]
+	}
+	if tags := l.levelTags.Load(); tags != nil && level >= DEBUG && level <= PANIC {
+		return tags[level]
+	}
+	return level.PaddedString()
+}
@@ -1,38 +1,20 @@
 package loggo
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"runtime"
 	"slices"
-	"sort"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Color codes for terminal output.
-// These are pre-calculated constants to avoid string allocations.
-const (
-	colorReset  = "\033[0m"  // Reset color
-	colorRed    = "\033[31m" // Red color
-	colorGreen  = "\033[32m" // Green color
-	colorYellow = "\033[33m" // Yellow color
-	colorBlue   = "\033[34m" // Blue color
-	colorPurple = "\033[35m" // Purple color
-	colorCyan   = "\033[36m" // Cyan color
-)
-
-// levelColors maps log levels to their corresponding color codes
-var levelColors = map[Level]string{
-	DEBUG:    colorCyan,
-	INFO:     colorGreen,
-	WARN:     colorYellow,
-	ERROR:    colorRed,
-	CRITICAL: colorRed,
-	FATAL:    colorRed,
-	PANIC:    colorRed,
-}
+// colorReset ends the colored part of a text line
+const colorReset = "\033[0m"
 
 // paddedLevelStrings maps log levels to their padded string representations
 var paddedLevelStrings = map[Level]string{
@@ -56,375 +38,838 @@ var (
 // multiWriter is a custom writer that writes to multiple outputs
 type multiWriter struct {
 	writers []io.Writer
+	tees    []io.Writer // Added with Tee, kept when the outputs are replaced
 	mu      sync.Mutex
+
+	terminal atomic.Bool                // Every output is a terminal
+	async    atomic.Pointer[asyncQueue] // Queue from SetAsync; nil when writing synchronously
 }
 
 // newMultiWriter creates a new multiWriter with the given writers
 func newMultiWriter(writers ...io.Writer) *multiWriter {
-	return &multiWriter{
+	w := &multiWriter{
 		writers: writers,
 	}
+	w.checkTerminal()
+	return w
 }
 
-// write writes the given data to all registered writers
+// write writes the given data to all registered writers, or queues it
+// if the logger is asynchronous
 func (w *multiWriter) write(data []byte) {
+	if q := w.async.Load(); q != nil && q.enqueue(data) {
+		return
+	}
+	w.writeNow(data)
+}
+
+// writeNow writes the given data to all registered writers
+func (w *multiWriter) writeNow(data []byte) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	for _, writer := range w.writers {
 		writer.Write(data)
 	}
+	for _, writer := range w.tees {
+		writer.Write(data)
+	}
 }
 
-// workerPool manages a pool of workers for executing jobs
+// count returns the number of outputs, not counting tees
+func (w *multiWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.writers)
+}
+
+// set replaces the outputs
+func (w *multiWriter) set(writers ...io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writers = writers
+	w.checkTerminal()
+}
+
+// checkTerminal records whether every output is a terminal.
+// The caller must hold w.mu or own w.
+func (w *multiWriter) checkTerminal() {
+	all := len(w.writers)+len(w.tees) > 0
+	for _, writer := range slices.Concat(w.writers, w.tees) {
+		all = all && isTerminal(writer)
+	}
+	w.terminal.Store(all)
+}
+
+// Worker pool sizing.
+const (
+	workerQueueSize = 16 // Jobs each lane of a worker holds
+	workerBatchSize = 8  // Jobs a worker takes from a lane at once
+	workerSpins     = 4  // Yields of an idle worker before it sleeps
+)
+
+// workerPool manages a pool of workers for executing jobs.
+// Jobs are queued in two lanes: urgent jobs (hooks for ERROR and above)
+// are always taken before normal ones, so under load the most important
+// records are handled first. Every worker consumes its own lock-free
+// rings, so submitting a job costs a CompareAndSwap rather than a channel
+// send; submitters hand jobs to the workers in turn, moving on to the next
+// one when a lane is full.
 type workerPool struct {
-	jobs     chan func()
-	wg       sync.WaitGroup
+	workers  []*poolWorker
+	next     atomic.Uint64 // Worker offered the next job
+	sending  atomic.Int64  // Submissions in progress
+	waiting  atomic.Int32  // Submitters waiting for room
+	room     chan struct{} // Signalled by workers when submitters wait
+	stopped  atomic.Bool
 	stopChan chan struct{}
-	workers  int
-	mu       sync.Mutex // Mutex to protect stop channel
-	stopped  bool       // Flag to track if pool is stopped
+	wg       sync.WaitGroup
+}
+
+// poolWorker is a worker and the lanes it consumes
+type poolWorker struct {
+	urgent *mpscRing[func()] // ERROR and above
+	jobs   *mpscRing[func()] // Everything else
+	idle   atomic.Bool       // Set while the worker waits for wake
+	wake   chan struct{}     // Signalled by submitters when idle
+
+	// Owned by the worker
+	batch       []func()
+	urgentBatch []func()
 }
 
 // newWorkerPool creates a new worker pool with the specified number of workers
 func newWorkerPool(workers int) *workerPool {
-	pool := &workerPool{
-		jobs:     make(chan func(), workers*2),
-		stopChan: make(chan struct{}),
-		workers:  workers,
-	}
+	pool := &workerPool{room: make(chan struct{}, 1), stopChan: make(chan struct{})}
 
 	for range workers {
+		w := &poolWorker{
+			urgent:      newMPSCRing[func()](workerQueueSize),
+			jobs:        newMPSCRing[func()](workerQueueSize),
+			wake:        make(chan struct{}, 1),
+			batch:       make([]func(), 0, workerBatchSize),
+			urgentBatch: make([]func(), 0, workerBatchSize),
+		}
+		pool.workers = append(pool.workers, w)
 		pool.wg.Add(1)
-		go pool.worker()
+		go pool.worker(w)
 	}
 
 	return pool
 }
 
-// worker processes jobs from the queue
-func (p *workerPool) worker() {
+// worker processes jobs from the lanes of w, urgent lane first, until the
+// pool is stopped and the lanes are drained
+func (p *workerPool) worker(w *poolWorker) {
 	defer p.wg.Done()
 
-	for {
+	for spins := 0; ; {
+		if w.runBatch() {
+			p.signalRoom()
+			spins = 0
+			continue
+		}
+		// Yield a few times before sleeping: under load the next job
+		// usually arrives sooner than a wake-up would
+		if spins < workerSpins {
+			spins++
+			runtime.Gosched()
+			continue
+		}
+		spins = 0
+		w.idle.Store(true)
+		// A submitter either sees idle or its job is seen here
+		if w.urgent.ready() || w.jobs.ready() {
+			w.idle.Store(false)
+			continue
+		}
 		select {
-		case job, ok := <-p.jobs:
-			if !ok {
-				return
-			}
-			job()
+		case <-w.wake:
+			w.idle.Store(false)
 		case <-p.stopChan:
+			w.idle.Store(false)
+			w.drain()
 			return
 		}
 	}
 }
 
-// event represents a log event that can be built using a chained API.
-// The event type provides a fluent interface for building log messages
-// with zero allocations. It is created by calling one of the level methods
-// on a Logger (e.g., logger.Debug(), logger.Info(), etc.).
+// runBatch runs a batch of urgent jobs or, if there are none, a batch of
+// normal jobs, running urgent jobs queued meanwhile between them. It
+// returns false if both lanes are empty.
+func (w *poolWorker) runBatch() bool {
+	if w.runUrgent() {
+		return true
+	}
+	w.batch = w.jobs.popBatch(w.batch[:0], workerBatchSize)
+	for i, job := range w.batch {
+		if i > 0 {
+			for w.runUrgent() {
+			}
+		}
+		job()
+	}
+	clear(w.batch)
+	return len(w.batch) > 0
+}
+
+// runUrgent runs a batch of urgent jobs and reports whether there were any
+func (w *poolWorker) runUrgent() bool {
+	w.urgentBatch = w.urgent.popBatch(w.urgentBatch[:0], workerBatchSize)
+	for _, job := range w.urgentBatch {
+		job()
+	}
+	clear(w.urgentBatch)
+	return len(w.urgentBatch) > 0
+}
+
+// drain runs the queued jobs, urgent lane first
+func (w *poolWorker) drain() {
+	for w.runBatch() {
+	}
+}
+
+// notify wakes the worker if it is waiting for jobs
+func (w *poolWorker) notify() {
+	if w.idle.Load() {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// signalRoom wakes a submitter waiting for room in the lanes
+func (p *workerPool) signalRoom() {
+	if p.waiting.Load() > 0 {
+		select {
+		case p.room <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// queued returns the number of waiting jobs and the capacity of both lanes
+func (p *workerPool) queued() (int, int) {
+	queued, capacity := 0, 0
+	for _, w := range p.workers {
+		queued += w.urgent.len() + w.jobs.len()
+		capacity += w.urgent.cap() + w.jobs.cap()
+	}
+	return queued, capacity
+}
+
+// Event represents a log event that can be built using a chained API.
+// The Event type provides a fluent interface for building log messages
+// with zero allocations. It is created by calling one of the level event
+// methods on a Logger (e.g., logger.DebugEvent(), logger.InfoEvent(), etc.).
+// A nil Event is returned when the level is disabled; all methods are
+// safe to call on a nil Event and do nothing.
 //
 // Example:
 //
 //	logger := loggo.New()
-//	logger.Info().Msgf("Processing request %d", 123)
+//	logger.InfoEvent().Msgf("Processing request %d", 123)
 //
 // Performance Note: Events are designed for zero-allocation logging by
 // writing directly to a pooled buffer. The Msgf method formats and writes
 // the message in a single operation, minimizing memory allocations.
-type event struct {
-	logger *Logger
-	level  Level
-	buf    *[]byte
+type Event struct {
+	logger     *Logger
+	level      Level
+	buf        *[]byte
+	fields     []Field
+	internal   bool         // Generated by the logger itself and never sampled
+	grouped    bool         // Logged through a LoggerGroup, which exits or panics once
+	at         time.Time    // Timestamp set with Time, zero for the current time
+	arena      *eventArena  // Memory of the event with the loggo_arena build tag
+	stackField int          // Position of the StackKey field in fields plus 1; 0 for none
+	entryID    string       // ID shared with continuation records, set when paging
+	pages      []pagedField // Fields continued in continuation records
 }
 
-// msgf formats and writes the message to the event buffer.
+// Time sets the event's timestamp, for records that were already stamped
+// elsewhere, e.g. when replaying or bridging from another system. Without
+// it, the time the message is written is used.
+func (e *Event) Time(t time.Time) *Event {
+	if e == nil {
+		return nil
+	}
+	e.at = t
+	return e
+}
+
+// timestamp returns the time set with Time, or the current time from
+// the logger's time source, adding the skew it reports as a field
+func (e *Event) timestamp() time.Time {
+	if !e.at.IsZero() {
+		return e.at
+	}
+	source := e.logger.timeSource.Load()
+	if source == nil {
+		return time.Now()
+	}
+	now, skew := (*source).Now()
+	if skew != 0 {
+		e.fields = append(e.fields, Dur(ClockSkewKey, skew))
+	}
+	return now
+}
+
+// Msgf formats and writes the message to the event buffer.
 // The format string and arguments follow the same rules as fmt.Sprintf.
 // After writing the message, the buffer is returned to the pool.
 //
 // Example:
 //
-//	logger.Info().msgf("Processing request %d from %s", 123, "user")
+//	logger.InfoEvent().Msgf("Processing request %d from %s", 123, "user")
 //
 // Performance Note: This method writes directly to the buffer without
 // intermediate string allocations. The buffer is automatically returned
 // to the pool after use.
-func (e *event) msgf(format string, args ...any) {
+func (e *Event) Msgf(format string, args ...any) {
 	if e == nil {
 		return
 	}
-	defer e.logger.putBuffer(e.buf)
-
-	// Format timestamp
-	timestamp := e.logger.getFormattedTime()
-
-	// Pre-allocate buffer with estimated size
-	// Format: color + level + reset + timestamp + ": " + message + "\n"
-	estimatedSize := len(levelColors[e.level]) + len(e.level.PaddedString()) +
-		len(colorReset) + len(timestamp) + 2 + len(format) + 1
-
-	// Resize buffer if needed
-	if cap(*e.buf) < estimatedSize {
-		newBuf := e.logger.getBuffer(estimatedSize)
-		*newBuf = append(*newBuf, *e.buf...)
-		e.buf = newBuf
-	}
-
-	// Write the formatted message directly to the buffer
-	*e.buf = fmt.Appendf(*e.buf, "%s%s%s %s: ",
-		levelColors[e.level],
-		e.level.PaddedString(),
-		colorReset,
-		timestamp,
-	)
-
-	// Optimize common formatting patterns
-	if len(args) == 0 {
-		*e.buf = append(*e.buf, format...)
-	} else if len(args) == 1 {
-		switch v := args[0].(type) {
-		case string:
-			*e.buf = append(*e.buf, v...)
-		case int:
-			*e.buf = strconv.AppendInt(*e.buf, int64(v), 10)
-		case int64:
-			*e.buf = strconv.AppendInt(*e.buf, v, 10)
-		case float64:
-			*e.buf = strconv.AppendFloat(*e.buf, v, 'f', -1, 64)
-		case error:
-			*e.buf = append(*e.buf, v.Error()...)
-		default:
-			*e.buf = fmt.Appendf(*e.buf, format, args...)
+	defer e.release()
+	if !e.sample(format, len(args) == 0) {
+		return
+	}
+	quotas, component, ok := e.checkQuota()
+	if !ok {
+		return
+	}
+	circuit, ok := e.checkCircuit()
+	if !ok {
+		return
+	}
+	e.addStack()
+	e.resolveFields()
+	if !e.dedupe(format, args) {
+		return
+	}
+	e.encryptFields()
+	e.addCaller()
+	e.addFingerprint(format, len(args) == 0)
+	e.observeSchema()
+
+	now := e.timestamp()
+	var message string
+	if formatter := e.logger.formatter.Load(); formatter != nil {
+		message = format
+		if len(args) > 0 {
+			message = fmt.Sprintf(format, args...)
 		}
+		e.formatLine(*formatter, now, message)
 	} else {
-		*e.buf = fmt.Appendf(*e.buf, format, args...)
+		// Write the line header directly to the buffer
+		colors, start := e.beginLine(e.logger.getFormattedTime(now), len(format))
+
+		// Optimize common formatting patterns
+		if len(args) == 0 {
+			*e.buf = append(*e.buf, format...)
+		} else if len(args) == 1 && (format == "%s" || format == "%d" || format == "%v") {
+			*e.buf = appendSingleArg(*e.buf, format, args[0])
+		} else {
+			*e.buf = fmt.Appendf(*e.buf, format, args...)
+		}
+		e.endLine(colors, start)
 	}
 
-	*e.buf = append(*e.buf, '\n')
-
 	// Write to output
 	e.logger.output.write(*e.buf)
-
-	// Execute hooks if any exist, but only format message if hooks are present
-	if len(e.logger.hooks) > 0 {
-		// Only format message if hooks are present
-		message := fmt.Sprintf(format, args...)
-		e.logger.executeHooks(e.level, message)
+	if quotas != nil {
+		quotas.account(e.logger, component, len(*e.buf))
+	}
+	if circuit != nil {
+		circuit.account(len(*e.buf))
 	}
 
-	if e.level == FATAL {
-		e.logger.wg.Wait()
-		e.logger.workerPool.stop()
-		exitFunc(1)
+	// Only format the message if something downstream needs it
+	if message == "" && e.needsMessage() {
+		if len(args) == 0 {
+			message = format
+		} else {
+			message = fmt.Sprintf(format, args...)
+		}
 	}
-	if e.level == PANIC {
-		message := fmt.Sprintf(format, args...)
-		e.logger.wg.Wait()
-		e.logger.workerPool.stop()
-		panicFunc(message)
+	e.finish(now, message)
+}
+
+// appendSingleArg appends the message of Msgf called with a lone "%s",
+// "%d" or "%v" verb, writing strings, errors and integers without fmt
+func appendSingleArg(buf []byte, format string, arg any) []byte {
+	switch v := arg.(type) {
+	case string:
+		if format != "%d" {
+			return append(buf, v...)
+		}
+	case int:
+		if format != "%s" {
+			return appendInt(buf, int64(v))
+		}
+	case int64:
+		if format != "%s" {
+			return appendInt(buf, v)
+		}
+	case error:
+		if _, custom := v.(fmt.Formatter); !custom && format != "%d" {
+			return append(buf, v.Error()...)
+		}
 	}
+	return fmt.Appendf(buf, format, arg)
 }
 
-// msg writes the message to the event buffer.
-// This is a non-formatted version of msgf.
-func (e *event) msg(msg string) {
+// Msg writes the message to the event buffer.
+// This is a non-formatted version of Msgf.
+func (e *Event) Msg(msg string) {
 	if e == nil {
 		return
 	}
-	defer e.logger.putBuffer(e.buf)
+	defer e.release()
+	if !e.sample(msg, true) {
+		return
+	}
+	quotas, component, ok := e.checkQuota()
+	if !ok {
+		return
+	}
+	circuit, ok := e.checkCircuit()
+	if !ok {
+		return
+	}
+	e.addStack()
+	e.resolveFields()
+	if !e.dedupe(msg, nil) {
+		return
+	}
+	e.encryptFields()
+	e.addCaller()
+	e.addFingerprint(msg, true)
+	e.observeSchema()
+
+	now := e.timestamp()
+	if formatter := e.logger.formatter.Load(); formatter != nil {
+		e.formatLine(*formatter, now, msg)
+	} else {
+		// Write the line directly to the buffer
+		colors, start := e.beginLine(e.logger.getFormattedTime(now), len(msg))
+		*e.buf = append(*e.buf, msg...)
+		e.endLine(colors, start)
+	}
 
-	// Format timestamp
-	timestamp := e.logger.getFormattedTime()
+	// Write to output
+	e.logger.output.write(*e.buf)
+	if quotas != nil {
+		quotas.account(e.logger, component, len(*e.buf))
+	}
+	if circuit != nil {
+		circuit.account(len(*e.buf))
+	}
 
-	// Pre-allocate buffer with estimated size
-	// Format: color + level + reset + timestamp + ": " + message + "\n"
-	estimatedSize := len(levelColors[e.level]) + len(e.level.PaddedString()) +
-		len(colorReset) + len(timestamp) + 2 + len(msg) + 1
+	e.finish(now, msg)
+}
 
-	// Resize buffer if needed
-	if cap(*e.buf) < estimatedSize {
-		newBuf := e.logger.getBuffer(estimatedSize)
-		*newBuf = append(*newBuf, *e.buf...)
-		e.buf = newBuf
+// resolveFields evaluates deferred fields exactly once so that every
+// encoder sees the same value, and applies the UTF-8 policy to them.
+func (e *Event) resolveFields() {
+	policy := UTF8Policy(e.logger.utf8Policy.Load())
+	for i := range e.fields {
+		f := &e.fields[i]
+		switch f.Type {
+		case StringerType, LazyStringType:
+			*f = Str(f.Key, f.resolveString())
+		}
+		if policy != UTF8Keep {
+			f.Key = policy.Apply(f.Key)
+			if f.Type == StringType {
+				f.Str = policy.Apply(f.Str)
+			}
+		}
 	}
+}
 
-	// Write the formatted message directly to the buffer
-	*e.buf = fmt.Appendf(*e.buf, "%s%s%s %s: %s\n",
-		levelColors[e.level],
-		e.level.PaddedString(),
-		colorReset,
-		timestamp,
-		msg,
-	)
+// needsMessage reports whether the plain message is required after the
+// text line has been written (by sinks, hooks or a panic).
+func (e *Event) needsMessage() bool {
+	l := e.logger
+	return e.level == PANIC || l.hookCount.Load() > 0 || l.replay.size.Load() > 0 || len(l.loadSinks()) > 0
+}
 
-	// Write to output
-	e.logger.output.write(*e.buf)
+// finish delivers the event to sinks and hooks and applies the
+// FATAL and PANIC behaviors.
+func (e *Event) finish(now time.Time, message string) {
+	l := e.logger
+	message = UTF8Policy(l.utf8Policy.Load()).Apply(message)
+	if !l.colors() {
+		message = StripANSI(message)
+	}
+
+	if l.probes.Load() {
+		fireProbe(e.level, message)
+	}
+	l.report.Load().count(e.level)
+
+	if sinks := l.loadSinks(); len(sinks) > 0 {
+		entry := getEntry()
+		entry.Time, entry.Level, entry.Message = now, e.level, message
+		entry.Fields = append(entry.Fields, l.loadEnvFields()...)
+		entry.Fields = append(entry.Fields, e.fields...)
+		if l.entryHashes.Load() {
+			entry.Fields = append(entry.Fields, Str(EntryHashKey, EntryHash(entry)))
+		}
+		if len(sinks) > 1 {
+			attachEncodingCache(entry)
+		}
+		for _, sink := range sinks {
+			if err := sink.WriteEntry(entry); err != nil {
+				l.reportError("Sink error", err)
+				if dl := l.deadLetter.Load(); dl != nil {
+					(*dl).DeadLetter(sinkName(sink), err, entry)
+				}
+			}
+		}
+		if entry.cache != nil {
+			detachEncodingCache(entry)
+		}
+		putEntry(entry)
+	}
 
 	// Execute hooks if any exist
-	if len(e.logger.hooks) > 0 {
-		e.logger.executeHooks(e.level, msg)
+	if l.hookCount.Load() > 0 || l.replay.size.Load() > 0 {
+		l.executeHooks(e.level, message)
+	}
+	if len(e.pages) > 0 {
+		e.writeContinuations()
 	}
 
+	if e.level >= FATAL && l.crashMirror.Load() {
+		mirrorCrash(e.level, *e.buf)
+	}
+	if e.grouped {
+		return
+	}
 	if e.level == FATAL {
-		e.logger.wg.Wait()
-		e.logger.workerPool.stop()
+		l.Flush()
+		l.workerPool.stop()
 		exitFunc(1)
 	}
 	if e.level == PANIC {
-		e.logger.wg.Wait()
-		e.logger.workerPool.stop()
-		panicFunc(msg)
+		l.Flush()
+		l.workerPool.stop()
+		panicFunc(message)
 	}
 }
 
-// stop stops the worker pool and waits for all workers to finish.
-// It is safe to call multiple times.
+// stop stops the worker pool after running the queued jobs and waits for
+// all workers to finish. It is safe to call multiple times.
 func (p *workerPool) stop() {
-	p.mu.Lock()
-	if p.stopped {
-		p.mu.Unlock()
+	if !p.stopped.CompareAndSwap(false, true) {
 		return
 	}
-	p.stopped = true
 	close(p.stopChan)
-	close(p.jobs)
-	p.mu.Unlock()
+	for p.sending.Load() > 0 {
+		runtime.Gosched()
+	}
 	p.wg.Wait()
+	// Jobs queued after the workers drained their lanes
+	for _, w := range p.workers {
+		w.drain()
+	}
 }
 
-// submit submits a job to the worker pool.
-// If the pool is stopped, the job is silently dropped.
-func (p *workerPool) submit(job func()) {
-	p.mu.Lock()
-	if p.stopped {
-		p.mu.Unlock()
-		return
+// submit submits a job to the worker pool, on the urgent lane if urgent.
+// When every lane is full it waits for a worker to make room. It returns
+// false, without running the job, if the pool is stopped.
+func (p *workerPool) submit(job func(), urgent bool) bool {
+	p.sending.Add(1)
+	defer p.sending.Add(-1)
+	if p.stopped.Load() {
+		return false
+	}
+
+	start := p.next.Add(1)
+	if p.offer(job, urgent, start) {
+		return true
+	}
+
+	// Every lane is full: wait for a worker to make room. Registering as
+	// waiting before trying again means a worker emptying a lane after
+	// the attempt sees the waiter and signals it.
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+	for !p.offer(job, urgent, start) {
+		select {
+		case <-p.room:
+		case <-p.stopChan:
+			return false
+		}
 	}
-	p.mu.Unlock()
+	// Pass the signal on to the next waiter, if any
+	if p.waiting.Load() > 1 {
+		p.signalRoom()
+	}
+	return true
+}
 
-	select {
-	case p.jobs <- job:
-	case <-p.stopChan:
+// offer queues job on the first worker with room in the lane, starting
+// from worker start, and reports whether it was queued
+func (p *workerPool) offer(job func(), urgent bool, start uint64) bool {
+	n := uint64(len(p.workers))
+	for i := range n {
+		w := p.workers[(start+i)%n]
+		lane := w.jobs
+		if urgent {
+			lane = w.urgent
+		}
+		if lane.push(job) {
+			w.notify()
+			return true
+		}
 	}
+	return false
 }
 
 // sortedKeys returns a sorted slice of map keys
-func sortedKeys[K comparable, V any](m map[K]V) []K {
-	keys := make([]K, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// newEvent creates a new event with the given level.
+// It returns nil if the level is disabled.
+func (l *Logger) newEvent(level Level) *Event {
+	if level < l.threshold() {
+		return nil
+	}
+	e := allocEvent()
+	e.logger = l
+	e.level = level
+	e.buf = l.getBuffer(int(l.bufSize.Load()))
+	if len(l.bound) > 0 {
+		// Copied because resolveFields rewrites deferred fields in place
+		if e.fields == nil {
+			e.fields = make([]Field, 0, len(l.bound)+4)
+		}
+		e.fields = append(e.fields, l.bound...)
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j])
-	})
-	return keys
+	return e
 }
 
-// getBuffer gets a buffer from the pool
-func (l *Logger) getBuffer(size int) *[]byte {
-	if size > l.bufSize*4 {
-		buf := make([]byte, 0, size)
-		return &buf
+// getFormattedTime returns a formatted timestamp, cached per second for
+// formats without fractions of a second. The cache is swapped atomically,
+// so the cached path takes no lock.
+func (l *Logger) getFormattedTime(now time.Time) string {
+	cache := l.timeCache.Load()
+	key := now.Unix()
+	if key == cache.second && !cache.subSecond {
+		return cache.value
+	}
+	if cache.unixMillis {
+		return string(appendUnixMillis(nil, now))
 	}
-	buf := l.pool.Get().(*[]byte)
-	*buf = (*buf)[:0]
-	return buf
+	var scratch [64]byte
+	formatted := string(appendTime(scratch[:0], now, cache.format))
+	if !cache.subSecond {
+		// Losing the race to another goroutine or to SetTimeFormat only
+		// costs a format on the next call
+		next := *cache
+		next.second, next.value = key, formatted
+		l.timeCache.CompareAndSwap(cache, &next)
+	}
+	return formatted
 }
 
-// putBuffer returns a buffer to the pool
-func (l *Logger) putBuffer(buf *[]byte) {
-	if buf == nil {
-		return
+// executeHooks runs the synchronous hooks and queues the others on the
+// worker pool, in priority order, after keeping the record for replay
+func (l *Logger) executeHooks(level Level, msg string) {
+	l.mu.Lock()
+	if l.replay.size.Load() > 0 {
+		l.replay.add(level, msg)
+	}
+	// Sorted by priority and replaced rather than modified by addHook and
+	// removeHook, so the slice can be read without the lock
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	async := false
+	for _, hook := range hooks {
+		if hook.sync {
+			l.runHook(hook, level, msg)
+		} else {
+			async = true
+		}
 	}
-	if cap(*buf) > l.bufSize*4 {
+	if !async {
 		return
 	}
-	l.pool.Put(buf)
+
+	job := getHookJob()
+	job.logger, job.hooks, job.level, job.msg = l, hooks, level, msg
+	job.epoch = l.hookJobs.add()
+	if !l.workerPool.submit(job.run, level >= ERROR) {
+		job.finish()
+	}
 }
 
-// newEvent creates a new event with the given level
-func (l *Logger) newEvent(level Level) *event {
-	if level < l.level {
-		return nil
+// hookJob delivers a record to the asynchronous hooks from the worker
+// pool. Jobs are pooled with run bound to deliver, so queuing one does
+// not allocate.
+type hookJob struct {
+	logger *Logger
+	hooks  []Hook
+	level  Level
+	msg    string
+	epoch  uint64
+	run    func()
+}
+
+// hookJobPool recycles hook jobs
+var hookJobPool sync.Pool
+
+// getHookJob returns an empty job from the pool
+func getHookJob() *hookJob {
+	if job, ok := hookJobPool.Get().(*hookJob); ok {
+		return job
 	}
-	buf := l.getBuffer(l.bufSize)
-	return &event{
-		logger: l,
-		level:  level,
-		buf:    buf,
+	job := new(hookJob)
+	job.run = job.deliver
+	return job
+}
+
+// deliver runs the asynchronous hooks
+func (j *hookJob) deliver() {
+	defer j.finish()
+	for _, hook := range j.hooks {
+		if !hook.sync {
+			j.logger.runHook(hook, j.level, j.msg)
+		}
 	}
 }
 
-// getFormattedTime returns a formatted timestamp, using caching for efficiency
-func (l *Logger) getFormattedTime() string {
-	now := time.Now()
-	key := now.Unix()
+// finish marks the job done and returns it to the pool
+func (j *hookJob) finish() {
+	j.logger.hookJobs.done(j.epoch)
+	*j = hookJob{run: j.run}
+	hookJobPool.Put(j)
+}
 
-	// Check if we have a cached value for this second
-	if key == l.timeKey {
-		return l.timeValue
-	}
+// hookJobs counts queued hook jobs. Unlike a sync.WaitGroup it may be
+// waited on while jobs are added, from any number of goroutines: wait
+// returns once the jobs added before it was called are done, even if
+// logging continues.
+type hookJobs struct {
+	mu     sync.Mutex
+	cond   sync.Cond
+	epoch  uint64         // Epoch of new jobs, advanced by wait
+	counts map[uint64]int // Jobs pending per epoch
+}
 
-	// Format the time
-	formatted := now.Format(l.timeFormat)
+// add registers a job and returns the epoch to pass to done
+func (j *hookJobs) add() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.counts == nil {
+		j.counts = make(map[uint64]int)
+	}
+	j.counts[j.epoch]++
+	return j.epoch
+}
 
-	// Update cache
-	l.timeKey = key
-	l.timeValue = formatted
+// done marks a job of epoch as finished
+func (j *hookJobs) done(epoch uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.counts[epoch]--; j.counts[epoch] == 0 {
+		delete(j.counts, epoch)
+		j.cond.Broadcast()
+	}
+}
 
-	// Clean up old cache entries if needed
-	l.cleanupTimeCache()
+// wait blocks until the jobs added so far are done
+func (j *hookJobs) wait() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cond.L = &j.mu
+	last := j.epoch
+	j.epoch++
+	for j.pendingUpTo(last) {
+		j.cond.Wait()
+	}
+}
 
-	return formatted
+// pendingUpTo reports whether jobs of epoch last or earlier are pending;
+// j.mu must be held
+func (j *hookJobs) pendingUpTo(last uint64) bool {
+	for epoch := range j.counts {
+		if epoch <= last {
+			return true
+		}
+	}
+	return false
 }
 
-// cleanupTimeCache removes old entries from the time format cache
-func (l *Logger) cleanupTimeCache() {
-	if l.cleanupInProgress {
-		return
+// runHook calls a hook, removing it if it fails
+func (l *Logger) runHook(hook Hook, level Level, msg string) {
+	if err := hook.fn(level, msg); err != nil {
+		// Log the error and remove the hook
+		l.reportError("Hook error", err)
+		l.removeHook(hook.id)
 	}
+}
 
-	now := time.Now().Unix()
-	if now-l.lastCleanup < 60 { // Clean up at most once per minute
-		return
+// reportError writes an internal error (from a hook or sink) to the
+// logger's outputs so it is visible alongside the log stream, and keeps
+// it in the recent errors shown on the debug page.
+func (l *Logger) reportError(prefix string, err error) {
+	l.errors.add(prefix, err)
+	line := fmt.Appendf(nil, "%s: %v\n", prefix, err)
+	if !l.colors() {
+		line = stripANSIInPlace(line)
 	}
+	l.output.write(line)
+}
 
-	l.cleanupInProgress = true
-	defer func() {
-		l.cleanupInProgress = false
-		l.lastCleanup = now
-	}()
+// maxRecentErrors is the number of internal errors kept for the debug page
+const maxRecentErrors = 20
 
-	l.timeCache.Range(func(key, value any) bool {
-		if key.(int64) < now-3600 { // Remove entries older than 1 hour
-			l.timeCache.Delete(key)
-		}
-		return true
-	})
+// InternalError is an error raised by a hook or sink, kept for the debug page.
+type InternalError struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
 }
 
-// executeHooks executes all registered hooks asynchronously
-func (l *Logger) executeHooks(level Level, msg string) {
-	l.wg.Add(1)
-	l.workerPool.submit(func() {
-		defer l.wg.Done()
-
-		// Sort hooks by priority (higher priority first)
-		hooks := make([]Hook, len(l.hooks))
-		copy(hooks, l.hooks)
-		slices.SortFunc(hooks, func(a, b Hook) int {
-			return b.priority - a.priority
-		})
-
-		// Execute hooks
-		for _, hook := range hooks {
-			if err := hook.fn(level, msg); err != nil {
-				// Log the error and remove the hook
-				fmt.Fprintf(os.Stderr, "Hook error: %v\n", err)
-				l.removeHook(hook.id)
-			}
-		}
-	})
+// errorLog is a small ring buffer of recent internal errors
+type errorLog struct {
+	mu     sync.Mutex
+	recent []InternalError
+	next   int
+	total  int64
+}
+
+// add records an error, overwriting the oldest once full
+func (e *errorLog) add(source string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	record := InternalError{Time: time.Now(), Source: source, Message: err.Error()}
+	if len(e.recent) < maxRecentErrors {
+		e.recent = append(e.recent, record)
+	} else {
+		e.recent[e.next] = record
+	}
+	e.next = (e.next + 1) % maxRecentErrors
+	e.total++
+}
+
+// snapshot returns the recorded errors, newest first, and the total count
+func (e *errorLog) snapshot() ([]InternalError, int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]InternalError, 0, len(e.recent))
+	for i := 1; i <= len(e.recent); i++ {
+		out = append(out, e.recent[(e.next-i+len(e.recent))%len(e.recent)])
+	}
+	return out, e.total
 }
 
 // removeHook removes a hook by its ID
@@ -434,17 +879,32 @@ func (l *Logger) removeHook(id string) {
 
 	for i, hook := range l.hooks {
 		if hook.id == id {
-			l.hooks = slices.Delete(l.hooks, i, i+1)
+			l.hooks = slices.Delete(slices.Clone(l.hooks), i, i+1)
+			l.hookCount.Store(int32(len(l.hooks)))
 			return
 		}
 	}
 }
 
-// Internal event creation methods
-func (l *Logger) debugEvent() *event    { return l.newEvent(DEBUG) }
-func (l *Logger) infoEvent() *event     { return l.newEvent(INFO) }
-func (l *Logger) warnEvent() *event     { return l.newEvent(WARN) }
-func (l *Logger) errorEvent() *event    { return l.newEvent(ERROR) }
-func (l *Logger) criticalEvent() *event { return l.newEvent(CRITICAL) }
-func (l *Logger) fatalEvent() *event    { return l.newEvent(FATAL) }
-func (l *Logger) panicEvent() *event    { return l.newEvent(PANIC) }
+// DebugEvent starts a new event at DEBUG level.
+func (l *Logger) DebugEvent() *Event { return l.newEvent(DEBUG) }
+
+// InfoEvent starts a new event at INFO level.
+func (l *Logger) InfoEvent() *Event { return l.newEvent(INFO) }
+
+// WarnEvent starts a new event at WARN level.
+func (l *Logger) WarnEvent() *Event { return l.newEvent(WARN) }
+
+// ErrorEvent starts a new event at ERROR level.
+func (l *Logger) ErrorEvent() *Event { return l.newEvent(ERROR) }
+
+// CriticalEvent starts a new event at CRITICAL level.
+func (l *Logger) CriticalEvent() *Event { return l.newEvent(CRITICAL) }
+
+// FatalEvent starts a new event at FATAL level.
+// The program exits after the message is written.
+func (l *Logger) FatalEvent() *Event { return l.newEvent(FATAL) }
+
+// PanicEvent starts a new event at PANIC level.
+// A panic is triggered after the message is written.
+func (l *Logger) PanicEvent() *Event { return l.newEvent(PANIC) }
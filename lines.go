@@ -0,0 +1,104 @@
+package loggo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// maxLineLength splits longer lines into several records
+const maxLineLength = 64 * 1024
+
+// LineWriter turns text written to it into records, one per line, e.g.
+// for the output of libraries or child processes that print instead of
+// logging. A final line without a newline is written by Close.
+// It is safe for concurrent use.
+type LineWriter struct {
+	logger *Logger
+	level  Level
+	fields []Field
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// NewLineWriter returns a writer logging each line at level with the
+// given fields.
+func (l *Logger) NewLineWriter(level Level, fields ...Field) *LineWriter {
+	return &LineWriter{logger: l, level: level, fields: fields}
+}
+
+// Write logs the complete lines in p and keeps a trailing partial line
+// until more data or Close arrives.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[start : start+i])
+		start += i + 1
+	}
+	for len(w.buf)-start >= maxLineLength {
+		w.emit(w.buf[start : start+maxLineLength])
+		start += maxLineLength
+	}
+	// Keep the partial line at the start of the buffer
+	w.buf = append(w.buf[:0], w.buf[start:]...)
+	return len(p), nil
+}
+
+// Close logs the remaining partial line, if any.
+func (w *LineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+// emit logs one line without its line ending; w.mu must be held
+func (w *LineWriter) emit(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if e := w.logger.newEvent(w.level); e != nil {
+		e.fields = append(e.fields, w.fields...)
+		e.Msg(string(line))
+	}
+}
+
+// Ingest logs every line read from r at level with the given fields until
+// r is exhausted, e.g. to re-emit the output of another program piped to
+// os.Stdin with this logger's formatting and sinks.
+func (l *Logger) Ingest(r io.Reader, level Level, fields ...Field) error {
+	w := l.NewLineWriter(level, fields...)
+	_, err := io.Copy(w, r)
+	w.Close()
+	return err
+}
+
+// StreamKey is the field naming the output stream of a wrapped command.
+const StreamKey = "stream"
+
+// WrapCmd redirects the standard output and error of cmd, which must not
+// have been started, to the logger: every line becomes a record at level
+// with a StreamKey field of "stdout" or "stderr". Start the command as
+// usual and call the returned wait function instead of cmd.Wait, so the
+// final lines are logged once the command has exited. Run is replaced by
+// Start followed by wait.
+func (l *Logger) WrapCmd(cmd *exec.Cmd, level Level) (wait func() error) {
+	stdout := l.NewLineWriter(level, Str(StreamKey, "stdout"))
+	stderr := l.NewLineWriter(level, Str(StreamKey, "stderr"))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return func() error {
+		err := cmd.Wait()
+		return errors.Join(err, stdout.Close(), stderr.Close())
+	}
+}
@@ -0,0 +1,44 @@
+// Package logconsumer lets container logs of integration tests flow
+// through loggo. A Consumer implements the LogConsumer interface of
+// testcontainers-go:
+//
+//	consumer := logconsumer.New(logger, "postgres", loggo.INFO)
+//	defer consumer.Close()
+//	req := testcontainers.ContainerRequest{
+//		Image: "postgres:16",
+//		LogConsumerCfg: &testcontainers.LogConsumerConfig{
+//			Consumers: []testcontainers.LogConsumer{consumer},
+//		},
+//	}
+//
+// Every line becomes a record carrying the container name and the stream
+// it was written to.
+package logconsumer
+
+import (
+	"github.com/milsoncodes/loggo"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Consumer logs the output of a container.
+type Consumer struct {
+	logs *loggo.ContainerLogger
+}
+
+var _ testcontainers.LogConsumer = (*Consumer)(nil)
+
+// New returns a consumer logging the lines of the named container at
+// level with the given fields.
+func New(logger *loggo.Logger, container string, level loggo.Level, fields ...loggo.Field) *Consumer {
+	return &Consumer{logs: logger.NewContainerLogger(container, level, fields...)}
+}
+
+// Accept logs a chunk of container output.
+func (c *Consumer) Accept(log testcontainers.Log) {
+	c.logs.Log(log.LogType, log.Content)
+}
+
+// Close logs the final partial lines, if any.
+func (c *Consumer) Close() error {
+	return c.logs.Close()
+}
@@ -0,0 +1,78 @@
+package loggo
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultLogfmtKeys are the keys used by AppendLogfmt.
+var DefaultLogfmtKeys = JSONKeys{Time: "ts", Level: "level", Message: "msg"}
+
+// AppendLogfmt appends the entry to buf as a logfmt line without a
+// trailing newline, e.g.
+//
+//	level=info ts=2024-05-01T14:03:07Z msg="request handled" status=200
+//
+// as expected by ingestion pipelines such as Grafana Agent and Heroku.
+// The level, time and message come first, followed by the entry's fields.
+func AppendLogfmt(buf []byte, entry *Entry) []byte {
+	return AppendLogfmtKeys(buf, entry, DefaultLogfmtKeys)
+}
+
+// AppendLogfmtKeys is like AppendLogfmt but uses the given keys for the
+// built-in attributes. Empty keys fall back to DefaultLogfmtKeys.
+func AppendLogfmtKeys(buf []byte, entry *Entry, keys JSONKeys) []byte {
+	if keys.Time == "" {
+		keys.Time = DefaultLogfmtKeys.Time
+	}
+	if keys.Level == "" {
+		keys.Level = DefaultLogfmtKeys.Level
+	}
+	if keys.Message == "" {
+		keys.Message = DefaultLogfmtKeys.Message
+	}
+	return appendEncoded(buf, entry, encodingLogfmt, keys, appendLogfmtKeys)
+}
+
+// appendLogfmtKeys encodes the entry as AppendLogfmtKeys, with keys filled in
+func appendLogfmtKeys(buf []byte, entry *Entry, keys JSONKeys) []byte {
+	level, ok := jsonLevelStrings[entry.Level]
+	if !ok {
+		level = "unknown"
+	}
+	buf = appendLogfmtKey(buf, keys.Level)
+	buf = append(buf, level...)
+	buf = append(buf, ' ')
+	buf = appendLogfmtKey(buf, keys.Time)
+	buf = entry.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, ' ')
+	buf = appendLogfmtKey(buf, keys.Message)
+	buf = appendTextString(buf, entry.Message)
+
+	fields := entry.Fields
+	if keys.Order == FieldsSorted && !slices.IsSortedFunc(fields, compareFieldKeys) {
+		fields = slices.SortedStableFunc(slices.Values(fields), compareFieldKeys)
+	}
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, f.Key)
+		buf = appendTextValue(buf, f)
+	}
+	return buf
+}
+
+// appendLogfmtKey appends key and '=', replacing characters that would
+// break key=value parsing with '_'
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_', '=')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return append(buf, '=')
+}
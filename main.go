@@ -24,20 +24,24 @@
 //	loggo.Info("Hello, %s!", "World")
 //
 //	// Advanced usage with chained API (for performance-critical code)
-//	logger.Info().Msgf("Hello, %s!", "World")
+//	logger.InfoEvent().Msgf("Hello, %s!", "World")
 //
 // Important Notes:
 // - Always call Close() when you're done with a logger instance to clean up resources
-// - The global logger is managed by the package and doesn't need to be closed
-// - Hooks are executed asynchronously and may continue running after Close() is called
+// - Close the global logger with CloseGlobal, Exit or CloseGlobalOnSignal if it has hooks or sinks
+// - Close() runs the hooks still queued before returning
 // - Panic and Fatal levels will still trigger their respective behaviors even after Close()
 package loggo
 
 import (
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Level represents the logging level.
@@ -62,6 +66,8 @@ type Hook struct {
 	fn       func(level Level, msg string) error
 	priority int    // Higher priority hooks are executed first
 	id       string // Unique identifier for the hook
+	sync     bool   // Run in the logging goroutine instead of the worker pool
+	replay   int    // Recent records to deliver before live ones, from ReplayRecent
 }
 
 // Logger represents the main logger struct that handles all logging operations.
@@ -70,24 +76,74 @@ type Hook struct {
 // - Efficient buffer pooling
 // - Time format caching
 // - Asynchronous hook execution
+//
+// Loggers derived with With share their parent's state; only the bound
+// fields differ.
 type Logger struct {
-	level             Level          // Current logging level
-	output            *multiWriter   // Output destination(s) for log messages
-	timeFormat        string         // Format string for timestamps
-	hooks             []Hook         // List of registered hooks
-	mu                sync.Mutex     // Mutex for thread-safe operations
-	wg                sync.WaitGroup // WaitGroup for hook goroutines
-	maxHooks          int            // Maximum number of hooks allowed
-	bufSize           int            // Buffer size for log messages
-	pool              sync.Pool      // Buffer pool for log messages
-	timeCache         sync.Map       // Cache for formatted timestamps
-	workerPool        *workerPool    // Worker pool for hook execution
-	maxCacheSize      int            // Maximum size of time format cache
-	cleanupInProgress bool
-	lastCleanup       int64     // Last cleanup timestamp
-	bufPool           sync.Pool // Additional pool for larger buffers
-	timeKey           int64     // Current time key for caching
-	timeValue         string    // Current time value
+	*loggerCore
+	bound      []Field // Fields bound with With, written before the event's fields
+	callerSkip int     // Frames skipped when reporting the caller, from WithCallerSkip
+}
+
+// loggerCore is the state shared by a logger and the loggers derived from it
+type loggerCore struct {
+	level          atomic.Int32               // Current logging level
+	writeLevel     atomic.Int32               // Level of untagged records written with Write
+	assertPanics   atomic.Bool                // AssertTrue panics on violations
+	reportCaller   atomic.Bool                // Add CallerKey fields
+	callerFuncs    atomic.Bool                // Add CallerFuncKey fields too
+	stackLevels    atomic.Uint32              // Levels with stack traces from SetStackTraces, as bits
+	output         *multiWriter               // Output destination(s) for log messages
+	hooks          []Hook                     // List of registered hooks
+	hookCount      atomic.Int32               // len(hooks), read without the lock when logging
+	mu             sync.Mutex                 // Mutex for thread-safe operations
+	hookJobs       hookJobs                   // Queued hook jobs
+	maxHooks       int                        // Maximum number of hooks allowed
+	bufSize        atomic.Int64               // Initial capacity of pooled buffers
+	maxBufSize     atomic.Int64               // Largest buffer returned to the pool; 0 for 4 × bufSize
+	bufGrowth      atomic.Int32               // BufferGrowth of event buffers
+	pool           sync.Pool                  // Buffer pool for log messages
+	workerPool     *workerPool                // Worker pool for hook execution
+	timeCache      atomic.Pointer[timeCache]  // Time format and the timestamp of the current second
+	timeSource     atomic.Pointer[TimeSource] // Source of timestamps from SetTimeSource; nil for time.Now
+	envFields      atomic.Pointer[[]Field]    // Runtime environment fields attached to every record
+	sinks          atomic.Pointer[[]Sink]     // Structured sinks, swapped on change
+	errors         errorLog                   // Recent hook and sink errors
+	crashMirror    atomic.Bool                // Mirror FATAL/PANIC records to OS facilities
+	probes         atomic.Bool                // Fire per-level tracing probes
+	fingerprints   atomic.Bool                // Attach automatic fingerprints
+	entryHashes    atomic.Bool                // Attach content hashes to sink entries
+	report         atomic.Pointer[session]    // Counts for the shutdown report; nil when disabled
+	rand           atomic.Pointer[randSource] // Source from WithRandSource; nil for the global one
+	sampler        atomic.Pointer[Sampler]    // Decides which records are written
+	quotas         atomic.Pointer[ByteQuotas] // Byte accounting and quotas
+	circuit        atomic.Pointer[Circuit]    // Throttles runaway output; nil when not installed
+	schema         schemaRegistry             // Declared and observed fields
+	components     atomic.Pointer[compLevels] // Per-component level overrides
+	namespaces     atomic.Pointer[compLevels] // Levels of named loggers from SetNamespaceLevel
+	levelCallbacks []func(old, new Level)     // Called when the level changes
+	boost          levelBoost                 // Temporary level from BoostLevel
+	periodic       periodicTasks              // Tasks scheduled with Every and EnforceRetention
+	dedupe         dedupeState                // Burst of identical records from SetDedupe
+	replay         replayBuffer               // Recent hook records from SetReplayBuffer
+	runtimeStats   runtimeStats               // Runtime metrics read by Event.RuntimeStats
+	colorMode      atomic.Int32               // ColorMode from SetColorMode
+	noColorEnv     bool                       // NO_COLOR is set, disabling colors in ColorModeAuto
+	colorScope     atomic.Int32               // ColorScope from SetColorScope
+	levelTags      atomic.Pointer[levelTags]  // Level tags from SetLevelFormat
+	columns        atomic.Pointer[Columns]    // Column alignment from SetColumns
+	formatter      atomic.Pointer[Formatter]  // Line layout from SetFormatter; nil for the built-in one
+	symbolTags     atomic.Pointer[levelTags]  // Level symbols from SetLevelSymbols
+	themeColors    atomic.Pointer[levelTags]  // Level escape sequences from SetTheme
+	deadLetter     atomic.Pointer[DeadLetter] // Receives entries sinks failed to write
+	sampled        samplingSummary            // Records dropped by the sampler
+	escape         atomic.Int32               // EscapePolicy of messages in text output
+	utf8Policy     atomic.Int32               // UTF8Policy for invalid UTF-8
+	blobLimit      atomic.Int64               // Bytes of Blob fields encoded; 0 for the default
+	blobPaging     atomic.Bool                // Continue long Blob fields in follow-up records
+	sqlVerbatim    atomic.Bool                // Log SQL literals and arguments
+	encryption     atomic.Pointer[Encryptor]  // Encrypts selected fields; nil when off
+	proto          atomic.Pointer[protoCodec] // Encoding of Proto fields; nil for the defaults
 }
 
 // String returns the string representation of the log level.
@@ -113,6 +169,30 @@ func (l Level) String() string {
 	}
 }
 
+// ParseLevel converts a level name such as "debug", "WARN" or "crit" into
+// a Level. Matching is case-insensitive and accepts the names returned by
+// String as well as the full "CRITICAL" and "WARNING" spellings.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	case "CRIT", "CRITICAL":
+		return CRITICAL, nil
+	case "FATAL":
+		return FATAL, nil
+	case "PANIC":
+		return PANIC, nil
+	default:
+		return INFO, fmt.Errorf("loggo: unknown level %q", name)
+	}
+}
+
 // PaddedString returns the pre-calculated padded string representation of the log level.
 // If the level is unknown, it returns "[UNKNOWN]".
 func (l Level) PaddedString() string {
@@ -128,27 +208,20 @@ func (l Level) PaddedString() string {
 // - Uses sync.Map for efficient concurrent time format caching
 // - Sets reasonable defaults for hooks and buffer size
 func New() *Logger {
-	l := &Logger{
-		level:        INFO,
-		output:       newMultiWriter(os.Stdout),
-		timeFormat:   "2006-01-02 15:04:05.000 MST",
-		maxHooks:     100,  // Reasonable limit for hooks
-		bufSize:      1024, // Initial buffer size
-		maxCacheSize: 1000, // Maximum number of cached time formats
-	}
+	l := &Logger{loggerCore: &loggerCore{
+		output:     newMultiWriter(os.Stdout),
+		maxHooks:   100, // Reasonable limit for hooks
+		noColorEnv: os.Getenv("NO_COLOR") != "",
+	}}
+	l.level.Store(int32(INFO))
+	l.writeLevel.Store(int32(INFO))
+	l.timeCache.Store(newTimeCache(DefaultTimeFormat))
+	l.bufSize.Store(DefaultBufferSize)
 
 	// Initialize main buffer pool with dynamic sizing
 	l.pool = sync.Pool{
 		New: func() any {
-			buf := make([]byte, 0, l.bufSize)
-			return &buf
-		},
-	}
-
-	// Initialize large buffer pool for bigger messages
-	l.bufPool = sync.Pool{
-		New: func() any {
-			buf := make([]byte, 0, l.bufSize*4)
+			buf := make([]byte, 0, l.bufSize.Load())
 			return &buf
 		},
 	}
@@ -159,34 +232,102 @@ func New() *Logger {
 	return l
 }
 
+// With returns a logger that writes key=value before the fields of every
+// record, e.g. a request-scoped logger carrying the request ID. The value
+// is converted with Any. The derived logger shares the parent's level,
+// outputs, hooks and sinks, so configuring or closing either one affects
+// both.
+func (l *Logger) With(key string, value any) *Logger {
+	bound := make([]Field, len(l.bound), len(l.bound)+1)
+	copy(bound, l.bound)
+	return &Logger{loggerCore: l.loggerCore, bound: append(bound, Any(key, value)), callerSkip: l.callerSkip}
+}
+
+// WithFields returns a logger that writes every field of fields before
+// the fields of every record, as With, in key order so lines are stable
+// from one record to the next. Bound fields keep their order: fields
+// bound later are written after those bound earlier.
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	bound := make([]Field, len(l.bound), len(l.bound)+len(fields))
+	copy(bound, l.bound)
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		bound = append(bound, Any(key, fields[key]))
+	}
+	return &Logger{loggerCore: l.loggerCore, bound: bound, callerSkip: l.callerSkip}
+}
+
 // SetLevel sets the minimum logging level for the logger.
 // Messages with levels below this will be ignored.
+// If the level changes, the callbacks registered with OnLevelChange are called.
 func (l *Logger) SetLevel(level Level) {
-	l.level = level
+	l.mu.Lock()
+	old := Level(l.level.Swap(int32(level)))
+	callbacks := l.levelCallbacks
+	l.mu.Unlock()
+
+	if old == level {
+		return
+	}
+	for _, fn := range callbacks {
+		fn(old, level)
+	}
+}
+
+// OnLevelChange registers a callback called with the previous and new
+// level whenever SetLevel changes the level, e.g. from the debug page.
+// Components that precompute state based on verbosity, such as enabling
+// wire dumps in a client, use it to react at runtime. Callbacks run in
+// registration order in the goroutine calling SetLevel.
+func (l *Logger) OnLevelChange(fn func(old, new Level)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levelCallbacks = append(l.levelCallbacks, fn)
+}
+
+// Level returns the minimum logging level of the logger.
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
 }
 
 // SetOutputs sets multiple output destinations for log messages.
 // It accepts any number of writers that implement the io.Writer interface.
 // All log messages will be written to all specified outputs.
+// Writers added with Tee are kept.
 func (l *Logger) SetOutputs(outputs ...io.Writer) {
 	if len(outputs) == 0 {
-		l.output = newMultiWriter(os.Stdout)
+		l.output.set(os.Stdout)
 		return
 	}
-	l.output = newMultiWriter(outputs...)
+	l.output.set(outputs...)
 }
 
 // SetOutput sets a single output destination for log messages.
 // It accepts any type that implements the io.Writer interface.
 // This is a convenience method for when only one output is needed.
 func (l *Logger) SetOutput(output io.Writer) {
-	l.output = newMultiWriter(output)
+	l.output.set(output)
+}
+
+// SetColors enables or disables colored output, as SetColorMode with
+// ColorModeAlways or ColorModeNever. By default colors follow ColorModeAuto.
+func (l *Logger) SetColors(enabled bool) {
+	if enabled {
+		l.SetColorMode(ColorModeAlways)
+	} else {
+		l.SetColorMode(ColorModeNever)
+	}
 }
 
 // SetTimeFormat sets the format string for timestamps in log messages.
-// The format string should follow Go's time format layout.
-func (l *Logger) SetTimeFormat(format string) {
-	l.timeFormat = format
+// The format string should follow Go's time format layout; strftime
+// patterns and layouts without time elements are rejected with an error
+// and leave the format unchanged. SetTimePreset selects common formats.
+func (l *Logger) SetTimeFormat(format string) error {
+	if err := validateTimeFormat(format); err != nil {
+		return err
+	}
+	l.timeCache.Store(newTimeCache(format))
+	return nil
 }
 
 // AddHook adds a new hook function to the logger.
@@ -194,100 +335,139 @@ func (l *Logger) SetTimeFormat(format string) {
 // If a hook returns an error, it will be logged and the hook will be removed.
 // Note: Hook execution order is not guaranteed due to asynchronous execution.
 // Returns an error if the maximum number of hooks is reached.
-func (l *Logger) AddHook(hook func(level Level, msg string) error, priority int) error {
+func (l *Logger) AddHook(hook func(level Level, msg string) error, priority int, opts ...HookOption) error {
+	return l.addHook(hook, priority, false, opts)
+}
+
+// AddHookSync adds a hook that runs in the logging goroutine before the
+// logging call returns, for hooks that must observe every record even if
+// the process exits right after it (e.g. flushing an audit trail).
+// Slow synchronous hooks slow down logging. Errors are handled as for AddHook.
+func (l *Logger) AddHookSync(hook func(level Level, msg string) error, priority int, opts ...HookOption) error {
+	return l.addHook(hook, priority, true, opts)
+}
+
+// addHook registers a hook unless the maximum is reached
+func (l *Logger) addHook(hook func(level Level, msg string) error, priority int, sync bool, opts []HookOption) error {
+	h := Hook{
+		fn:       hook,
+		priority: priority,
+		id:       fmt.Sprintf("%p", hook), // Use function pointer as unique identifier
+		sync:     sync,
+	}
+	for _, opt := range opts {
+		opt(&h)
+	}
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	if len(l.hooks) >= l.maxHooks {
+		l.mu.Unlock()
 		return fmt.Errorf("maximum number of hooks (%d) reached", l.maxHooks)
 	}
-	l.hooks = append(l.hooks, Hook{
-		fn:       hook,
-		priority: priority,
-		id:       fmt.Sprintf("%p", hook), // Use function pointer as unique identifier
-	})
+	// Take the records to replay with the lock held, so none is both
+	// replayed and delivered live
+	var replay func()
+	if h.replay > 0 {
+		if records := l.replay.recent(h.replay); len(records) > 0 {
+			h, replay = l.replayingHook(h, records)
+		}
+	}
+	// Insert after the hooks of the same or higher priority, in a new
+	// array since executeHooks reads the slice without the lock
+	i := len(l.hooks)
+	for i > 0 && l.hooks[i-1].priority < h.priority {
+		i--
+	}
+	l.hooks = slices.Insert(slices.Clip(l.hooks), i, h)
+	l.hookCount.Store(int32(len(l.hooks)))
+	l.mu.Unlock()
+
+	if replay != nil {
+		replay()
+	}
 	return nil
 }
 
 // Debug logs a debug message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Debug(msg string) {
-	l.debugEvent().msg(msg)
+	l.DebugEvent().Msg(msg)
 }
 
 // Debugf logs a formatted debug message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Debugf(msg string, args ...any) {
-	l.debugEvent().msgf(msg, args...)
+	l.DebugEvent().Msgf(msg, args...)
 }
 
 // Info logs an info message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Info(msg string) {
-	l.infoEvent().msg(msg)
+	l.InfoEvent().Msg(msg)
 }
 
 // Infof logs a formatted info message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Infof(msg string, args ...any) {
-	l.infoEvent().msgf(msg, args...)
+	l.InfoEvent().Msgf(msg, args...)
 }
 
 // Warn logs a warning message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Warn(msg string) {
-	l.warnEvent().msg(msg)
+	l.WarnEvent().Msg(msg)
 }
 
 // Warnf logs a formatted warning message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Warnf(msg string, args ...any) {
-	l.warnEvent().msgf(msg, args...)
+	l.WarnEvent().Msgf(msg, args...)
 }
 
 // Error logs an error message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Error(msg string) {
-	l.errorEvent().msg(msg)
+	l.ErrorEvent().Msg(msg)
 }
 
 // Errorf logs a formatted error message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Errorf(msg string, args ...any) {
-	l.errorEvent().msgf(msg, args...)
+	l.ErrorEvent().Msgf(msg, args...)
 }
 
 // Critical logs a critical message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Critical(msg string) {
-	l.criticalEvent().msg(msg)
+	l.CriticalEvent().Msg(msg)
 }
 
 // Criticalf logs a formatted critical message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Criticalf(msg string, args ...any) {
-	l.criticalEvent().msgf(msg, args...)
+	l.CriticalEvent().Msgf(msg, args...)
 }
 
 // Fatal logs a fatal error message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Fatal(msg string) {
-	l.fatalEvent().msg(msg)
+	l.FatalEvent().Msg(msg)
 }
 
 // Fatalf logs a formatted fatal error message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Fatalf(msg string, args ...any) {
-	l.fatalEvent().msgf(msg, args...)
+	l.FatalEvent().Msgf(msg, args...)
 }
 
 // Panic logs a panic message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Panic(msg string) {
-	l.panicEvent().msg(msg)
+	l.PanicEvent().Msg(msg)
 }
 
 // Panicf logs a formatted panic message using the simple API.
 // This is a convenience method that internally uses the chained API.
 func (l *Logger) Panicf(msg string, args ...any) {
-	l.panicEvent().msgf(msg, args...)
+	l.PanicEvent().Msgf(msg, args...)
 }
@@ -0,0 +1,101 @@
+package loggo
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldMapping declares how entry fields are transformed for one sink.
+// Operations are applied in order: Drop, Rename, Coerce, then Namespace.
+// The built-in time, level and msg keys are not fields; rename them with
+// the JSONKeys of the encoding sink.
+type FieldMapping struct {
+	Drop       []string             // Keys removed from the entry
+	Rename     map[string]string    // Old key -> new key
+	Coerce     map[string]FieldType // Key -> target type (StringType, IntType, FloatType or BoolType)
+	Namespace  string               // Prefix added as "<namespace>." to the keys below
+	Namespaced []string             // Keys moved under Namespace; empty means all fields
+}
+
+// Apply returns a copy of fields transformed by the mapping.
+// Values that cannot be coerced keep their original type.
+func (m *FieldMapping) Apply(fields []Field) []Field {
+	return m.appendApplied(make([]Field, 0, len(fields)), fields)
+}
+
+// appendApplied appends the fields transformed by the mapping to out
+func (m *FieldMapping) appendApplied(out, fields []Field) []Field {
+	for _, f := range fields {
+		if slices.Contains(m.Drop, f.Key) {
+			continue
+		}
+		if key, ok := m.Rename[f.Key]; ok {
+			f.Key = key
+		}
+		if target, ok := m.Coerce[f.Key]; ok {
+			f = coerceField(f, target)
+		}
+		if m.Namespace != "" && (len(m.Namespaced) == 0 || slices.Contains(m.Namespaced, f.Key)) {
+			f.Key = m.Namespace + "." + f.Key
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// coerceField converts a field to the target type when possible
+func coerceField(f Field, target FieldType) Field {
+	if f.Type == target {
+		return f
+	}
+	text := f.ValueString()
+	switch target {
+	case StringType:
+		return Str(f.Key, text)
+	case IntType:
+		if f.Type == FloatType {
+			if v := f.Float(); !math.IsNaN(v) && !math.IsInf(v, 0) {
+				return Int64(f.Key, int64(v))
+			}
+		}
+		if i, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+			return Int64(f.Key, i)
+		}
+	case FloatType:
+		if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return Float64(f.Key, v)
+		}
+	case BoolType:
+		if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return Bool(f.Key, b)
+		}
+	}
+	return f
+}
+
+// MappingSink applies a FieldMapping to every entry before passing it on.
+type MappingSink struct {
+	sink    Sink
+	mapping FieldMapping
+}
+
+// NewMappingSink wraps sink so that it receives entries transformed by mapping.
+func NewMappingSink(sink Sink, mapping FieldMapping) *MappingSink {
+	return &MappingSink{sink: sink, mapping: mapping}
+}
+
+// WriteEntry transforms a copy of the entry and writes it to the wrapped sink.
+func (m *MappingSink) WriteEntry(entry *Entry) error {
+	mapped := getEntry()
+	defer putEntry(mapped)
+	mapped.Time, mapped.Level, mapped.Message = entry.Time, entry.Level, entry.Message
+	mapped.Fields = m.mapping.appendApplied(mapped.Fields, entry.Fields)
+	return m.sink.WriteEntry(mapped)
+}
+
+// Close closes the wrapped sink.
+func (m *MappingSink) Close() error {
+	return m.sink.Close()
+}
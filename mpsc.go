@@ -0,0 +1,105 @@
+package loggo
+
+import "sync/atomic"
+
+// mpscRing is a bounded lock-free queue for many producers and a single
+// consumer, after Dmitry Vyukov's bounded MPMC queue. Every slot carries a
+// sequence number saying whose turn it is: producers claim a position with
+// one CompareAndSwap on tail and publish the value by advancing the slot's
+// sequence, so they never wait on a lock or on each other. The consumer
+// owns head and reads values in batches.
+type mpscRing[T any] struct {
+	tail  atomic.Uint64 // Next position claimed by producers
+	_     [56]byte      // Keeps tail and head on separate cache lines
+	head  atomic.Uint64 // Next position read by the consumer
+	_     [56]byte
+	mask  uint64
+	slots []mpscSlot[T]
+}
+
+// mpscSlot holds a value and the position it is next valid for: pos when
+// free for the producer claiming pos, pos+1 once the value is published
+type mpscSlot[T any] struct {
+	seq   atomic.Uint64
+	value T
+}
+
+// newMPSCRing creates a ring holding at least size values, rounded up to a
+// power of two
+func newMPSCRing[T any](size int) *mpscRing[T] {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	r := &mpscRing[T]{mask: uint64(n - 1), slots: make([]mpscSlot[T], n)}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	return r
+}
+
+// push adds v to the ring. It returns false if the ring is full.
+// It is safe to call from any number of goroutines.
+func (r *mpscRing[T]) push(v T) bool {
+	pos := r.tail.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		switch diff := int64(slot.seq.Load() - pos); {
+		case diff == 0:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				slot.value = v
+				slot.seq.Store(pos + 1)
+				return true
+			}
+			pos = r.tail.Load()
+		case diff < 0:
+			// The slot still holds the value of the previous lap
+			return false
+		default:
+			// Another producer claimed pos
+			pos = r.tail.Load()
+		}
+	}
+}
+
+// popBatch appends up to max published values to dst in order. It stops
+// early at a slot claimed but not yet written. Only the consumer may call
+// it.
+func (r *mpscRing[T]) popBatch(dst []T, max int) []T {
+	var zero T
+	pos := r.head.Load()
+	for range max {
+		slot := &r.slots[pos&r.mask]
+		if slot.seq.Load() != pos+1 {
+			break
+		}
+		dst = append(dst, slot.value)
+		slot.value = zero
+		slot.seq.Store(pos + r.mask + 1)
+		pos++
+	}
+	r.head.Store(pos)
+	return dst
+}
+
+// ready reports whether the next value is published. Only the consumer
+// may call it.
+func (r *mpscRing[T]) ready() bool {
+	pos := r.head.Load()
+	return r.slots[pos&r.mask].seq.Load() == pos+1
+}
+
+// len returns the number of claimed positions not yet consumed
+func (r *mpscRing[T]) len() int {
+	head := r.head.Load()
+	tail := r.tail.Load()
+	if tail < head {
+		return 0
+	}
+	return int(tail - head)
+}
+
+// cap returns the number of values the ring holds
+func (r *mpscRing[T]) cap() int {
+	return len(r.slots)
+}
@@ -0,0 +1,250 @@
+package loggo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Minimal MessagePack support for the Fluent Forward protocol
+
+// appendMsgpackString appends a string
+func appendMsgpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendMsgpackArray appends an array header for n elements
+func appendMsgpackArray(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+// appendMsgpackMap appends a map header for n key/value pairs
+func appendMsgpackMap(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackInt appends a signed integer in its shortest form
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v < 128:
+		return append(buf, byte(v))
+	case v < 0 && v >= -32:
+		return append(buf, byte(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+	}
+}
+
+// appendMsgpackFloat appends a float64
+func appendMsgpackFloat(buf []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(v))
+}
+
+// appendMsgpackBool appends a boolean
+func appendMsgpackBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+// appendMsgpackValue appends a field value using its native type
+func appendMsgpackValue(buf []byte, f Field) []byte {
+	switch f.Type {
+	case IntType:
+		return appendMsgpackInt(buf, f.Int)
+	case FloatType:
+		return appendMsgpackFloat(buf, f.Float())
+	case BoolType:
+		return appendMsgpackBool(buf, f.Int != 0)
+	default:
+		return appendMsgpackString(buf, f.ValueString())
+	}
+}
+
+// msgpackExt is a decoded extension value
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// errMsgpackTooLarge guards against allocating for corrupt lengths
+var errMsgpackTooLarge = errors.New("loggo: msgpack value too large")
+
+// readMsgpack decodes a single value. Maps decode to map[string]any,
+// arrays to []any, integers to int64 or uint64, and str and bin to string.
+func readMsgpack(r *bufio.Reader) (any, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return readMsgpackMap(r, int(b&0x0f))
+	case b&0xf0 == 0x90:
+		return readMsgpackArray(r, int(b&0x0f))
+	case b&0xe0 == 0xa0:
+		return readMsgpackBytes(r, int(b&0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		n, err := readMsgpackUint(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xc5, 0xda:
+		n, err := readMsgpackUint(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xc6, 0xdb:
+		n, err := readMsgpackUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xca:
+		v, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return readMsgpackUint(r, 1<<(b-0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := readMsgpackUint(r, size)
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(b-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackUint(r, 1<<(b-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(b-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n))
+	}
+	return nil, fmt.Errorf("loggo: invalid msgpack type 0x%02x", b)
+}
+
+// readMsgpackUint reads a big-endian unsigned integer of size bytes
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	var raw [8]byte
+	if _, err := io.ReadFull(r, raw[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(raw[:]), nil
+}
+
+// readMsgpackBytes reads a str or bin payload
+func readMsgpackBytes(r *bufio.Reader, n int) (string, error) {
+	if n > 1<<24 {
+		return "", errMsgpackTooLarge
+	}
+	data := make([]byte, n)
+	_, err := io.ReadFull(r, data)
+	return string(data), err
+}
+
+// readMsgpackExt reads an extension type and its n-byte payload
+func readMsgpackExt(r *bufio.Reader, n int) (msgpackExt, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return msgpackExt{}, err
+	}
+	data, err := readMsgpackBytes(r, n)
+	return msgpackExt{Type: int8(typ), Data: []byte(data)}, err
+}
+
+// readMsgpackArray reads n values
+func readMsgpackArray(r *bufio.Reader, n int) ([]any, error) {
+	if n > 1<<20 {
+		return nil, errMsgpackTooLarge
+	}
+	values := make([]any, 0, n)
+	for range n {
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// readMsgpackMap reads n pairs; keys that are not strings are formatted
+func readMsgpackMap(r *bufio.Reader, n int) (map[string]any, error) {
+	if n > 1<<20 {
+		return nil, errMsgpackTooLarge
+	}
+	values := make(map[string]any, n)
+	for range n {
+		k, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
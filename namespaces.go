@@ -0,0 +1,92 @@
+package loggo
+
+import (
+	"maps"
+	"strings"
+)
+
+// LoggerKey is the key of the field naming a logger created with Named or
+// GetLogger, e.g. "http.server".
+const LoggerKey = "logger"
+
+// Named returns a logger derived from l that writes its name in the
+// LoggerKey field. Names are hierarchical, with dot-separated parts: on a
+// named logger, Named appends name to the logger's own, so
+// GetLogger("http").Named("server") is named "http.server". The level of
+// a named logger can be set with SetNamespaceLevel.
+func (l *Logger) Named(name string) *Logger {
+	if parent := l.name(); parent != "" {
+		name = parent + "." + name
+	}
+	return l.With(LoggerKey, name)
+}
+
+// SetNamespaceLevel sets the level of the loggers named namespace or
+// below it, e.g. "db" applies to "db" and "db.pool" but not to "dbx". The
+// override may be lower or higher than the logger's level; the most
+// specific namespace set applies, and component levels take precedence.
+func (l *Logger) SetNamespaceLevel(namespace string, level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	overrides := compLevels{}
+	if current := l.namespaces.Load(); current != nil {
+		overrides = maps.Clone(*current)
+	}
+	overrides[namespace] = level
+	l.namespaces.Store(&overrides)
+}
+
+// ClearNamespaceLevel removes the level set for namespace, so its loggers
+// follow the level of the enclosing namespace or the logger's.
+func (l *Logger) ClearNamespaceLevel(namespace string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.namespaces.Load()
+	if current == nil {
+		return
+	}
+	if _, ok := (*current)[namespace]; !ok {
+		return
+	}
+	if len(*current) == 1 {
+		l.namespaces.Store(nil)
+		return
+	}
+	overrides := maps.Clone(*current)
+	delete(overrides, namespace)
+	l.namespaces.Store(&overrides)
+}
+
+// NamespaceLevels returns the levels set with SetNamespaceLevel.
+func (l *Logger) NamespaceLevels() map[string]Level {
+	if overrides := l.namespaces.Load(); overrides != nil {
+		return maps.Clone(*overrides)
+	}
+	return nil
+}
+
+// name returns the logger's name, or "" if it has none
+func (l *Logger) name() string {
+	for i := len(l.bound) - 1; i >= 0; i-- {
+		if l.bound[i].Key == LoggerKey {
+			return l.bound[i].ValueString()
+		}
+	}
+	return ""
+}
+
+// namespaceLevel returns the level set for the most specific namespace
+// enclosing name
+func (overrides compLevels) namespaceLevel(name string) (Level, bool) {
+	for name != "" {
+		if level, ok := overrides[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package loggo
+
+import "encoding/hex"
+
+// Keys of the fields of paged records.
+const (
+	EntryIDKey    = "entry.id"    // ID shared by a record and its continuation records
+	ChunkFieldKey = "chunk.field" // Key of the field a continuation record continues
+	ChunkIndexKey = "chunk.index" // Position of the chunk in the field; the record itself holds chunk 0
+	ChunkCountKey = "chunk.count" // Number of chunks of the field
+)
+
+// ContinuationMessage is the message of continuation records.
+const ContinuationMessage = "continued"
+
+// pagedField is a field split into chunks, the first written in the
+// record itself
+type pagedField struct {
+	key    string
+	chunks []string
+}
+
+// SetBlobPaging makes Blob fields longer than the blob limit continue in
+// follow-up records instead of being truncated, so complete payloads can
+// be reconstructed. The record holds the first chunk, the number of
+// chunks in a field named key plus BlobChunksSuffix, and an EntryIDKey
+// field. Each following chunk is written right after the record in a
+// record with message ContinuationMessage, the same entry ID, and
+// ChunkFieldKey, ChunkIndexKey and ChunkCountKey fields, at the same
+// level, or CRITICAL for FATAL and PANIC records. Concatenating the
+// chunks in index order gives the encoded blob.
+func (l *Logger) SetBlobPaging(enabled bool) {
+	l.blobPaging.Store(enabled)
+}
+
+// page adds the first chunk of value as key and keeps the rest for
+// continuation records
+func (e *Event) page(key, value string, size int) {
+	var chunks []string
+	for len(value) > size {
+		chunks = append(chunks, value[:size])
+		value = value[size:]
+	}
+	chunks = append(chunks, value)
+	if e.entryID == "" {
+		var id [8]byte
+		e.logger.rand.Load().Read(id[:])
+		e.entryID = hex.EncodeToString(id[:])
+		e.fields = append(e.fields, Str(EntryIDKey, e.entryID))
+	}
+	e.fields = append(e.fields, Str(key, chunks[0]), Int(key+BlobChunksSuffix, len(chunks)))
+	e.pages = append(e.pages, pagedField{key: key, chunks: chunks})
+}
+
+// writeContinuations writes the continuation records of paged fields,
+// before a FATAL or PANIC record ends the program
+func (e *Event) writeContinuations() {
+	// Continuations of FATAL and PANIC records must not end the program
+	level := min(e.level, CRITICAL)
+	for _, p := range e.pages {
+		for i := 1; i < len(p.chunks); i++ {
+			c := e.logger.newEvent(level)
+			if c == nil {
+				return
+			}
+			// Never sampled or throttled apart from the record
+			c.internal = true
+			c.fields = append(c.fields,
+				Str(EntryIDKey, e.entryID),
+				Str(ChunkFieldKey, p.key),
+				Int(ChunkIndexKey, i),
+				Int(ChunkCountKey, len(p.chunks)),
+				Str(p.key, p.chunks[i]),
+			)
+			c.Msg(ContinuationMessage)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package loggo
+
+// SetProbes enables or disables the per-level tracing probes.
+//
+// When enabled, every record calls a tiny non-inlined function named after
+// its level (probeDebug, probeInfo, ..., probePanic) with the message as
+// its only argument. Tracers such as bpftrace can attach a uprobe to these
+// stable symbols, for example:
+//
+//	bpftrace -e 'uprobe:./app:"github.com/milsoncodes/loggo.probeError" {
+//		printf("%s\n", str(reg("ax"), reg("bx"))); }'
+//
+// With no tracer attached a probe costs one empty call per record; when
+// disabled it costs a single boolean check. Loggers created by NewFromEnv
+// also enable probes when LOGGO_PROBES=1 is set in the environment.
+//
+// These are uprobe attach points rather than USDT notes: emitting a
+// .note.stapsdt section requires cgo, which this package avoids.
+func (l *Logger) SetProbes(enabled bool) {
+	l.probes.Store(enabled)
+}
+
+// fireProbe calls the probe function for level
+func fireProbe(level Level, msg string) {
+	switch level {
+	case DEBUG:
+		probeDebug(msg)
+	case INFO:
+		probeInfo(msg)
+	case WARN:
+		probeWarn(msg)
+	case ERROR:
+		probeError(msg)
+	case CRITICAL:
+		probeCritical(msg)
+	case FATAL:
+		probeFatal(msg)
+	case PANIC:
+		probePanic(msg)
+	}
+}
+
+// The probe functions do nothing; they exist so tracers have a stable,
+// per-level symbol to attach to. They must not be inlined.
+
+//go:noinline
+func probeDebug(msg string) {}
+
+//go:noinline
+func probeInfo(msg string) {}
+
+//go:noinline
+func probeWarn(msg string) {}
+
+//go:noinline
+func probeError(msg string) {}
+
+//go:noinline
+func probeCritical(msg string) {}
+
+//go:noinline
+func probeFatal(msg string) {}
+
+//go:noinline
+func probePanic(msg string) {}
@@ -0,0 +1,184 @@
+package loggo
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// errProtoTruncated is returned for messages ending in the middle of a field
+var errProtoTruncated = errors.New("loggo: truncated protobuf message")
+
+// AppendProto appends the protobuf encoding of entry to buf, using the
+// loggo.v1.Entry message defined in proto/loggo.proto. Fields of unknown
+// type are encoded as strings.
+func AppendProto(buf []byte, entry *Entry) []byte {
+	return appendEncoded(buf, entry, encodingProto, JSONKeys{}, appendProto)
+}
+
+// appendProto encodes the entry as AppendProto
+func appendProto(buf []byte, entry *Entry, _ JSONKeys) []byte {
+	if !entry.Time.IsZero() {
+		buf = appendProtoVarint(buf, 1, uint64(entry.Time.UnixNano()))
+	}
+	if entry.Level != DEBUG {
+		buf = appendProtoVarint(buf, 2, uint64(entry.Level))
+	}
+	if entry.Message != "" {
+		buf = appendProtoString(buf, 3, entry.Message)
+	}
+
+	var field []byte
+	for _, f := range entry.Fields {
+		field = appendProtoString(field[:0], 1, f.Key)
+		switch f.Type {
+		case IntType:
+			field = appendProtoVarint(field, 3, uint64(f.Int<<1^f.Int>>63))
+		case FloatType:
+			field = appendProtoTag(field, 4, protoFixed64)
+			field = binary.LittleEndian.AppendUint64(field, uint64(f.Int))
+		case BoolType:
+			field = appendProtoVarint(field, 5, uint64(f.Int))
+		default:
+			field = appendProtoString(field, 2, f.ValueString())
+		}
+		buf = appendProtoTag(buf, 4, protoBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+// ParseProto decodes a loggo.v1.Entry message produced by AppendProto.
+// Unknown fields are skipped.
+func ParseProto(data []byte) (*Entry, error) {
+	entry, _, err := parseProtoEntry(data)
+	return entry, err
+}
+
+// parseProtoEntry decodes an entry and its sequence number
+func parseProtoEntry(data []byte) (*Entry, uint64, error) {
+	entry := &Entry{}
+	var seq uint64
+	err := walkProto(data, func(num int, wire int, v uint64, b []byte) error {
+		switch {
+		case num == 1 && wire == protoVarint:
+			entry.Time = time.Unix(0, int64(v))
+		case num == 2 && wire == protoVarint:
+			entry.Level = Level(v)
+		case num == 3 && wire == protoBytes:
+			entry.Message = string(b)
+		case num == 4 && wire == protoBytes:
+			f, err := parseProtoField(b)
+			if err != nil {
+				return err
+			}
+			entry.Fields = append(entry.Fields, f)
+		case num == 5 && wire == protoVarint:
+			seq = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entry, seq, nil
+}
+
+// parseProtoField decodes a loggo.v1.Field message
+func parseProtoField(data []byte) (Field, error) {
+	var f Field
+	err := walkProto(data, func(num int, wire int, v uint64, b []byte) error {
+		switch {
+		case num == 1 && wire == protoBytes:
+			f.Key = string(b)
+		case num == 2 && wire == protoBytes:
+			f.Type, f.Str = StringType, string(b)
+		case num == 3 && wire == protoVarint:
+			f.Type, f.Int = IntType, int64(v>>1)^-int64(v&1)
+		case num == 4 && wire == protoFixed64:
+			f.Type, f.Int = FloatType, int64(v)
+		case num == 5 && wire == protoVarint:
+			f.Type, f.Int = BoolType, 0
+			if v != 0 {
+				f.Int = 1
+			}
+		}
+		return nil
+	})
+	if f.Type == UnknownType {
+		f.Type = StringType
+	}
+	return f, err
+}
+
+// walkProto calls fn for every field of a protobuf message. Varint and
+// fixed values are passed in v, length-delimited values in b.
+func walkProto(data []byte, fn func(num int, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		data = data[n:]
+		num, wire := int(tag>>3), int(tag&7)
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case protoVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errProtoTruncated
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return errProtoTruncated
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return errProtoTruncated
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return errProtoTruncated
+			}
+			b, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return errors.New("loggo: unsupported protobuf wire type")
+		}
+		if err := fn(num, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendProtoTag appends a field tag
+func appendProtoTag(buf []byte, num int, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(num)<<3|uint64(wire))
+}
+
+// appendProtoVarint appends a varint field
+func appendProtoVarint(buf []byte, num int, v uint64) []byte {
+	buf = appendProtoTag(buf, num, protoVarint)
+	return binary.AppendUvarint(buf, v)
+}
+
+// appendProtoString appends a length-delimited string field
+func appendProtoString(buf []byte, num int, s string) []byte {
+	buf = appendProtoTag(buf, num, protoBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
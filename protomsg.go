@@ -0,0 +1,96 @@
+package loggo
+
+// ProtoMessage is implemented by every generated protobuf message (it is
+// the protoiface.MessageV1 method set), so messages can be logged without
+// loggo depending on the protobuf module.
+type ProtoMessage interface {
+	ProtoMessage()
+	Reset()
+	String() string
+}
+
+// ProtoMarshalFunc encodes a message for Proto fields, typically with
+// protojson after clearing fields annotated as sensitive.
+type ProtoMarshalFunc func(msg ProtoMessage) ([]byte, error)
+
+// DefaultProtoLimit is the number of bytes of an encoded message logged
+// by default.
+const DefaultProtoLimit = 4096
+
+// ProtoErrorSuffix names the field recording a failure to encode a message.
+const ProtoErrorSuffix = ".error"
+
+// Proto adds a protobuf message, such as a request payload, encoded by
+// the logger's marshaler (see SetProtoMarshaler), or by the message's
+// String method, which redacts fields marked debug_redact, if none is set.
+// Encodings longer than the logger's proto limit are truncated and marked
+// with BlobSizeSuffix and BlobTruncatedSuffix fields.
+func (e *Event) Proto(key string, msg ProtoMessage) *Event {
+	if e == nil {
+		return nil
+	}
+	if msg == nil {
+		e.fields = append(e.fields, Str(key, "null"))
+		return e
+	}
+	var codec protoCodec
+	if c := e.logger.proto.Load(); c != nil {
+		codec = *c
+	}
+	var data []byte
+	if codec.marshal != nil {
+		var err error
+		if data, err = codec.marshal(msg); err != nil {
+			e.fields = append(e.fields, Str(key+ProtoErrorSuffix, err.Error()))
+			return e
+		}
+	} else {
+		data = []byte(msg.String())
+	}
+
+	limit := codec.limit
+	if limit == 0 {
+		limit = DefaultProtoLimit
+	}
+	if limit < 0 || len(data) <= limit {
+		e.fields = append(e.fields, Str(key, string(data)))
+		return e
+	}
+	e.fields = append(e.fields,
+		Str(key, string(data[:limit])),
+		Int(key+BlobSizeSuffix, len(data)),
+		Bool(key+BlobTruncatedSuffix, true),
+	)
+	return e
+}
+
+// SetProtoMarshaler sets how Proto encodes messages, or restores their
+// String method if fn is nil. See the README for a protojson marshaler
+// that honors debug_redact annotations.
+func (l *Logger) SetProtoMarshaler(fn ProtoMarshalFunc) {
+	l.updateProto(func(c *protoCodec) { c.marshal = fn })
+}
+
+// SetProtoLimit sets how many bytes of each encoded message Proto logs.
+// 0 restores DefaultProtoLimit and a negative limit logs messages whole.
+func (l *Logger) SetProtoLimit(limit int) {
+	l.updateProto(func(c *protoCodec) { c.limit = limit })
+}
+
+// protoCodec is how Proto encodes messages
+type protoCodec struct {
+	marshal ProtoMarshalFunc // nil uses String
+	limit   int              // Bytes logged; 0 for the default
+}
+
+// updateProto publishes a copy of the Proto settings changed by update
+func (l *Logger) updateProto(update func(c *protoCodec)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var c protoCodec
+	if old := l.proto.Load(); old != nil {
+		c = *old
+	}
+	update(&c)
+	l.proto.Store(&c)
+}
@@ -0,0 +1,177 @@
+package loggo
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota is a byte budget per window for one component.
+type Quota struct {
+	Soft int64 // Bytes after which a warning is logged (0 disables)
+	Hard int64 // Bytes after which records below HardLevel are dropped (0 disables)
+}
+
+// QuotaConfig configures ByteQuotas. Zero values select the defaults.
+type QuotaConfig struct {
+	ComponentField string           // Field naming the component of a record (default "component")
+	Window         time.Duration    // Accounting window after which quotas reset (default 1h)
+	Default        Quota            // Quota of components without an entry in Components
+	Components     map[string]Quota // Per-component quotas
+	HardLevel      Level            // Lowest level still written once the hard quota is exceeded (default WARN)
+}
+
+// QuotaUsage reports the output of one component.
+type QuotaUsage struct {
+	Component   string // Value of the component field, "" for records without one
+	Bytes       int64  // Bytes written since the quotas were installed
+	Records     int64  // Records written since the quotas were installed
+	Dropped     int64  // Records dropped by the hard quota
+	WindowBytes int64  // Bytes written in the current window
+	OverSoft    bool   // Soft quota exceeded in the current window
+	OverHard    bool   // Hard quota exceeded in the current window
+}
+
+// componentUsage is the running usage of a component
+type componentUsage struct {
+	QuotaUsage
+	window time.Time
+}
+
+// ByteQuotas accounts the bytes each component logs, for chargeback in
+// multi-team programs, and enforces quotas: exceeding a soft quota logs a
+// warning, exceeding a hard quota downgrades the component's verbosity
+// to HardLevel until the window ends. Install it with Logger.SetQuotas.
+type ByteQuotas struct {
+	config QuotaConfig
+	mu     sync.Mutex
+	usage  map[string]*componentUsage
+}
+
+// NewByteQuotas creates quotas with the given configuration.
+func NewByteQuotas(config QuotaConfig) *ByteQuotas {
+	if config.ComponentField == "" {
+		config.ComponentField = "component"
+	}
+	if config.Window <= 0 {
+		config.Window = time.Hour
+	}
+	if config.HardLevel == DEBUG {
+		config.HardLevel = WARN
+	}
+	return &ByteQuotas{config: config, usage: make(map[string]*componentUsage)}
+}
+
+// SetQuotas installs byte accounting and quotas, or removes them if q is nil.
+func (l *Logger) SetQuotas(q *ByteQuotas) {
+	l.quotas.Store(q)
+}
+
+// checkQuota returns the logger's quotas and the event's component, and
+// whether the event may be written
+func (e *Event) checkQuota() (*ByteQuotas, string, bool) {
+	q := e.logger.quotas.Load()
+	if q == nil {
+		return nil, "", true
+	}
+	component := q.component(e)
+	return q, component, e.internal || q.allow(component, e.level)
+}
+
+// Usage returns the usage of every component, sorted by component.
+func (q *ByteQuotas) Usage() []QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage := make([]QuotaUsage, 0, len(q.usage))
+	for _, u := range q.usage {
+		usage = append(usage, u.QuotaUsage)
+	}
+	slices.SortFunc(usage, func(a, b QuotaUsage) int {
+		return strings.Compare(a.Component, b.Component)
+	})
+	return usage
+}
+
+// component returns the component of an event
+func (q *ByteQuotas) component(e *Event) string {
+	for i := len(e.fields) - 1; i >= 0; i-- {
+		if e.fields[i].Key == q.config.ComponentField {
+			return e.fields[i].ValueString()
+		}
+	}
+	return ""
+}
+
+// lookup returns the usage of a component, starting a new window if the
+// current one has ended; q.mu must be held
+func (q *ByteQuotas) lookup(component string, now time.Time) *componentUsage {
+	u := q.usage[component]
+	if u == nil {
+		u = &componentUsage{QuotaUsage: QuotaUsage{Component: component}, window: now}
+		q.usage[component] = u
+	}
+	if now.Sub(u.window) >= q.config.Window {
+		u.window, u.WindowBytes, u.OverSoft, u.OverHard = now, 0, false, false
+	}
+	return u
+}
+
+// allow reports whether a record at level from component may be written
+func (q *ByteQuotas) allow(component string, level Level) bool {
+	if level >= q.config.HardLevel {
+		return true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.lookup(component, time.Now())
+	if u.OverHard {
+		u.Dropped++
+		return false
+	}
+	return true
+}
+
+// account adds a written record and logs a notice on l when it crosses
+// a quota
+func (q *ByteQuotas) account(l *Logger, component string, size int) {
+	quota, ok := q.config.Components[component]
+	if !ok {
+		quota = q.config.Default
+	}
+
+	q.mu.Lock()
+	u := q.lookup(component, time.Now())
+	u.Bytes += int64(size)
+	u.Records++
+	u.WindowBytes += int64(size)
+	soft := quota.Soft > 0 && !u.OverSoft && u.WindowBytes > quota.Soft
+	hard := quota.Hard > 0 && !u.OverHard && u.WindowBytes > quota.Hard
+	u.OverSoft = u.OverSoft || soft
+	u.OverHard = u.OverHard || hard
+	window := q.config.Window.String()
+	q.mu.Unlock()
+
+	name := component
+	if name == "" {
+		name = "(none)"
+	}
+	if soft {
+		q.notice(l, component, quota.Soft, "component "+name+" exceeded its soft log quota of "+
+			strconv.FormatInt(quota.Soft, 10)+" bytes per "+window)
+	}
+	if hard {
+		q.notice(l, component, quota.Hard, "component "+name+" exceeded its hard log quota of "+
+			strconv.FormatInt(quota.Hard, 10)+" bytes per "+window+"; dropping records below "+q.config.HardLevel.String())
+	}
+}
+
+// notice logs a quota warning attributed to the component
+func (q *ByteQuotas) notice(l *Logger, component string, limit int64, msg string) {
+	if e := l.WarnEvent(); e != nil {
+		e.internal = true
+		e.fields = append(e.fields, Str(q.config.ComponentField, component), Int64("quota_bytes", limit))
+		e.Msg(msg)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package loggo
+
+// raceEnabled reports whether tests run with the race detector
+const raceEnabled = true
@@ -0,0 +1,77 @@
+package loggo
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync"
+)
+
+// randSource is a random source safe for concurrent use. A nil
+// *randSource draws from the global sources.
+type randSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// Float64 returns a number in [0, 1).
+func (s *randSource) Float64() float64 {
+	if s == nil {
+		return rand.Float64()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Float64()
+}
+
+// Read fills p with random bytes, from crypto/rand for a nil source.
+func (s *randSource) Read(p []byte) {
+	if s == nil {
+		crand.Read(p)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var word [8]byte
+	for len(p) > 0 {
+		binary.LittleEndian.PutUint64(word[:], s.r.Uint64())
+		p = p[copy(p, word[:]):]
+	}
+}
+
+// randUser is implemented by samplers, sinks and batch senders drawing
+// random numbers, which use the source of the logger they are added to
+type randUser interface {
+	setRand(r *randSource)
+}
+
+// WithRandSource makes the logger draw every random number, for sampling
+// decisions and generated IDs, from src, and returns the logger. With a
+// seeded source, tests are fully deterministic:
+//
+//	logger := loggo.New().WithRandSource(rand.NewPCG(1, 2))
+//
+// The source is passed on to the sampler and sinks of the logger, those
+// added later included, and to the senders of its BatchSinks. A nil src
+// restores the global sources.
+func (l *Logger) WithRandSource(src rand.Source) *Logger {
+	var r *randSource
+	if src != nil {
+		r = &randSource{r: rand.New(src)}
+	}
+	l.rand.Store(r)
+	if sampler := l.sampler.Load(); sampler != nil {
+		l.shareRand(*sampler)
+	}
+	for _, sink := range l.loadSinks() {
+		l.shareRand(sink)
+	}
+	return l
+}
+
+// shareRand passes the logger's random source on to v
+func (l *Logger) shareRand(v any) {
+	if u, ok := v.(randUser); ok {
+		u.setRand(l.rand.Load())
+	}
+}
@@ -0,0 +1,173 @@
+package loggo
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// siteLimit tracks one call site of a CallerRateLimiter
+type siteLimit struct {
+	location   string // "file.go:line"
+	window     int64  // Unix second of the current window
+	count      int    // Records kept in the window
+	suppressed int    // Records dropped since the last notice
+}
+
+// CallerRateLimiter is a Sampler limiting each log call site (program
+// counter) to a number of records per second, so one noisy loop cannot
+// drown out other components. Dropped records are reported periodically
+// as a WARN "suppressed N messages from file:line" notice, emitted with
+// the next record logged after the report interval.
+type CallerRateLimiter struct {
+	logger    *Logger
+	perSecond int
+	interval  time.Duration
+	mu        sync.Mutex
+	sites     map[uintptr]*siteLimit
+	reported  time.Time
+}
+
+// NewCallerRateLimiter creates a limiter allowing perSecond records per
+// call site, reporting suppressed records on logger every 10 seconds.
+// Install it with logger.SetSampler.
+func NewCallerRateLimiter(logger *Logger, perSecond int) *CallerRateLimiter {
+	return &CallerRateLimiter{
+		logger:    logger,
+		perSecond: max(perSecond, 1),
+		interval:  10 * time.Second,
+		sites:     make(map[uintptr]*siteLimit),
+		reported:  time.Now(),
+	}
+}
+
+// SetReportInterval changes how often suppression notices are emitted.
+func (r *CallerRateLimiter) SetReportInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = interval
+}
+
+// Sample implements Sampler. ERROR and above are limited like any other
+// level; combine with other samplers using ChainSamplers.
+func (r *CallerRateLimiter) Sample(level Level, _ string) (bool, float64) {
+	frame, ok := callerFrame()
+	if !ok {
+		return true, 1
+	}
+	now := time.Now()
+	second := now.Unix()
+
+	r.mu.Lock()
+	site := r.sites[frame.PC]
+	if site == nil {
+		site = &siteLimit{location: filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)}
+		r.sites[frame.PC] = site
+	}
+	if site.window != second {
+		site.window, site.count = second, 0
+	}
+	keep := site.count < r.perSecond
+	if keep {
+		site.count++
+	} else {
+		site.suppressed++
+	}
+
+	var notices []*siteLimit
+	if now.Sub(r.reported) >= r.interval {
+		r.reported = now
+		for _, s := range r.sites {
+			if s.suppressed > 0 {
+				notices = append(notices, &siteLimit{location: s.location, suppressed: s.suppressed})
+				s.suppressed = 0
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	for _, notice := range notices {
+		if e := r.logger.WarnEvent(); e != nil {
+			e.internal = true
+			e.fields = append(e.fields, Str("caller", notice.location), Int("suppressed", notice.suppressed))
+			e.Msg("suppressed " + strconv.Itoa(notice.suppressed) + " messages from " + notice.location)
+		}
+	}
+	return keep, 1
+}
+
+// chainedSamplers applies several samplers in order
+type chainedSamplers []Sampler
+
+// Sample keeps a record only if every sampler keeps it.
+func (c chainedSamplers) Sample(level Level, template string) (bool, float64) {
+	rate := 1.0
+	for _, s := range c {
+		keep, r := s.Sample(level, template)
+		if !keep {
+			return false, rate * r
+		}
+		rate *= r
+	}
+	return true, rate
+}
+
+// ChainSamplers returns a Sampler keeping a record only if every sampler
+// keeps it, in order; the reported rate is the product of their rates.
+func ChainSamplers(samplers ...Sampler) Sampler {
+	return chainedSamplers(samplers)
+}
+
+// TokenBucket is a Sampler limiting all records to a sustained rate per
+// second, with bursts of up to a number of records. Unlike sampling, it
+// keeps every record while tokens last and none after, so it caps the
+// load on outputs and hooks. ERROR and above are limited like any other
+// level; combine with other samplers using ChainSamplers.
+type TokenBucket struct {
+	perSecond  float64
+	burst      float64
+	now        func() time.Time
+	mu         sync.Mutex
+	tokens     float64
+	last       time.Time        // Time tokens were last added
+	suppressed [PANIC + 1]int64 // Records dropped per level since creation
+}
+
+// NewTokenBucket creates a limiter allowing perSecond records per second
+// on average and up to burst records at once, starting full.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	b := &TokenBucket{perSecond: perSecond, burst: float64(max(burst, 1)), now: time.Now}
+	b.tokens, b.last = b.burst, b.now()
+	return b
+}
+
+// Sample implements Sampler.
+func (b *TokenBucket) Sample(level Level, _ string) (bool, float64) {
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.perSecond, b.burst)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 1
+	}
+	if level >= DEBUG && level <= PANIC {
+		b.suppressed[level]++
+	}
+	return false, 1
+}
+
+// Suppressed returns the number of records at level the limiter dropped.
+func (b *TokenBucket) Suppressed(level Level) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if level < DEBUG || level > PANIC {
+		return 0
+	}
+	return b.suppressed[level]
+}
@@ -0,0 +1,100 @@
+package loggo
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// HookOption configures a hook added with AddHook or AddHookSync.
+type HookOption func(*Hook)
+
+// ReplayRecent makes the hook receive up to n of the records kept by
+// SetReplayBuffer, oldest first, before any record logged after it was
+// added, e.g. so a debug UI attached late shows recent history. The
+// replay runs in the goroutine adding the hook, before AddHook returns.
+// If the hook fails during the replay, it is removed as for live records.
+func ReplayRecent(n int) HookOption {
+	return func(h *Hook) { h.replay = n }
+}
+
+// SetReplayBuffer keeps the last n records delivered to hooks, as level
+// and message, for hooks added with ReplayRecent. The records are kept
+// even while no hook is registered. Resizing keeps the most recent
+// records that fit; 0 disables the buffer and discards them.
+func (l *Logger) SetReplayBuffer(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := &l.replay
+	if n <= 0 {
+		r.size.Store(0)
+		r.records, r.next = nil, 0
+		return
+	}
+	records := make([]hookRecord, 0, n)
+	r.records = append(records, r.recent(n)...)
+	r.next = len(r.records) % n
+	r.size.Store(int32(n))
+}
+
+// hookRecord is a record as delivered to hooks
+type hookRecord struct {
+	level Level
+	msg   string
+}
+
+// replayBuffer is a ring buffer of the last records delivered to hooks.
+// records and next are guarded by the logger's mutex.
+type replayBuffer struct {
+	size    atomic.Int32 // Capacity; 0 when disabled
+	records []hookRecord
+	next    int // Index of the slot to overwrite once full
+}
+
+// add records a record, overwriting the oldest once full
+func (r *replayBuffer) add(level Level, msg string) {
+	record := hookRecord{level: level, msg: msg}
+	if size := int(r.size.Load()); len(r.records) < size {
+		r.records = append(r.records, record)
+		r.next = len(r.records) % size
+		return
+	}
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+}
+
+// recent returns up to n of the most recent records, oldest first
+func (r *replayBuffer) recent(n int) []hookRecord {
+	count := min(n, len(r.records))
+	out := make([]hookRecord, 0, count)
+	for i := len(r.records) - count; i < len(r.records); i++ {
+		out = append(out, r.records[(r.next+i)%len(r.records)])
+	}
+	return out
+}
+
+// replayingHook wraps the hook so it receives records before any live
+// one. The returned function runs the replay; live calls wait for it.
+func (l *Logger) replayingHook(hook Hook, records []hookRecord) (Hook, func()) {
+	var once sync.Once
+	failed := false
+	fn, id := hook.fn, hook.id
+	replay := func() {
+		for _, record := range records {
+			if err := fn(record.level, record.msg); err != nil {
+				l.reportError("Hook error", err)
+				l.removeHook(id)
+				failed = true
+				return
+			}
+		}
+	}
+	hook.fn = func(level Level, msg string) error {
+		once.Do(replay)
+		if failed {
+			// Removed during the replay
+			return nil
+		}
+		return fn(level, msg)
+	}
+	return hook, func() { once.Do(replay) }
+}
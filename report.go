@@ -0,0 +1,88 @@
+package loggo
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Keys of the fields of the shutdown report
+const (
+	ReportEntriesKey = "report.entries" // Prefix of the per-level counts, e.g. "report.entries.info"
+	ReportSampledKey = "report.sampled" // Records dropped by the sampler
+	ReportQuotaKey   = "report.quota"   // Records dropped by the hard quota
+	ReportErrorsKey  = "report.errors"  // Hook and sink errors
+	ReportUptimeKey  = "report.uptime"  // Time since the report was enabled
+)
+
+// session counts what the logger wrote since SetShutdownReport
+type session struct {
+	start   time.Time
+	entries [PANIC + 1]atomic.Int64
+	sampled atomic.Int64
+}
+
+// SetShutdownReport makes Close write a final INFO record summarizing the
+// session: the records written per level (ReportEntriesKey followed by the
+// lowercase level name), the records dropped by the sampler and by the
+// hard quota, the hook and sink errors, and the uptime. Batch jobs and CI
+// runs can read the outcome of a run from their last line. Counting starts
+// when the report is enabled; disabling it discards the counts. The
+// report is never sampled or dropped by quotas.
+func (l *Logger) SetShutdownReport(enabled bool) {
+	if !enabled {
+		l.report.Store(nil)
+		return
+	}
+	l.report.CompareAndSwap(nil, &session{start: time.Now()})
+}
+
+// count records a written entry
+func (r *session) count(level Level) {
+	if r != nil && level >= DEBUG && level <= PANIC {
+		r.entries[level].Add(1)
+	}
+}
+
+// emitShutdownReport writes the shutdown report once
+func (l *Logger) emitShutdownReport() {
+	r := l.report.Swap(nil)
+	if r == nil {
+		return
+	}
+	// Let queued hooks run so their errors are counted
+	l.hookJobs.wait()
+
+	e := l.newEvent(INFO)
+	if e == nil {
+		return
+	}
+	e.internal = true
+
+	var total int64
+	for level := DEBUG; level <= PANIC; level++ {
+		n := r.entries[level].Load()
+		total += n
+		e.fields = append(e.fields, Int64(ReportEntriesKey+"."+strings.ToLower(level.String()), n))
+	}
+	var quota int64
+	if q := l.quotas.Load(); q != nil {
+		for _, u := range q.Usage() {
+			quota += u.Dropped
+		}
+	}
+	sampled := r.sampled.Load()
+	_, errs := l.errors.snapshot()
+	uptime := time.Since(r.start)
+	e.fields = append(e.fields,
+		Int64(ReportSampledKey, sampled),
+		Int64(ReportQuotaKey, quota),
+		Int64(ReportErrorsKey, errs),
+		Str(ReportUptimeKey, uptime.String()),
+	)
+	e.Msg("session ended after " + uptime.Round(time.Millisecond).String() + ": " +
+		strconv.FormatInt(total, 10) + " records, " +
+		strconv.FormatInt(sampled+quota, 10) + " dropped, " +
+		strconv.FormatInt(errs, 10) + " errors")
+}
@@ -0,0 +1,142 @@
+package loggo
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultRetentionInterval is the time between retention runs when the
+// policy does not set one
+const defaultRetentionInterval = time.Minute
+
+// RetentionPolicy limits the rotated log files kept in a directory. Zero
+// values disable the corresponding limit.
+type RetentionPolicy struct {
+	MaxTotalSize int64         // Total bytes of the files kept; the oldest are removed first
+	MaxAge       time.Duration // Files last modified longer ago are removed
+	MaxFiles     int           // Files kept; the oldest are removed first
+	Pattern      string        // filepath.Match pattern of the file names managed (default: rotated backups)
+	Interval     time.Duration // Time between runs of EnforceRetention (default 1m)
+}
+
+// EnforceRetention applies the policy to the files in dir every
+// Interval, on the logger's worker pool, so the rotated logs of one or
+// more RotatingFileWriters, plain or compressed, stay within bounds
+// across restarts and processes:
+//
+//	logger.EnforceRetention("/var/log/app", loggo.RetentionPolicy{
+//		MaxTotalSize: 1 << 30,
+//		MaxAge:       30 * 24 * time.Hour,
+//	})
+//
+// Errors are reported like hook errors. The returned function stops the
+// schedule, as does Close.
+func (l *Logger) EnforceRetention(dir string, policy RetentionPolicy) (stop func()) {
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = defaultRetentionInterval
+	}
+	return l.schedule(interval, func() {
+		if err := policy.Enforce(dir); err != nil {
+			l.reportError("Retention error", err)
+		}
+	})
+}
+
+// Enforce removes the files in dir beyond the policy's limits once. The
+// files are those matching Pattern or, without one, the backups named
+// by RotatingFileWriter, which never include the files being written.
+// Files are ordered by modification time, and the newest are kept.
+func (p RetentionPolicy) Enforce(dir string) error {
+	files, err := p.files(dir)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(files, func(a, b retainedFile) int {
+		return cmp.Or(b.modTime.Compare(a.modTime), strings.Compare(b.name, a.name))
+	})
+
+	now := time.Now()
+	var (
+		errs  []error
+		kept  int
+		total int64
+		full  bool // A newer file already exceeded MaxTotalSize
+	)
+	for _, f := range files {
+		full = full || p.MaxTotalSize > 0 && total+f.size > p.MaxTotalSize
+		if full ||
+			p.MaxAge > 0 && now.Sub(f.modTime) > p.MaxAge ||
+			p.MaxFiles > 0 && kept >= p.MaxFiles {
+			if err := os.Remove(filepath.Join(dir, f.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		kept++
+		total += f.size
+	}
+	return errors.Join(errs...)
+}
+
+// retainedFile is a file managed by a retention policy
+type retainedFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// files returns the files in dir the policy applies to
+func (p RetentionPolicy) files(dir string) ([]retainedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []retainedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !p.matches(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		files = append(files, retainedFile{name: name, size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// matches reports whether the policy applies to the file name
+func (p RetentionPolicy) matches(name string) bool {
+	if p.Pattern != "" {
+		ok, _ := filepath.Match(p.Pattern, name)
+		return ok
+	}
+	return isRotatedName(name)
+}
+
+// isRotatedName reports whether name is that of a backup written by a
+// RotatingFileWriter, i.e. has a "-" followed by a rotation timestamp
+func isRotatedName(name string) bool {
+	for i := strings.IndexByte(name, '-'); i >= 0; {
+		stamp := name[i+1:]
+		if len(stamp) >= len(rotateTimeFormat) {
+			if _, err := time.Parse(rotateTimeFormat, stamp[:len(rotateTimeFormat)]); err == nil {
+				return true
+			}
+		}
+		j := strings.IndexByte(stamp, '-')
+		if j < 0 {
+			break
+		}
+		i += 1 + j
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package loggo
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat names rotated files; it sorts chronologically
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig configures a RotatingFileWriter. Zero values disable the
+// corresponding rotation or limit.
+type RotateConfig struct {
+	MaxSize    int64         // Rotate before a write would grow the file beyond this many bytes
+	Interval   time.Duration // Rotate at multiples of Interval since the zero time, e.g. 24h rotates at midnight UTC
+	MaxBackups int           // Rotated files kept; the oldest are removed first
+	Compress   bool          // Gzip rotated files in the background
+	Perm       os.FileMode   // Permissions of created files (default 0644)
+}
+
+// RotatingFileWriter is an output that writes to a file and rotates it by
+// size and time, e.g.
+//
+//	w, err := loggo.NewRotatingFileWriter("/var/log/app.log", loggo.RotateConfig{MaxSize: 100 << 20, MaxBackups: 7, Compress: true})
+//	logger.SetOutput(w)
+//
+// Rotated files are renamed to the file name plus a timestamp, e.g.
+// app-2024-05-01T14-03-07.000.log, and gzipped to .log.gz when Compress is
+// set. The logger writes every record in one Write call, so records are
+// never split between files. Writes go straight to the file, without
+// copying the logger's buffer. It is safe for concurrent use.
+type RotatingFileWriter struct {
+	path   string
+	config RotateConfig
+	now    func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64     // Bytes in the current file
+	period time.Time // Interval the current file belongs to
+	closed bool
+
+	mill    sync.Mutex     // Serializes compressing and removing backups
+	pending sync.WaitGroup // Background compression and cleanup
+	errMu   sync.Mutex
+	errs    []error // Errors of background work, returned by Close
+}
+
+// NewRotatingFileWriter opens (or creates) path in append mode and returns
+// a writer rotating it as configured.
+func NewRotatingFileWriter(path string, config RotateConfig) (*RotatingFileWriter, error) {
+	if config.Perm == 0 {
+		config.Perm = 0o644
+	}
+	w := &RotatingFileWriter{path: path, config: config, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p to the file, rotating it first if p would exceed
+// MaxSize or the Interval has ended.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		// A previous rotation or reopen failed
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.due(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file now, e.g. on an operator's request.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes the file and opens path again, for external tools such as
+// logrotate that move the file away and signal the process.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return w.open()
+}
+
+// ReopenOnSignal calls Reopen whenever one of the signals arrives (by
+// default SIGHUP). On platforms without SIGHUP, such as Windows, there is
+// no default and ReopenOnSignal without signals does nothing. The
+// returned function removes the handler.
+func (w *RotatingFileWriter) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = defaultReopenSignals
+	}
+	if len(signals) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Sync commits the file's contents to stable storage.
+func (w *RotatingFileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close closes the file and waits for background compression and cleanup,
+// returning their errors.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if !w.closed && w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.pending.Wait()
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	return errors.Join(append([]error{err}, w.errs...)...)
+}
+
+// open opens the file for appending; w.mu must be held or w unshared
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.config.Perm)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	w.period = w.currentPeriod()
+	return nil
+}
+
+// currentPeriod returns the start of the current rotation interval
+func (w *RotatingFileWriter) currentPeriod() time.Time {
+	if w.config.Interval <= 0 {
+		return time.Time{}
+	}
+	return w.now().Truncate(w.config.Interval)
+}
+
+// due reports whether the file must rotate before n more bytes are
+// written; w.mu must be held
+func (w *RotatingFileWriter) due(n int) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.config.MaxSize {
+		return true
+	}
+	return w.config.Interval > 0 && !w.currentPeriod().Equal(w.period)
+}
+
+// rotate renames the file to a backup and opens a new one; w.mu must be
+// held
+func (w *RotatingFileWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	backup := w.backupName(w.now())
+	if err := os.Rename(w.path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		w.mill.Lock()
+		defer w.mill.Unlock()
+		if w.config.Compress {
+			w.record(compressFile(backup))
+		}
+		if w.config.MaxBackups > 0 {
+			w.record(w.removeOldBackups())
+		}
+	}()
+	return nil
+}
+
+// backupName returns an unused name for a backup rotated at t
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	stamp := t.UTC().Format(rotateTimeFormat)
+	name := filepath.Join(dir, prefix+stamp+ext)
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = filepath.Join(dir, prefix+stamp+"."+strconv.Itoa(i)+ext)
+	}
+	return name
+}
+
+// nameParts splits the path into its directory, the backup name prefix
+// and the extension, e.g. "/var/log", "app-" and ".log"
+func (w *RotatingFileWriter) nameParts() (dir, prefix, ext string) {
+	dir, base := filepath.Split(w.path)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backups returns the rotated files, oldest first
+func (w *RotatingFileWriter) backups() ([]string, error) {
+	dir, prefix, ext := w.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		stamp, ok = strings.CutSuffix(stamp, ext)
+		if !ok || len(stamp) < len(rotateTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(rotateTimeFormat, stamp[:len(rotateTimeFormat)]); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for i, name := range names {
+		names[i] = filepath.Join(dir, name)
+	}
+	return names, nil
+}
+
+// removeOldBackups removes the oldest backups beyond MaxBackups
+func (w *RotatingFileWriter) removeOldBackups() error {
+	backups, err := w.backups()
+	if err != nil || len(backups) <= w.config.MaxBackups {
+		return err
+	}
+	var errs []error
+	for _, name := range backups[:len(backups)-w.config.MaxBackups] {
+		errs = append(errs, os.Remove(name))
+	}
+	return errors.Join(errs...)
+}
+
+// record keeps an error of background work for Close
+func (w *RotatingFileWriter) record(err error) {
+	if err == nil {
+		return
+	}
+	w.errMu.Lock()
+	w.errs = append(w.errs, err)
+	w.errMu.Unlock()
+}
+
+// compressFile gzips name to name.gz and removes name
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(name + ".gz")
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := errors.Join(gz.Close(), dst.Close()); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+// fileExists reports whether name exists
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package loggo
+
+import "os"
+
+// defaultReopenSignals is empty on platforms without SIGHUP, so
+// ReopenOnSignal does nothing unless given signals
+var defaultReopenSignals = []os.Signal{}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package loggo
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultReopenSignals are the signals ReopenOnSignal handles by default
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,92 @@
+package loggo
+
+import "errors"
+
+// Route sends entries whose Field has the given Value to Sink.
+// Routes are typically used to segregate tenant logs, e.g.
+// Route{Field: "tenant", Value: "acme", Sink: acmeSink, Exclusive: true}.
+type Route struct {
+	Field     string // Field key to match
+	Value     string // Field value to match, compared as text
+	Sink      Sink   // Destination for matching entries
+	Exclusive bool   // Matching entries skip the default sinks
+}
+
+// RouterSink dispatches entries to sinks according to declarative routes.
+// Entries matching no exclusive route also go to the default sinks.
+type RouterSink struct {
+	fields   []string                      // Distinct field keys used by routes
+	routes   map[string]map[string][]Route // Field key -> value -> routes
+	defaults []Sink
+}
+
+// NewRouterSink creates a RouterSink with the given routes and default sinks.
+func NewRouterSink(routes []Route, defaults ...Sink) *RouterSink {
+	r := &RouterSink{
+		routes:   make(map[string]map[string][]Route),
+		defaults: defaults,
+	}
+	for _, route := range routes {
+		byValue, ok := r.routes[route.Field]
+		if !ok {
+			byValue = make(map[string][]Route)
+			r.routes[route.Field] = byValue
+			r.fields = append(r.fields, route.Field)
+		}
+		byValue[route.Value] = append(byValue[route.Value], route)
+	}
+	return r
+}
+
+// WriteEntry writes the entry to every matching route and, unless an
+// exclusive route matched, to the default sinks.
+func (r *RouterSink) WriteEntry(entry *Entry) error {
+	var errs []error
+	exclusive := false
+	for _, key := range r.fields {
+		field, ok := entry.Field(key)
+		if !ok {
+			continue
+		}
+		for _, route := range r.routes[key][field.ValueString()] {
+			if err := route.Sink.WriteEntry(entry); err != nil {
+				errs = append(errs, err)
+			}
+			exclusive = exclusive || route.Exclusive
+		}
+	}
+	if !exclusive {
+		for _, sink := range r.defaults {
+			if err := sink.WriteEntry(entry); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every routed and default sink once.
+func (r *RouterSink) Close() error {
+	seen := make(map[Sink]bool)
+	var errs []error
+	closeOnce := func(sink Sink) {
+		if seen[sink] {
+			return
+		}
+		seen[sink] = true
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, key := range r.fields {
+		for _, value := range sortedKeys(r.routes[key]) {
+			for _, route := range r.routes[key][value] {
+				closeOnce(route.Sink)
+			}
+		}
+	}
+	for _, sink := range r.defaults {
+		closeOnce(sink)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package loggo
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// Metrics read by RuntimeStats; heap in use is the sum of the two heap
+// classes, as MemStats.HeapInuse
+var runtimeMetrics = []string{
+	"/sched/goroutines:goroutines",
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/heap/unused:bytes",
+	"/sched/pauses/total/gc:seconds",
+}
+
+// runtimeStats reads runtime metrics for RuntimeStats and remembers the
+// GC pause histogram of the previous read
+type runtimeStats struct {
+	mu      sync.Mutex
+	samples []metrics.Sample
+	pauses  []uint64 // Pause counts per bucket at the previous read
+}
+
+// RuntimeStats adds a snapshot of the Go runtime for periodic health
+// records:
+//
+//	runtime.goroutines  number of goroutines
+//	runtime.heap_inuse  bytes of heap spans in use, as MemStats.HeapInuse
+//	runtime.gc_pauses   stop-the-world GC pauses since the previous call
+//	runtime.gc_pause    approximate total time of those pauses
+//
+// The previous call is tracked per logger, including loggers derived with
+// With and Named; the first call counts pauses since the process started.
+// The values come from runtime/metrics, which, unlike
+// runtime.ReadMemStats, does not stop the world.
+func (e *Event) RuntimeStats() *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = e.logger.runtimeStats.appendFields(e.fields)
+	return e
+}
+
+// appendFields reads the metrics and appends them to fields
+func (s *runtimeStats) appendFields(fields []Field) []Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.samples == nil {
+		s.samples = make([]metrics.Sample, len(runtimeMetrics))
+		for i, name := range runtimeMetrics {
+			s.samples[i].Name = name
+		}
+	}
+	metrics.Read(s.samples)
+
+	var pauses uint64
+	var pauseTime float64
+	if s.samples[3].Value.Kind() == metrics.KindFloat64Histogram {
+		hist := s.samples[3].Value.Float64Histogram()
+		if len(s.pauses) != len(hist.Counts) {
+			s.pauses = make([]uint64, len(hist.Counts))
+		}
+		for i, count := range hist.Counts {
+			delta := count - s.pauses[i]
+			s.pauses[i] = count
+			if delta == 0 {
+				continue
+			}
+			pauses += delta
+			pauseTime += float64(delta) * bucketMidpoint(hist.Buckets[i], hist.Buckets[i+1])
+		}
+	}
+
+	return append(fields,
+		Int64("runtime.goroutines", int64(sampleUint64(s.samples[0]))),
+		Int64("runtime.heap_inuse", int64(sampleUint64(s.samples[1])+sampleUint64(s.samples[2]))),
+		Int64("runtime.gc_pauses", int64(pauses)),
+		Dur("runtime.gc_pause", time.Duration(pauseTime*float64(time.Second))),
+	)
+}
+
+// sampleUint64 returns the value of a sample, or 0 if the runtime does not
+// support the metric
+func sampleUint64(sample metrics.Sample) uint64 {
+	if sample.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample.Value.Uint64()
+}
+
+// bucketMidpoint returns the middle of a histogram bucket, or its finite
+// bound for the open-ended first and last buckets
+func bucketMidpoint(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1):
+		return max(upper, 0)
+	case math.IsInf(upper, 1):
+		return lower
+	}
+	return (lower + upper) / 2
+}
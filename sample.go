@@ -0,0 +1,210 @@
+package loggo
+
+import (
+	"sync"
+	"time"
+)
+
+// SampleRateKey is the field recording the sampling rate of a kept record.
+const SampleRateKey = "sample_rate"
+
+// Sampler decides which records are written. It is consulted when the
+// message is logged, before anything is formatted. FATAL and PANIC
+// records are never sampled.
+type Sampler interface {
+	// Sample reports whether a record at level with the given message
+	// template (the Msgf format, or the message for Msg) is kept, and the
+	// rate at which such records are currently kept. Kept records with a
+	// rate below 1 carry it in a SampleRateKey field.
+	Sample(level Level, template string) (keep bool, rate float64)
+}
+
+// SetSampler installs a sampler, or removes it if s is nil.
+func (l *Logger) SetSampler(s Sampler) {
+	if s == nil {
+		l.sampler.Store(nil)
+		return
+	}
+	l.shareRand(s)
+	l.sampler.Store(&s)
+}
+
+// sample applies the logger's sampler to the event and reports whether
+// it should be written; masked is set when the template is a message
+// rather than a format
+func (e *Event) sample(template string, masked bool) bool {
+	sampler := e.logger.sampler.Load()
+	if sampler == nil || e.level >= FATAL || e.internal {
+		return true
+	}
+	if e.logger.sampled.due() {
+		e.logger.emitSamplingSummaries()
+	}
+	keep, rate := (*sampler).Sample(e.level, template)
+	if !keep {
+		e.logger.sampled.record(e, template, masked)
+		if r := e.logger.report.Load(); r != nil {
+			r.sampled.Add(1)
+		}
+		return false
+	}
+	if rate < 1 {
+		e.fields = append(e.fields, Float64(SampleRateKey, rate))
+	}
+	return true
+}
+
+// AdaptiveSampler keeps output within a lines-per-second budget. Every
+// second it derives per-level sampling rates from the volume offered in
+// the previous second, giving the budget to the most severe levels first:
+// ERROR and above are never sampled, then WARN, INFO and DEBUG receive
+// what remains. Traffic within the budget is never sampled.
+type AdaptiveSampler struct {
+	budget  int
+	minRate float64
+	mu      sync.Mutex
+	window  int64              // Unix second of the current window
+	offered [PANIC + 1]int     // Records offered in the current window
+	kept    int                // Records kept in the current window
+	rates   [PANIC + 1]float64 // Rates applied in the current window
+	rand    *randSource        // Source of the logger; nil for the global one
+}
+
+// NewAdaptiveSampler creates a sampler targeting at most linesPerSecond
+// records per second. Rates never fall below 1/1000, so a level is never
+// silenced entirely.
+func NewAdaptiveSampler(linesPerSecond int) *AdaptiveSampler {
+	s := &AdaptiveSampler{budget: max(linesPerSecond, 1), minRate: 0.001}
+	for i := range s.rates {
+		s.rates[i] = 1
+	}
+	return s
+}
+
+// setRand sets the source of the sampling decisions
+func (s *AdaptiveSampler) setRand(r *randSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rand = r
+}
+
+// Sample implements Sampler.
+func (s *AdaptiveSampler) Sample(level Level, _ string) (bool, float64) {
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now != s.window {
+		s.adjust(now)
+	}
+	if level < DEBUG || level > PANIC {
+		return true, 1
+	}
+	s.offered[level]++
+	if level >= ERROR {
+		s.kept++
+		return true, 1
+	}
+	// Enforce the budget within the window too, so the first second of
+	// a burst cannot exceed it
+	rate := s.rates[level]
+	if s.kept >= s.budget || (rate < 1 && s.rand.Float64() >= rate) {
+		return false, rate
+	}
+	s.kept++
+	return true, rate
+}
+
+// adjust starts a new window and computes its rates from the last one; s.mu must be held
+func (s *AdaptiveSampler) adjust(now int64) {
+	if now-s.window > 1 {
+		// Idle for more than a window: nothing to learn from
+		clear(s.offered[:])
+	}
+	remaining := float64(s.budget)
+	for level := PANIC; level >= DEBUG; level-- {
+		offered := float64(s.offered[level])
+		rate := 1.0
+		if level < ERROR && offered > remaining {
+			rate = max(remaining/offered, s.minRate)
+		}
+		s.rates[level] = rate
+		remaining = max(remaining-offered*rate, 0)
+	}
+	s.window, s.kept = now, 0
+	clear(s.offered[:])
+}
+
+// Rate returns the sampling rate currently applied to level.
+func (s *AdaptiveSampler) Rate(level Level) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if level < DEBUG || level > PANIC {
+		return 1
+	}
+	return s.rates[level]
+}
+
+// burstKey identifies the records counted together by a BurstSampler
+type burstKey struct {
+	level    Level
+	template string
+}
+
+// BurstSampler keeps the first records of each level and message
+// template every second, then one in every so many, so a hot loop
+// repeating the same warning writes a handful of lines per second while
+// other messages are unaffected. It is deterministic and needs no random
+// numbers.
+type BurstSampler struct {
+	first      int
+	thereafter int
+	now        func() time.Time
+	mu         sync.Mutex
+	window     int64            // Unix second of the current window
+	counts     map[burstKey]int // Records offered in the current window
+	suppressed [PANIC + 1]int64 // Records dropped per level since creation
+}
+
+// NewBurstSampler creates a sampler keeping the first records of each
+// level and message template every second, then one in every thereafter
+// records. A thereafter of 0 or less drops the rest of the second.
+func NewBurstSampler(first, thereafter int) *BurstSampler {
+	return &BurstSampler{first: first, thereafter: thereafter, now: time.Now, counts: make(map[burstKey]int)}
+}
+
+// Sample implements Sampler. Records beyond the first are kept at a rate
+// of 1/thereafter.
+func (s *BurstSampler) Sample(level Level, template string) (bool, float64) {
+	now := s.now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now != s.window {
+		s.window = now
+		clear(s.counts)
+	}
+	key := burstKey{level, template}
+	n := s.counts[key]
+	s.counts[key] = n + 1
+	if n < s.first {
+		return true, 1
+	}
+	if s.thereafter > 0 && (n-s.first)%s.thereafter == 0 {
+		return true, 1 / float64(s.thereafter)
+	}
+	if level >= DEBUG && level <= PANIC {
+		s.suppressed[level]++
+	}
+	return false, 0
+}
+
+// Suppressed returns the number of records at level the sampler dropped.
+func (s *BurstSampler) Suppressed(level Level) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if level < DEBUG || level > PANIC {
+		return 0
+	}
+	return s.suppressed[level]
+}
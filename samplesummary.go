@@ -0,0 +1,169 @@
+package loggo
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Fields of sampling summary records.
+const (
+	SampledDroppedKey = "sampled.dropped" // Records dropped since the last summary
+	SampledFirstKey   = "sampled.first"   // Time of the first dropped record
+	SampledLastKey    = "sampled.last"    // Time of the last dropped record
+)
+
+// maxSampledGroups bounds the fingerprints tracked between summaries;
+// drops of further fingerprints are counted under an empty one
+const maxSampledGroups = 1000
+
+// sampledGroup accumulates the records of one fingerprint and level
+// dropped by the sampler
+type sampledGroup struct {
+	level       Level
+	fingerprint string
+	template    string
+	dropped     int
+	first, last time.Time
+}
+
+// samplingSummary tracks dropped records between summaries
+type samplingSummary struct {
+	interval atomic.Int64 // Nanoseconds between summaries; 0 disables tracking
+	next     atomic.Int64 // Unix nanoseconds of the next summary
+	mu       sync.Mutex
+	groups   map[sampledGroupKey]*sampledGroup
+}
+
+type sampledGroupKey struct {
+	level       Level
+	fingerprint string
+}
+
+// SetSamplingSummaries makes the logger account for records dropped by its
+// sampler and emit, every interval, one summary record per fingerprint and
+// level with the number dropped (SampledDroppedKey) and the times of the
+// first and last (SampledFirstKey, SampledLastKey), so dashboards can
+// reconstruct true volumes. Summaries are written at the level of the
+// dropped records, are never sampled, and are emitted while logging, so
+// pending ones are also written by Flush. An interval of 0 disables them.
+func (l *Logger) SetSamplingSummaries(interval time.Duration) {
+	s := &l.sampled
+	s.interval.Store(int64(max(interval, 0)))
+	s.next.Store(time.Now().Add(interval).UnixNano())
+	if interval <= 0 {
+		s.mu.Lock()
+		s.groups = nil
+		s.mu.Unlock()
+	}
+}
+
+// record counts a record dropped by the sampler
+func (s *samplingSummary) record(e *Event, template string, masked bool) {
+	if s.interval.Load() == 0 {
+		return
+	}
+	fingerprint := ""
+	for i := range e.fields {
+		if e.fields[i].Key == FingerprintKey {
+			fingerprint = e.fields[i].ValueString()
+			break
+		}
+	}
+	if fingerprint == "" {
+		if masked {
+			template = messageSignature(template)
+		}
+		fingerprint = Fingerprint(template)
+	}
+	// Not the time source: a dropped record must not take a skew report
+	now := e.at
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.groups == nil {
+		s.groups = make(map[sampledGroupKey]*sampledGroup)
+	}
+	key := sampledGroupKey{e.level, fingerprint}
+	g, ok := s.groups[key]
+	if !ok {
+		if len(s.groups) >= maxSampledGroups {
+			key.fingerprint, template = "", ""
+			g = s.groups[key]
+		}
+		if g == nil {
+			g = &sampledGroup{level: e.level, fingerprint: key.fingerprint, template: template, first: now}
+			s.groups[key] = g
+		}
+	}
+	g.dropped++
+	g.first = minTime(g.first, now)
+	g.last = maxTime(g.last, now)
+}
+
+// due reports whether a summary should be emitted now, claiming it
+func (s *samplingSummary) due() bool {
+	interval := s.interval.Load()
+	if interval == 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	next := s.next.Load()
+	return now >= next && s.next.CompareAndSwap(next, now+interval)
+}
+
+// take returns the pending groups, oldest first, and resets them
+func (s *samplingSummary) take() []*sampledGroup {
+	s.mu.Lock()
+	groups := make([]*sampledGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	clear(s.groups)
+	s.mu.Unlock()
+	slices.SortFunc(groups, func(a, b *sampledGroup) int {
+		return a.first.Compare(b.first)
+	})
+	return groups
+}
+
+// emitSamplingSummaries writes one record per pending group
+func (l *Logger) emitSamplingSummaries() {
+	for _, g := range l.sampled.take() {
+		e := l.newEvent(g.level)
+		if e == nil {
+			continue
+		}
+		e.internal = true
+		e.fields = append(e.fields,
+			Int(SampledDroppedKey, g.dropped),
+			Str(SampledFirstKey, g.first.Format(time.RFC3339Nano)),
+			Str(SampledLastKey, g.last.Format(time.RFC3339Nano)),
+		)
+		msg := "sampled out " + strconv.Itoa(g.dropped) + " records"
+		if g.fingerprint != "" {
+			e.fields = append(e.fields, Str(FingerprintKey, g.fingerprint))
+			msg += ": " + g.template
+		}
+		e.Msg(msg)
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
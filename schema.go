@@ -0,0 +1,230 @@
+package loggo
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FieldSchema describes one field key seen in or declared for a logger's
+// records.
+type FieldSchema struct {
+	Key         string      // Field key
+	Types       []FieldType // Value types, after deferred fields are resolved
+	Count       int64       // Records carrying the field since tracking was enabled
+	Declared    bool        // Declared with DeclareField
+	Description string      // Description given to DeclareField
+	Semconv     string      // OpenTelemetry semantic convention attribute, if one applies
+}
+
+// Schema is the set of fields a logger writes, sorted by key.
+type Schema struct {
+	Fields []FieldSchema
+}
+
+// schemaRegistry collects declared and observed fields
+type schemaRegistry struct {
+	tracking atomic.Bool
+	mu       sync.Mutex
+	fields   map[string]*FieldSchema
+}
+
+// SetSchemaTracking enables or disables recording the key and type of
+// every field written, for DescribeSchema. Tracking takes a lock per
+// record, so it is meant for staging environments and audits rather than
+// hot production paths.
+func (l *Logger) SetSchemaTracking(enabled bool) {
+	l.schema.tracking.Store(enabled)
+}
+
+// DeclareField documents a field the program logs, so it appears in
+// DescribeSchema even before it has been observed.
+func (l *Logger) DeclareField(key string, typ FieldType, description string) {
+	s := &l.schema
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.lookup(key)
+	f.Declared = true
+	f.Description = description
+	f.addType(typ)
+}
+
+// DescribeSchema returns the declared fields and those observed while
+// schema tracking was enabled, including the runtime environment fields.
+func (l *Logger) DescribeSchema() Schema {
+	s := &l.schema
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schema := Schema{Fields: make([]FieldSchema, 0, len(s.fields))}
+	for _, f := range s.fields {
+		c := *f
+		c.Types = slices.Clone(f.Types)
+		schema.Fields = append(schema.Fields, c)
+	}
+	slices.SortFunc(schema.Fields, func(a, b FieldSchema) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return schema
+}
+
+// observeSchema records the event's fields if tracking is enabled
+func (e *Event) observeSchema() {
+	s := &e.logger.schema
+	if !s.tracking.Load() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fields := range [][]Field{e.logger.loadEnvFields(), e.fields} {
+		for i := range fields {
+			f := s.lookup(fields[i].Key)
+			f.Count++
+			f.addType(fields[i].Type)
+		}
+	}
+}
+
+// lookup returns the entry for key, creating it; s.mu must be held
+func (s *schemaRegistry) lookup(key string) *FieldSchema {
+	if s.fields == nil {
+		s.fields = make(map[string]*FieldSchema)
+	}
+	f, ok := s.fields[key]
+	if !ok {
+		f = &FieldSchema{Key: key, Semconv: semconvAttribute(key)}
+		s.fields[key] = f
+	}
+	return f
+}
+
+// addType adds typ to the field's types if not yet present
+func (f *FieldSchema) addType(typ FieldType) {
+	if !slices.Contains(f.Types, typ) {
+		f.Types = append(f.Types, typ)
+	}
+}
+
+// semconvAttributes maps common field keys to OpenTelemetry semantic
+// convention attributes
+var semconvAttributes = map[string]string{
+	"error":         "exception.message",
+	"err":           "exception.message",
+	"error_type":    "exception.type",
+	"stack":         "exception.stacktrace",
+	"stacktrace":    "exception.stacktrace",
+	"method":        "http.request.method",
+	"http_method":   "http.request.method",
+	"status":        "http.response.status_code",
+	"status_code":   "http.response.status_code",
+	"http_status":   "http.response.status_code",
+	"route":         "http.route",
+	"path":          "url.path",
+	"url":           "url.full",
+	"user_agent":    "user_agent.original",
+	"client_ip":     "client.address",
+	"remote_addr":   "client.address",
+	"user_id":       "enduser.id",
+	"host":          "host.name",
+	"hostname":      "host.name",
+	"pid":           "process.pid",
+	"service":       "service.name",
+	"version":       "service.version",
+	"db_system":     "db.system",
+	"db_statement":  "db.query.text",
+	"query":         "db.query.text",
+	"k8s.pod":       "k8s.pod.name",
+	"k8s.namespace": "k8s.namespace.name",
+	"k8s.node":      "k8s.node.name",
+}
+
+// semconvAttribute returns the semantic convention attribute for a key.
+// Keys that already are convention attributes map to themselves.
+func semconvAttribute(key string) string {
+	if attr, ok := semconvAttributes[key]; ok {
+		return attr
+	}
+	for _, attr := range semconvAttributes {
+		if attr == key {
+			return key
+		}
+	}
+	switch key {
+	case "container.id", "container.runtime", "thread.id", "code.function", "code.filepath", "code.lineno":
+		return key
+	}
+	return ""
+}
+
+// OTelMapping returns the field keys that correspond to OpenTelemetry
+// semantic convention attributes, mapped to those attributes, for
+// configuring a collector's attribute renames.
+func (s Schema) OTelMapping() map[string]string {
+	mapping := make(map[string]string)
+	for _, f := range s.Fields {
+		if f.Semconv != "" {
+			mapping[f.Key] = f.Semconv
+		}
+	}
+	return mapping
+}
+
+// jsonSchemaType returns the JSON Schema type of a field type
+func jsonSchemaType(typ FieldType) string {
+	switch typ {
+	case IntType:
+		return "integer"
+	case FloatType:
+		return "number"
+	case BoolType:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing records as
+// written by AppendJSONKeys with the given keys. Fields carry their
+// semantic convention attribute as "x-otel-attribute".
+func (s Schema) JSONSchema(keys JSONKeys) ([]byte, error) {
+	keys = keys.withDefaults()
+	levels := make([]string, 0, len(jsonLevelStrings))
+	for level := DEBUG; level <= PANIC; level++ {
+		levels = append(levels, jsonLevelStrings[level])
+	}
+	properties := map[string]any{
+		keys.Time:    map[string]any{"type": "string", "format": "date-time"},
+		keys.Level:   map[string]any{"type": "string", "enum": levels},
+		keys.Message: map[string]any{"type": "string"},
+	}
+	for _, f := range s.Fields {
+		var types []string
+		for _, typ := range f.Types {
+			if t := jsonSchemaType(typ); !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		property := map[string]any{}
+		switch len(types) {
+		case 0:
+		case 1:
+			property["type"] = types[0]
+		default:
+			property["type"] = types
+		}
+		if f.Description != "" {
+			property["description"] = f.Description
+		}
+		if f.Semconv != "" {
+			property["x-otel-attribute"] = f.Semconv
+		}
+		properties[f.Key] = property
+	}
+	return json.MarshalIndent(map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{keys.Time, keys.Level, keys.Message},
+	}, "", "  ")
+}
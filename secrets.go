@@ -0,0 +1,146 @@
+package loggo
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SecretProvider supplies a credential, such as an API key or token, that
+// may change while the process runs. Implementations typically wrap a
+// secrets manager client (Vault, AWS Secrets Manager, ...).
+type SecretProvider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// SecretFunc adapts a function to the SecretProvider interface.
+type SecretFunc func(ctx context.Context) (string, error)
+
+// Secret calls f(ctx).
+func (f SecretFunc) Secret(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticSecret returns a provider that always returns secret.
+func StaticSecret(secret string) SecretProvider {
+	return SecretFunc(func(context.Context) (string, error) { return secret, nil })
+}
+
+// invalidator is implemented by providers and authenticators whose cached
+// credentials can be dropped after the collector rejects them
+type invalidator interface {
+	Invalidate()
+}
+
+// CachedSecret caches the value of another provider for a TTL and
+// notifies callbacks when the value changes, so long-running sinks pick
+// up rotated credentials without a restart.
+type CachedSecret struct {
+	source    SecretProvider
+	ttl       time.Duration
+	mu        sync.Mutex
+	value     string
+	fetched   time.Time
+	valid     bool
+	callbacks []func(secret string)
+}
+
+// NewCachedSecret caches source for ttl. A non-positive ttl caches the
+// value until Invalidate is called.
+func NewCachedSecret(source SecretProvider, ttl time.Duration) *CachedSecret {
+	return &CachedSecret{source: source, ttl: ttl}
+}
+
+// OnRotate registers a callback invoked with the new value whenever a
+// refresh returns a different secret.
+func (c *CachedSecret) OnRotate(fn func(secret string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callbacks = append(c.callbacks, fn)
+}
+
+// Invalidate forces the next Secret call to fetch a fresh value.
+// Network sinks call it when the collector rejects the credentials.
+func (c *CachedSecret) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid = false
+}
+
+// Secret returns the cached value, refreshing it when expired or invalidated.
+func (c *CachedSecret) Secret(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.valid && (c.ttl <= 0 || time.Since(c.fetched) < c.ttl) {
+		value := c.value
+		c.mu.Unlock()
+		return value, nil
+	}
+	c.mu.Unlock()
+
+	value, err := c.source.Secret(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	rotated := !c.fetched.IsZero() && value != c.value
+	c.value, c.fetched, c.valid = value, time.Now(), true
+	callbacks := slices.Clone(c.callbacks)
+	c.mu.Unlock()
+
+	if rotated {
+		for _, fn := range callbacks {
+			fn(value)
+		}
+	}
+	return value, nil
+}
+
+// providerAuth authenticates requests with a secret from a provider
+type providerAuth struct {
+	provider SecretProvider
+	apply    func(req *http.Request, secret string)
+}
+
+// Authenticate fetches the current secret and applies it to the request.
+func (a *providerAuth) Authenticate(req *http.Request, _ []byte) error {
+	secret, err := a.provider.Secret(req.Context())
+	if err != nil {
+		return err
+	}
+	a.apply(req, secret)
+	return nil
+}
+
+// Invalidate forwards to the provider if it caches its value.
+func (a *providerAuth) Invalidate() {
+	if inv, ok := a.provider.(invalidator); ok {
+		inv.Invalidate()
+	}
+}
+
+// BearerTokenFrom returns an Authenticator sending a bearer token obtained
+// from provider on every request.
+func BearerTokenFrom(provider SecretProvider) Authenticator {
+	return &providerAuth{provider: provider, apply: func(req *http.Request, secret string) {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}}
+}
+
+// BasicAuthFrom returns an Authenticator using basic authentication with a
+// password obtained from provider on every request.
+func BasicAuthFrom(username string, provider SecretProvider) Authenticator {
+	return &providerAuth{provider: provider, apply: func(req *http.Request, secret string) {
+		req.SetBasicAuth(username, secret)
+	}}
+}
+
+// HeaderFrom returns an Authenticator setting header to a secret obtained
+// from provider, for collectors using API key headers.
+func HeaderFrom(header string, provider SecretProvider) Authenticator {
+	return &providerAuth{provider: provider, apply: func(req *http.Request, secret string) {
+		req.Header.Set(header, secret)
+	}}
+}
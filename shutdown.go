@@ -0,0 +1,92 @@
+package loggo
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exitTimeout bounds how long Exit waits for the global logger to close
+var exitTimeout = 5 * time.Second
+
+// CloseGlobal closes the global logger: queued hooks run and sinks are
+// flushed and closed. Programs using the global logger with hooks or
+// sinks should call it before exiting, since events still queued when the
+// process ends are lost. If ctx ends first, CloseGlobal returns ctx.Err()
+// and closing continues in the background.
+func CloseGlobal(ctx context.Context) error {
+	done := make(chan error, 1)
+	logger := globalLogger
+	go func() {
+		done <- logger.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Exit closes the global logger, waiting at most 5 seconds, and then
+// exits the program with the given status code. Use it instead of
+// os.Exit, which ends the process without running queued hooks or
+// flushing sinks.
+func Exit(code int) {
+	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
+	CloseGlobal(ctx)
+	cancel()
+	exitFunc(code)
+}
+
+// ShutdownOptions configures CloseGlobalOnSignal.
+type ShutdownOptions struct {
+	Timeout time.Duration // Longest wait for the global logger to close (default 5s)
+	Signals []os.Signal   // Signals handled (default os.Interrupt and SIGTERM)
+	Exit    bool          // Exit after closing, with status 128+n for signal n as shells report it
+}
+
+// CloseGlobalOnSignal installs a handler that closes the global logger
+// when one of the signals arrives. Handlers the program registered with
+// signal.Notify still receive the signal, so a program shutting down
+// gracefully on its own keeps doing so; one without such a handler sets
+// Exit to end after the logger is closed. The status is also 128+n on
+// Windows, e.g. 130 for Ctrl-C, rather than the one of a process killed
+// by the console, and 1 on Plan 9. The returned function removes the
+// handler.
+func CloseGlobalOnSignal(options ShutdownOptions) (stop func()) {
+	if options.Timeout <= 0 {
+		options.Timeout = exitTimeout
+	}
+	if len(options.Signals) == 0 {
+		options.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, options.Signals...)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+			CloseGlobal(ctx)
+			cancel()
+			if options.Exit {
+				exitFunc(signalStatus(sig))
+			}
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !plan9
+
+package loggo
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalStatus returns the exit status for a process ended by sig
+func signalStatus(sig os.Signal) int {
+	if n, ok := sig.(syscall.Signal); ok {
+		return 128 + int(n)
+	}
+	return 1
+}
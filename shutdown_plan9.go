@@ -0,0 +1,9 @@
+package loggo
+
+import "os"
+
+// signalStatus returns the exit status for a process ended by sig; notes
+// have no numbers on Plan 9
+func signalStatus(sig os.Signal) int {
+	return 1
+}
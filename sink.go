@@ -0,0 +1,104 @@
+package loggo
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// errSinkClosed is returned when writing to a sink that has been closed
+var errSinkClosed = errors.New("loggo: sink is closed")
+
+// Entry is a fully resolved log record delivered to sinks.
+// Deferred fields have already been evaluated, so sinks may read
+// Fields without side effects. An Entry is only valid for the duration
+// of the WriteEntry call; sinks that retain it must copy it, e.g. with
+// Clone, as the logger reuses entries once its sinks have returned.
+type Entry struct {
+	Time    time.Time // Time the event was logged
+	Level   Level     // Severity of the event
+	Message string    // Formatted message
+	Fields  []Field   // Logger and event fields in output order
+
+	cache *encodingCache // Encodings shared by the sinks; nil outside the logger's delivery
+}
+
+// Clone returns a deep copy of the entry that can be retained after
+// WriteEntry returns.
+func (e *Entry) Clone() *Entry {
+	clone := *e
+	clone.Fields = append([]Field(nil), e.Fields...)
+	clone.cache = nil
+	return &clone
+}
+
+// Field returns the last field with the given key.
+func (e *Entry) Field(key string) (Field, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == key {
+			return e.Fields[i], true
+		}
+	}
+	return Field{}, false
+}
+
+// Sink receives structured entries in addition to the text written to
+// the logger's outputs. Sinks are called synchronously from the logging
+// call in the order they were added; slow sinks should buffer internally.
+type Sink interface {
+	// WriteEntry encodes and stores a single entry.
+	WriteEntry(entry *Entry) error
+	// Close flushes any buffered entries and releases resources.
+	Close() error
+}
+
+// AddSink registers a sink that receives every entry written by the logger.
+// Sinks are closed when the logger is closed.
+func (l *Logger) AddSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var sinks []Sink
+	if current := l.sinks.Load(); current != nil {
+		sinks = append(sinks, *current...)
+	}
+	sinks = append(sinks, sink)
+	l.shareRand(sink)
+	l.sinks.Store(&sinks)
+}
+
+// removeSink unregisters a sink without closing it
+func (l *Logger) removeSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.sinks.Load()
+	if current == nil {
+		return
+	}
+	sinks := slices.DeleteFunc(slices.Clone(*current), func(s Sink) bool { return s == sink })
+	l.sinks.Store(&sinks)
+}
+
+// loadSinks returns the currently registered sinks without locking
+func (l *Logger) loadSinks() []Sink {
+	if sinks := l.sinks.Load(); sinks != nil {
+		return *sinks
+	}
+	return nil
+}
+
+// closeSinks closes and unregisters all sinks, returning the joined errors
+func (l *Logger) closeSinks() error {
+	sinks := l.sinks.Swap(nil)
+	if sinks == nil {
+		return nil
+	}
+	var errs []error
+	for _, sink := range *sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,1726 @@
+package loggo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendJSON(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2025, 4, 4, 12, 0, 0, 0, time.UTC),
+		Level:   WARN,
+		Message: "quote \" newline \n bad \xff",
+		Fields:  []Field{Str("user", "alice")},
+	}
+	line := AppendJSON(nil, entry)
+
+	var decoded map[string]any
+	if err := json.Unmarshal(line, &decoded); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", line, err)
+	}
+	if decoded["level"] != "warn" || decoded["user"] != "alice" {
+		t.Errorf("Unexpected JSON content: %s", line)
+	}
+	if decoded["msg"] != "quote \" newline \n bad �" {
+		t.Errorf("Unexpected message: %q", decoded["msg"])
+	}
+	if !strings.HasPrefix(string(line), `{"time":"2025-04-04T12:00:00Z","level":"warn","msg":`) {
+		t.Errorf("Unexpected key order: %s", line)
+	}
+}
+
+func TestJSONFieldOrder(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2025, 4, 4, 12, 0, 0, 0, time.UTC),
+		Level:   INFO,
+		Message: "m",
+		Fields:  []Field{Int("zeta", 1), Str("alpha", "a"), Int("mid", 2), Str("alpha", "b")},
+	}
+	prefix := `{"time":"2025-04-04T12:00:00Z","level":"info","msg":"m",`
+
+	if got, want := string(AppendJSON(nil, entry)), prefix+`"zeta":1,"alpha":"a","mid":2,"alpha":"b"}`; got != want {
+		t.Errorf("Expected insertion order %s, got %s", want, got)
+	}
+	sorted := JSONKeys{Order: FieldsSorted}
+	if got, want := string(AppendJSONKeys(nil, entry, sorted)), prefix+`"alpha":"a","alpha":"b","mid":2,"zeta":1}`; got != want {
+		t.Errorf("Expected sorted order %s, got %s", want, got)
+	}
+	if entry.Fields[0].Key != "zeta" {
+		t.Errorf("Expected the entry's fields left in order, got %v", entry.Fields)
+	}
+
+	// More fields than fit the scratch space
+	entry.Fields = nil
+	for i := 30; i > 0; i-- {
+		entry.Fields = append(entry.Fields, Int(fmt.Sprintf("k%02d", i), i))
+	}
+	line := string(AppendJSONKeys(nil, entry, sorted))
+	if !strings.HasPrefix(line, prefix+`"k01":1,"k02":2,`) || !strings.HasSuffix(line, `"k30":30}`) {
+		t.Errorf("Expected 30 sorted fields, got %s", line)
+	}
+}
+
+func TestAppendLogfmt(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2025, 4, 4, 12, 0, 0, 0, time.UTC),
+		Level:   WARN,
+		Message: "disk \"almost\" full",
+		Fields:  []Field{Str("user", "alice"), Int("free_mb", 12), Str("bad key=", ""), Float64("ratio", 0.5)},
+	}
+	want := `level=warn ts=2025-04-04T12:00:00Z msg="disk \"almost\" full" user=alice free_mb=12 bad_key_="" ratio=0.5`
+	if got := string(AppendLogfmt(nil, entry)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	keys := JSONKeys{Time: "time", Order: FieldsSorted}
+	want = `level=warn time=2025-04-04T12:00:00Z msg="disk \"almost\" full" bad_key_="" free_mb=12 ratio=0.5 user=alice`
+	if got := string(AppendLogfmtKeys(nil, entry, keys)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	// Each output can receive its own encoding
+	logger := New()
+	logger.SetOutput(io.Discard)
+	var logfmt, jsonl bytes.Buffer
+	defer logger.Tee(&logfmt, TeeOptions{Logfmt: true})()
+	defer logger.Tee(&jsonl, TeeOptions{JSON: true})()
+	logger.With("id", 7).InfoEvent().Time(entry.Time).Msg("started")
+	if got, want := logfmt.String(), "level=info ts=2025-04-04T12:00:00Z msg=started id=7\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := jsonl.String(), `{"time":"2025-04-04T12:00:00Z","level":"info","msg":"started","id":7}`+"\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestJSONLFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewJSONLFileSink(path, FsyncEveryEntry, 0)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	logger := New()
+	logger.SetOutput(&strings.Builder{})
+	logger.AddSink(sink)
+	logger.InfoEvent().LazyStr("id", func() string { return "42" }).Msg("first")
+	logger.Warnf("second %d", 2)
+
+	if stats := sink.Stats(); stats.Count != 2 || stats.Errors != 0 {
+		t.Errorf("Expected 2 successful fsyncs, got %+v", stats)
+	}
+	logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), data)
+	}
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["msg"] != "first" || first["id"] != "42" || second["msg"] != "second 2" {
+		t.Errorf("Unexpected entries: %q", data)
+	}
+
+	if err := sink.WriteEntry(&Entry{}); err == nil {
+		t.Error("Expected write after close to fail")
+	}
+}
+
+func TestRotatingFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotatingFileWriter(path, RotateConfig{MaxSize: 40, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2025, 4, 4, 10, 30, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+
+	logger := New()
+	logger.SetOutput(w)
+	for i := range 4 {
+		logger.Infof("record %d", i) // Each record is longer than half MaxSize
+		clock = clock.Add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no close error, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "record 3") || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("Expected the last record alone in the current file, got %q", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	want := []string{
+		filepath.Join(dir, "app-2025-04-04T10-30-02.000.log"),
+		filepath.Join(dir, "app-2025-04-04T10-30-03.000.log"),
+	}
+	if !slices.Equal(backups, want) {
+		t.Errorf("Expected the 2 newest backups %q, got %q", want, backups)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("Expected writes after Close to fail")
+	}
+
+	// Time-based rotation with compression
+	path = filepath.Join(dir, "hourly.log")
+	w, err = NewRotatingFileWriter(path, RotateConfig{Interval: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return clock }
+	w.period = w.currentPeriod()
+	w.Write([]byte("before the hour\n"))
+	clock = clock.Add(30 * time.Minute)
+	w.Write([]byte("after the hour\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no close error, got %v", err)
+	}
+	file, err := os.Open(filepath.Join(dir, "hourly-2025-04-04T11-00-04.000.log.gz"))
+	if err != nil {
+		t.Fatalf("Expected a compressed backup: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "before the hour\n" {
+		t.Errorf("Expected the rotated record in the backup, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "after the hour\n" {
+		t.Errorf("Expected the new record in the current file, got %q", data)
+	}
+
+	// Reopen follows a file moved away by an external tool
+	path = filepath.Join(dir, "moved.log")
+	w, err = NewRotatingFileWriter(path, RotateConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("old\n"))
+	os.Rename(path, path+".1")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("new\n"))
+	w.Close()
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("Expected writes to the reopened file, got %q", data)
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "old\n" {
+		t.Errorf("Expected the moved file to keep its records, got %q", data)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	create := func(name string, size int, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+	create("app.log", 100, 0)
+	create("notes.txt", 100, 100*time.Hour)
+	create("app-2025-04-04T10-00-00.000.log.gz", 10, 50*time.Hour)
+	create("app-2025-04-05T10-00-00.000.log.gz", 10, 26*time.Hour)
+	create("app-2025-04-06T10-00-00.000.log.gz", 10, 3*time.Hour)
+	create("app-2025-04-06T11-00-00.000.log", 30, 2*time.Hour)
+	create("db-2025-04-06T11-00-00.000.1.log", 30, time.Hour)
+	remaining := func() []string {
+		entries, _ := os.ReadDir(dir)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	if err := (RetentionPolicy{MaxAge: 48 * time.Hour}).Enforce(dir); err != nil {
+		t.Fatal(err)
+	}
+	if names := remaining(); slices.Contains(names, "app-2025-04-04T10-00-00.000.log.gz") || len(names) != 6 {
+		t.Errorf("Expected only the backup older than MaxAge removed, got %q", names)
+	}
+
+	// The newest files are kept, whatever their application
+	(RetentionPolicy{MaxFiles: 3, MaxTotalSize: 100}).Enforce(dir)
+	want := []string{"app-2025-04-06T10-00-00.000.log.gz", "app-2025-04-06T11-00-00.000.log", "app.log", "db-2025-04-06T11-00-00.000.1.log", "notes.txt"}
+	if names := remaining(); !slices.Equal(names, want) {
+		t.Errorf("Expected %q within MaxFiles and MaxTotalSize, got %q", want, names)
+	}
+	(RetentionPolicy{MaxTotalSize: 50}).Enforce(dir)
+	want = []string{"app.log", "db-2025-04-06T11-00-00.000.1.log", "notes.txt"}
+	if names := remaining(); !slices.Equal(names, want) {
+		t.Errorf("Expected older files removed once one exceeds MaxTotalSize, got %q", names)
+	}
+
+	// A pattern selects other files, and the logger runs the policy
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.EnforceRetention(dir, RetentionPolicy{Pattern: "*.txt", MaxAge: time.Hour, Interval: 5 * time.Millisecond})
+	deadline := time.Now().Add(2 * time.Second)
+	for slices.Contains(remaining(), "notes.txt") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the logger to enforce the policy")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	logger.Close()
+	create("late.txt", 10, 100*time.Hour)
+	time.Sleep(30 * time.Millisecond)
+	if !slices.Contains(remaining(), "late.txt") {
+		t.Errorf("Expected no retention runs after Close")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.log")); err != nil {
+		t.Errorf("Expected the current file kept: %v", err)
+	}
+}
+
+func TestJSONLFileSinkInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+	sink, err := NewJSONLFileSink(path, FsyncInterval, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "tick"})
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.Stats().Count == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Interval fsync did not happen")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBatchSinkOrdering(t *testing.T) {
+	var batches []Batch
+	failures := 1
+	sender := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		if failures > 0 {
+			failures--
+			return errors.New("collector unavailable")
+		}
+		copied := *batch
+		copied.Entries = append([]*Entry(nil), batch.Entries...)
+		batches = append(batches, copied)
+		return nil
+	})
+	sink := NewBatchSink(sender, BatchConfig{
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+		Stream:        func(e *Entry) string { return e.Level.String() },
+	})
+	defer sink.Close()
+
+	base := time.Date(2025, 4, 4, 12, 0, 0, 0, time.UTC)
+	sink.WriteEntry(&Entry{Time: base.Add(2 * time.Second), Level: INFO, Message: "third"})
+	sink.WriteEntry(&Entry{Time: base, Level: INFO, Message: "first"})
+	sink.WriteEntry(&Entry{Time: base.Add(time.Second), Level: INFO, Message: "second"})
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if len(batches) != 1 || batches[0].Attempt != 2 {
+		t.Fatalf("Expected one batch delivered on the second attempt, got %+v", batches)
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if got := batches[0].Entries[i].Message; got != want {
+			t.Errorf("Entry %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if batches[0].SentAt.Before(batches[0].Entries[2].Time) {
+		t.Error("Expected send time to be recorded separately from event time")
+	}
+
+	// A late entry older than the last delivered one is moved forward
+	sink.WriteEntry(&Entry{Time: base.Add(-time.Minute), Level: INFO, Message: "late"})
+	sink.Flush()
+	late := batches[1].Entries[0]
+	if !late.Time.Equal(base.Add(2 * time.Second)) {
+		t.Errorf("Expected late entry to be clamped to last sent time, got %v", late.Time)
+	}
+	if len(late.Fields) != 1 || late.Fields[0].Key != "event_time" {
+		t.Errorf("Expected original time in event_time field, got %+v", late.Fields)
+	}
+
+	stats := sink.Stats()
+	if stats.Retries != 1 || stats.Reordered != 1 || stats.SkewCorrected != 1 || stats.Entries != 4 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+type reverseCodec struct{}
+
+func (reverseCodec) Name() string { return "reverse" }
+
+func (reverseCodec) Compress(dst, src []byte) ([]byte, error) {
+	for i := len(src) - 1; i >= 0; i-- {
+		dst = append(dst, src[i])
+	}
+	return dst, nil
+}
+
+func TestCodecRegistry(t *testing.T) {
+	if _, err := LookupCodec("zstd"); err == nil {
+		t.Error("Expected unregistered codec lookup to fail")
+	}
+	RegisterCodec(reverseCodec{})
+	codec, err := LookupCodec("reverse")
+	if err != nil {
+		t.Fatalf("Expected registered codec: %v", err)
+	}
+	if out, _ := codec.Compress(nil, []byte("abc")); string(out) != "cba" {
+		t.Errorf("Unexpected codec output %q", out)
+	}
+	if !slices.Contains(Codecs(), "gzip") || !slices.Contains(Codecs(), "reverse") {
+		t.Errorf("Unexpected codec list %v", Codecs())
+	}
+}
+
+func TestHTTPSinkGzip(t *testing.T) {
+	received := make(chan []string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected gzip encoding, got %q", r.Header.Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("Invalid gzip body: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(zr)
+		received <- strings.Split(strings.TrimSpace(string(data)), "\n")
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPSink(HTTPConfig{URL: server.URL, Codec: "snappy"}, BatchConfig{}); err == nil {
+		t.Error("Expected unknown codec to be rejected")
+	}
+	sink, err := NewHTTPSink(HTTPConfig{URL: server.URL, Codec: "gzip"}, BatchConfig{FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "one"})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "two"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	lines := <-received
+	if len(lines) != 2 || !strings.Contains(lines[1], `"msg":"two"`) {
+		t.Errorf("Unexpected request body lines: %q", lines)
+	}
+}
+
+func TestSigV4(t *testing.T) {
+	// AWS Signature Version 4 test suite, "get-vanilla"
+	signer := SigV4(SigV4Config{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}).(*sigV4Signer)
+	signer.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err := signer.Authenticate(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Unexpected signature:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestHTTPSinkTLSPinningAndAuth(t *testing.T) {
+	var authHeader string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pin := "sha256/" + PublicKeyPin(server.Certificate())
+	batch := &Batch{Entries: []*Entry{{Time: time.Now(), Level: INFO, Message: "secure"}}}
+
+	sender, err := NewHTTPSender(HTTPConfig{
+		URL:  server.URL,
+		TLS:  &TLSConfig{CAFile: caFile, PinnedKeys: []string{pin}},
+		Auth: BearerToken("s3cr3t"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+	if err := sender.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send with matching pin failed: %v", err)
+	}
+	if authHeader != "Bearer s3cr3t" {
+		t.Errorf("Expected bearer token, got %q", authHeader)
+	}
+
+	sender, err = NewHTTPSender(HTTPConfig{
+		URL: server.URL,
+		TLS: &TLSConfig{CAFile: caFile, PinnedKeys: []string{"sha256/AAAA"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+	if err := sender.Send(context.Background(), batch); err == nil {
+		t.Error("Expected send with mismatched pin to fail")
+	}
+
+	if _, err := NewHTTPSender(HTTPConfig{URL: server.URL, TLS: &TLSConfig{CAFile: "/nonexistent"}}); err == nil {
+		t.Error("Expected missing CA file to be reported at creation")
+	}
+}
+
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests = append(rt.requests, req)
+	return &http.Response{StatusCode: http.StatusNoContent, Status: "204 No Content", Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestHTTPSenderCustomClient(t *testing.T) {
+	transport := &recordingTransport{}
+	sender, err := NewHTTPSender(HTTPConfig{
+		URL:    "http://collector.internal/ingest",
+		Client: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+	batch := &Batch{Entries: []*Entry{{Time: time.Now(), Level: INFO, Message: "via proxy"}}}
+	if err := sender.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(transport.requests) != 1 || transport.requests[0].URL.Host != "collector.internal" {
+		t.Errorf("Expected request to go through the custom client, got %v", transport.requests)
+	}
+
+	_, err = NewHTTPSender(HTTPConfig{
+		URL:    "https://collector.internal/ingest",
+		Client: &http.Client{Transport: transport},
+		TLS:    &TLSConfig{},
+	})
+	if err == nil {
+		t.Error("Expected TLS with a non-*http.Transport client to be rejected")
+	}
+}
+
+func TestBatchSinkQueuePersistence(t *testing.T) {
+	queue := filepath.Join(t.TempDir(), "queue.jsonl")
+	down := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		return errors.New("collector down")
+	})
+	sink := NewBatchSink(down, BatchConfig{MaxRetries: -1, FlushInterval: time.Hour, QueueFile: queue})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "one", Fields: []Field{Str("tenant", "acme")}})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "two"})
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "collector down") {
+		t.Errorf("Expected Close to report the failed flush, got %v", err)
+	}
+	if stats := sink.Stats(); stats.Persisted != 2 || stats.Dropped != 0 {
+		t.Errorf("Expected 2 persisted entries, got %+v", stats)
+	}
+
+	// The file survives a failed delivery of the restored entries
+	sink = NewBatchSink(down, BatchConfig{MaxRetries: -1, FlushInterval: time.Hour, QueueFile: queue})
+	if stats := sink.Stats(); stats.Restored != 2 {
+		t.Errorf("Expected 2 restored entries, got %+v", stats)
+	}
+	sink.Flush()
+	if _, err := os.Stat(queue); err != nil {
+		t.Errorf("Expected the queue file kept until the entries are delivered: %v", err)
+	}
+	sink.Close()
+
+	var delivered []*Entry
+	up := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		delivered = append(delivered, batch.Entries...)
+		return nil
+	})
+	sink = NewBatchSink(up, BatchConfig{FlushInterval: time.Hour, QueueFile: queue})
+	if _, err := os.Stat(queue); err != nil {
+		t.Errorf("Expected the queue file kept until the first flush: %v", err)
+	}
+	defer sink.Close()
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(delivered) != 2 || delivered[0].Message != "one" || delivered[0].Fields[0].Str != "acme" {
+		t.Fatalf("Expected restored entries to be delivered, got %+v", delivered)
+	}
+	if _, err := os.Stat(queue); !os.IsNotExist(err) {
+		t.Error("Expected queue file to be removed after delivery")
+	}
+}
+
+// memoryDeadLetter records dead-lettered entries for assertions
+type memoryDeadLetter struct {
+	entries []*Entry
+}
+
+func (d *memoryDeadLetter) DeadLetter(sink string, reason error, entries ...*Entry) {
+	d.entries = append(d.entries, entries...)
+}
+
+func TestBatchSinkQueueBacklog(t *testing.T) {
+	queue := filepath.Join(t.TempDir(), "queue.jsonl")
+	down := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		return errors.New("collector down")
+	})
+	dl := &memoryDeadLetter{}
+	config := BatchConfig{MaxRetries: -1, FlushInterval: time.Hour, QueueFile: queue, MaxQueued: 3, DeadLetter: dl}
+
+	// Batches failing while the sink runs are kept, the oldest dropped
+	// beyond MaxQueued
+	sink := NewBatchSink(down, config)
+	for _, msg := range []string{"one", "two"} {
+		sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: msg})
+		sink.Flush()
+	}
+	if stats := sink.Stats(); stats.Queued != 2 || stats.Dropped != 0 {
+		t.Errorf("Expected 2 queued entries, got %+v", stats)
+	}
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "three"})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "four"})
+	sink.Close()
+	if stats := sink.Stats(); stats.Persisted != 3 || stats.Dropped != 1 {
+		t.Errorf("Expected 3 persisted and 1 dropped entries, got %+v", stats)
+	}
+	if len(dl.entries) != 1 || dl.entries[0].Message != "one" {
+		t.Errorf("Expected the oldest entry dead-lettered, got %+v", dl.entries)
+	}
+
+	// Restored entries leaving memory are removed from the file, so they
+	// are not sent again after another restart
+	config.MaxQueued = 2
+	sink = NewBatchSink(down, config)
+	sink.Flush()
+	data, err := os.ReadFile(queue)
+	if err != nil || strings.Count(string(data), "\n") != 2 || strings.Contains(string(data), `"two"`) {
+		t.Errorf("Expected the queue file rewritten without the dropped entry, got %q, %v", data, err)
+	}
+	sink.Close()
+
+	var delivered []string
+	up := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		for _, entry := range batch.Entries {
+			delivered = append(delivered, entry.Message)
+		}
+		return nil
+	})
+	sink = NewBatchSink(up, config)
+	defer sink.Close()
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !slices.Equal(delivered, []string{"three", "four"}) {
+		t.Errorf("Expected the backlog delivered once, got %q", delivered)
+	}
+}
+
+// memorySink records entries for assertions
+type memorySink struct {
+	mu       sync.Mutex
+	entries  []*Entry
+	closed   int
+	closeErr error
+}
+
+func (s *memorySink) WriteEntry(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry.Clone())
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed++
+	return s.closeErr
+}
+
+func (s *memorySink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var msgs []string
+	for _, entry := range s.entries {
+		msgs = append(msgs, entry.Message)
+	}
+	return msgs
+}
+
+func TestRouterSink(t *testing.T) {
+	shared, acme, globex := &memorySink{}, &memorySink{}, &memorySink{}
+	router := NewRouterSink([]Route{
+		{Field: "tenant", Value: "acme", Sink: acme},
+		{Field: "tenant", Value: "globex", Sink: globex, Exclusive: true},
+	}, shared)
+
+	router.WriteEntry(&Entry{Message: "acme", Fields: []Field{Str("tenant", "acme")}})
+	router.WriteEntry(&Entry{Message: "globex", Fields: []Field{Str("tenant", "globex")}})
+	router.WriteEntry(&Entry{Message: "none"})
+
+	if got := acme.messages(); !slices.Equal(got, []string{"acme"}) {
+		t.Errorf("Unexpected acme entries %v", got)
+	}
+	if got := globex.messages(); !slices.Equal(got, []string{"globex"}) {
+		t.Errorf("Unexpected globex entries %v", got)
+	}
+	if got := shared.messages(); !slices.Equal(got, []string{"acme", "none"}) {
+		t.Errorf("Exclusive routes must not reach the default sinks, got %v", got)
+	}
+
+	router.Close()
+	if shared.closed != 1 || acme.closed != 1 || globex.closed != 1 {
+		t.Error("Expected every sink to be closed exactly once")
+	}
+}
+
+func TestMappingSink(t *testing.T) {
+	target := &memorySink{}
+	sink := NewMappingSink(target, FieldMapping{
+		Drop:       []string{"password"},
+		Rename:     map[string]string{"user": "user_id"},
+		Coerce:     map[string]FieldType{"status": IntType, "ok": BoolType},
+		Namespace:  "http",
+		Namespaced: []string{"status"},
+	})
+	original := &Entry{Message: "request", Fields: []Field{
+		Str("user", "42"), Str("password", "hunter2"), Str("status", "503"), Str("ok", "false"),
+	}}
+	sink.WriteEntry(original)
+
+	got := target.entries[0].Fields
+	want := []Field{Str("user_id", "42"), Int("http.status", 503), Bool("ok", false)}
+	if !slices.Equal(got, want) {
+		t.Errorf("Unexpected mapped fields:\n got %+v\nwant %+v", got, want)
+	}
+	if original.Fields[0].Key != "user" {
+		t.Error("Mapping must not modify the original entry")
+	}
+
+	line := AppendJSONKeys(nil, target.entries[0], JSONKeys{Time: "@timestamp", Message: "message"})
+	if !strings.Contains(string(line), `"@timestamp":`) || !strings.Contains(string(line), `"message":"request"`) ||
+		!strings.Contains(string(line), `"http.status":503`) {
+		t.Errorf("Unexpected JSON %s", line)
+	}
+}
+
+func TestJSONLChecksums(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checked.jsonl")
+	sink, err := NewJSONLFileSink(path, FsyncNever, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.SetChecksums(true)
+	for _, msg := range []string{"one", "two", "three"} {
+		sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: msg})
+	}
+	sink.Close()
+
+	data, _ := os.ReadFile(path)
+	var decoded map[string]any
+	if err := json.Unmarshal(data[:bytes.IndexByte(data, '\n')], &decoded); err != nil || decoded["crc32"] == nil {
+		t.Fatalf("Expected checksummed records to stay valid JSON: %v", err)
+	}
+	validSize := int64(len(data))
+
+	// Simulate a crash mid-write
+	torn := append(data, `{"time":"2025-04-04T12:00:00Z","lev`...)
+	os.WriteFile(path, torn, 0o644)
+	report, err := SalvageJSONL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 3 || report.Corrupt != 1 || report.ValidSize != validSize {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if info, _ := os.Stat(path); info.Size() != validSize {
+		t.Errorf("Expected file truncated to %d bytes, got %d", validSize, info.Size())
+	}
+
+	// Flip a byte inside the second record
+	corrupted := bytes.Replace(data, []byte(`"two"`), []byte(`"tWo"`), 1)
+	report, _ = VerifyJSONL(bytes.NewReader(corrupted))
+	if report.Records != 2 || report.Corrupt != 1 || report.ValidSize != int64(bytes.IndexByte(data, '\n')+1) {
+		t.Errorf("Unexpected report for corrupted record %+v", report)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tail.jsonl")
+	sink, err := NewJSONLFileSink(path, FsyncNever, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.SetChecksums(true)
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "one"})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: WARN, Message: "two"})
+
+	tailer, err := Tail(path, 0, TailOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []TailRecord
+	for record := range tailer.C {
+		records = append(records, record)
+	}
+	if len(records) != 2 || records[1].Entry.Message != "two" || len(records[0].Entry.Fields) != 0 {
+		t.Fatalf("Unexpected records %+v", records)
+	}
+
+	// Follow from the end, across a rotation
+	follower, err := Tail(path, records[1].Offset, TailOptions{Follow: true, PollInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follower.Stop()
+	next := func() string {
+		select {
+		case record := <-follower.C:
+			if record.Err != nil {
+				t.Fatalf("Unexpected error: %v", record.Err)
+			}
+			return record.Entry.Message
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for record")
+			return ""
+		}
+	}
+
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "three"})
+	if got := next(); got != "three" {
+		t.Errorf("Expected followed record, got %q", got)
+	}
+
+	sink.Close()
+	os.Rename(path, path+".1")
+	rotated, err := NewJSONLFileSink(path, FsyncNever, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rotated.Close()
+	rotated.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "after rotation"})
+	if got := next(); got != "after rotation" {
+		t.Errorf("Expected record from rotated file, got %q", got)
+	}
+}
+
+func TestLiveTailHandler(t *testing.T) {
+	broadcast := NewBroadcastSink(0)
+	server := httptest.NewServer(LiveTailHandler(broadcast))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?level=warn&field=tenant:acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Unexpected content type %q", ct)
+	}
+
+	// Wait for the handler to subscribe
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		broadcast.mu.RLock()
+		n := len(broadcast.subscribers)
+		broadcast.mu.RUnlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Handler did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(broadcast)
+	logger.InfoEvent().LazyStr("tenant", func() string { return "acme" }).Msg("too low")
+	logger.WarnEvent().LazyStr("tenant", func() string { return "globex" }).Msg("other tenant")
+	logger.ErrorEvent().LazyStr("tenant", func() string { return "acme" }).Msg("match")
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "data: ") || !strings.Contains(line, `"msg":"match"`) {
+		t.Errorf("Unexpected event %q", line)
+	}
+
+	bad, err := http.Get(server.URL + "?level=loud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid level, got %d", bad.StatusCode)
+	}
+	broadcast.Close()
+}
+
+func TestSecretRotation(t *testing.T) {
+	var mu sync.Mutex
+	current := "token-1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+current {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	vault := SecretFunc(func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return current, nil
+	})
+	secret := NewCachedSecret(vault, 0)
+	var rotated []string
+	secret.OnRotate(func(value string) { rotated = append(rotated, value) })
+
+	sink, err := NewHTTPSink(HTTPConfig{URL: server.URL, Auth: BearerTokenFrom(secret)},
+		BatchConfig{FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "before"})
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush with initial token failed: %v", err)
+	}
+
+	mu.Lock()
+	current = "token-2"
+	mu.Unlock()
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: "after"})
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush after rotation failed: %v", err)
+	}
+	if stats := sink.Stats(); stats.Retries != 1 || stats.Entries != 2 {
+		t.Errorf("Expected one retry with the rotated token, got %+v", stats)
+	}
+	if !slices.Equal(rotated, []string{"token-2"}) {
+		t.Errorf("Expected rotation callback, got %v", rotated)
+	}
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2025, 4, 4, 12, 0, 0, 42, time.UTC),
+		Level:   ERROR,
+		Message: "failed",
+		Fields:  []Field{Str("user", "alice"), Int("attempt", -3), Float64("ratio", 0.5), Bool("retry", true)},
+	}
+	parsed, err := ParseProto(AppendProto(nil, entry))
+	if err != nil {
+		t.Fatalf("ParseProto failed: %v", err)
+	}
+	if !parsed.Time.Equal(entry.Time) || parsed.Level != ERROR || parsed.Message != "failed" {
+		t.Errorf("Expected header to round-trip, got %+v", parsed)
+	}
+	if !slices.Equal(parsed.Fields, entry.Fields) {
+		t.Errorf("Expected fields %v, got %v", entry.Fields, parsed.Fields)
+	}
+	if _, err := ParseProto([]byte{0x1a, 0x10, 'x'}); err == nil {
+		t.Errorf("Expected error for truncated message")
+	}
+}
+
+func TestGRPCSink(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loggo.v1.Ingest/Push" || r.ProtoMajor != 2 {
+			t.Errorf("Expected HTTP/2 call to Push, got %s %s", r.Proto, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		header := make([]byte, 5)
+		for {
+			if _, err := io.ReadFull(r.Body, header); err != nil {
+				break
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(header[1:]))
+			io.ReadFull(r.Body, msg)
+			entry, seq, err := parseProtoEntry(msg)
+			if err != nil {
+				t.Errorf("Invalid entry: %v", err)
+				return
+			}
+			mu.Lock()
+			received = append(received, entry.Message)
+			mu.Unlock()
+
+			ack := appendProtoVarint(nil, 1, seq)
+			frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(ack)))
+			w.Write(append(frame, ack...))
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	var streamErrors []error
+	sink, err := NewGRPCSink(GRPCConfig{
+		// The first endpoint refuses connections, forcing a failover
+		Endpoints:        []string{"https://127.0.0.1:1", server.URL},
+		Client:           server.Client(),
+		MaxInFlight:      2,
+		ReconnectBackoff: time.Millisecond,
+		OnError:          func(err error) { streamErrors = append(streamErrors, err) },
+	})
+	if err != nil {
+		t.Fatalf("NewGRPCSink failed: %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three", "four", "five"} {
+		sink.WriteEntry(&Entry{Time: time.Now(), Level: INFO, Message: msg})
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(received, []string{"one", "two", "three", "four", "five"}) {
+		t.Errorf("Expected all entries in order, got %v", received)
+	}
+	stats := sink.Stats()
+	if stats.Acked != 5 || stats.Dropped != 0 || stats.Streams != 2 || stats.Errors != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if len(streamErrors) != 1 {
+		t.Errorf("Expected one stream error from the refused endpoint, got %v", streamErrors)
+	}
+}
+
+func TestSocketSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on Windows")
+	}
+	dir, err := os.MkdirTemp("", "loggo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "collector.sock")
+
+	// The collector starts after the sink; the first entry is dropped
+	sink, err := NewSocketSink(SocketConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewSocketSink failed: %v", err)
+	}
+	defer sink.Close()
+	if err := sink.WriteEntry(&Entry{Message: "lost"}); err == nil {
+		t.Errorf("Expected error while the collector is down")
+	}
+
+	collector, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Close()
+
+	for _, msg := range []string{"one", "two"} {
+		if err := sink.WriteEntry(&Entry{Level: INFO, Message: msg}); err != nil {
+			t.Fatalf("WriteEntry failed: %v", err)
+		}
+	}
+	packet := make([]byte, 4096)
+	for _, want := range []string{"one", "two"} {
+		collector.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := collector.Read(packet)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		size := binary.BigEndian.Uint32(packet)
+		if int(size) != n-4 {
+			t.Errorf("Expected length prefix %d, got %d", n-4, size)
+		}
+		entry, err := ParseJSON(packet[4:n])
+		if err != nil || entry.Message != want {
+			t.Errorf("Expected entry %q, got %v (%v)", want, entry, err)
+		}
+	}
+
+	stats := sink.Stats()
+	if stats.Sent != 2 || stats.Dropped != 1 || stats.Connects != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestFluentSender(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan []any, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, err := readMsgpack(bufio.NewReader(conn))
+		if err != nil {
+			t.Errorf("Invalid message: %v", err)
+			return
+		}
+		forward := msg.([]any)
+		chunk := forward[2].(map[string]any)["chunk"].(string)
+		conn.Write(appendMsgpackString(appendMsgpackString(appendMsgpackMap(nil, 1), "ack"), chunk))
+		received <- forward
+	}()
+
+	sender, err := NewFluentSender(FluentConfig{Address: listener.Addr().String(), Tag: "app", RequireAck: true})
+	if err != nil {
+		t.Fatalf("NewFluentSender failed: %v", err)
+	}
+	when := time.Date(2025, 4, 4, 12, 0, 0, 500, time.UTC)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = sender.Send(ctx, &Batch{Stream: "web", Entries: []*Entry{
+		{Time: when, Level: WARN, Message: "slow", Fields: []Field{Int("ms", 1500), Bool("cached", false)}},
+	}})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	forward := <-received
+	if forward[0] != "app.web" {
+		t.Errorf("Expected tag app.web, got %v", forward[0])
+	}
+	event := forward[1].([]any)[0].([]any)
+	ts := event[0].(msgpackExt)
+	if ts.Type != 0 || binary.BigEndian.Uint32(ts.Data) != uint32(when.Unix()) || binary.BigEndian.Uint32(ts.Data[4:]) != 500 {
+		t.Errorf("Expected EventTime for %v, got %v", when, ts)
+	}
+	record := event[1].(map[string]any)
+	if record["level"] != "warn" || record["msg"] != "slow" || record["ms"] != int64(1500) || record["cached"] != false {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+func TestStatsDSink(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	sink, err := NewStatsDSink(StatsDConfig{
+		Address:   agent.LocalAddr().String(),
+		Prefix:    "app.",
+		Tags:      []string{"env:test"},
+		DogStatsD: true,
+		Rules: []StatsDRule{
+			{Filter: EntryFilter{MinLevel: ERROR}, Metric: "errors", LevelTag: true},
+			{Metric: "request.duration", Type: StatsDTimer, ValueField: "ms", TagFields: []string{"route"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewStatsDSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	sink.WriteEntry(&Entry{Level: INFO, Message: "ignored"})
+	sink.WriteEntry(&Entry{Level: ERROR, Message: "failed", Fields: []Field{Int("ms", 250), Str("route", "/pay")}})
+
+	packet := make([]byte, 2048)
+	agent.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := agent.ReadFrom(packet)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	want := "app.errors:1|c|#env:test,level:error\napp.request.duration:250|ms|#env:test,route:/pay"
+	if got := string(packet[:n]); got != want {
+		t.Errorf("Expected packet %q, got %q", want, got)
+	}
+	if stats := sink.Stats(); stats.Metrics != 2 || stats.Packets != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestAlertSink(t *testing.T) {
+	var mu sync.Mutex
+	var events []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]any
+		json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewAlertSink(AlertConfig{
+		Provider:   &PagerDuty{RoutingKey: "key", URL: server.URL},
+		DedupField: "incident",
+		Source:     "web-1",
+		RateLimit:  2,
+	})
+	sink.WriteEntry(&Entry{Level: ERROR, Message: "below threshold"})
+	sink.WriteEntry(&Entry{Level: CRITICAL, Message: "db down", Fields: []Field{Str("incident", "db")}})
+	sink.WriteEntry(&Entry{Level: CRITICAL, Message: "db still down", Fields: []Field{Str("incident", "db")}})
+	sink.Hook(FATAL, "disk full")
+	sink.Hook(FATAL, "out of memory")
+	sink.Close()
+
+	stats := sink.Stats()
+	if stats.Sent != 2 || stats.Deduplicated != 1 || stats.RateLimited != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	for _, event := range events {
+		payload := event["payload"].(map[string]any)
+		switch event["dedup_key"] {
+		case "db":
+			if payload["severity"] != "critical" || payload["source"] != "web-1" {
+				t.Errorf("Unexpected payload %v", payload)
+			}
+		default:
+			if payload["summary"] != "disk full" || !strings.HasPrefix(event["dedup_key"].(string), "loggo-") {
+				t.Errorf("Unexpected event %v", event)
+			}
+		}
+	}
+}
+
+// fakeSMTPServer accepts mail on a local port and returns the message bodies
+func fakeSMTPServer(t *testing.T) (addr string, messages func() []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var received []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				io.WriteString(conn, "220 test\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+					case "EHLO", "HELO", "MAIL", "RCPT":
+						io.WriteString(conn, "250 ok\r\n")
+					case "DATA":
+						io.WriteString(conn, "354 go ahead\r\n")
+						var msg strings.Builder
+						for {
+							line, err := r.ReadString('\n')
+							if err != nil || line == ".\r\n" {
+								break
+							}
+							msg.WriteString(line)
+						}
+						mu.Lock()
+						received = append(received, msg.String())
+						mu.Unlock()
+						io.WriteString(conn, "250 queued\r\n")
+					case "QUIT":
+						io.WriteString(conn, "221 bye\r\n")
+						return
+					default:
+						io.WriteString(conn, "502 unsupported\r\n")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	addr, messages := fakeSMTPServer(t)
+	sink, err := NewEmailSink(EmailConfig{Addr: addr, From: "app@example.com", To: []string{"ops@example.com"}})
+	if err != nil {
+		t.Fatalf("NewEmailSink failed: %v", err)
+	}
+	sink.WriteEntry(&Entry{Level: WARN, Message: "ignored"})
+	for _, port := range []string{"5432", "5433", "5434"} {
+		sink.WriteEntry(&Entry{Level: ERROR, Message: "connection refused on port " + port})
+	}
+	sink.WriteEntry(&Entry{Level: ERROR, Message: "cache miss"})
+	sink.Hook(FATAL, "out of memory")
+	sink.Close()
+
+	got := messages()
+	if len(got) != 2 {
+		t.Fatalf("Expected an immediate mail and a digest, got %d mails", len(got))
+	}
+	var immediate, digest string
+	for _, msg := range got {
+		if strings.Contains(msg, "Subject: [loggo] FATAL: out of memory") {
+			immediate = msg
+		} else {
+			digest = msg
+		}
+	}
+	if immediate == "" {
+		t.Errorf("Expected immediate FATAL mail, got %q", got)
+	}
+	if !strings.Contains(digest, "Subject: [loggo] 4 entries") || !strings.Contains(digest, "3x ERROR    connection refused on port 5432") ||
+		!strings.Contains(digest, "1x ERROR    cache miss") || strings.Contains(digest, "ignored") {
+		t.Errorf("Unexpected digest %q", digest)
+	}
+	if stats := sink.Stats(); stats.Immediate != 1 || stats.Digests != 1 || stats.Errors != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDeadLetterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	dl, err := NewDeadLetterFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down := BatchSenderFunc(func(ctx context.Context, batch *Batch) error {
+		return errors.New("collector down")
+	})
+	sink := NewBatchSink(down, BatchConfig{MaxRetries: -1, FlushInterval: time.Hour, DeadLetter: dl})
+	sink.WriteEntry(&Entry{Time: time.Now(), Level: ERROR, Message: "lost", Fields: []Field{Str("tenant", "acme")}})
+	if err := sink.Close(); err == nil {
+		t.Fatal("Expected the final flush to fail")
+	}
+
+	// The closed sink now fails synchronously
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(sink)
+	logger.SetDeadLetter(dl)
+	logger.Info("after close")
+
+	if err := dl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := dl.Stats(); stats.Entries != 2 || stats.Errors != 0 {
+		t.Errorf("Expected 2 dead-lettered entries, got %+v", stats)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []*Entry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		entry, err := ParseJSON([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", data)
+	}
+	for i, want := range []struct{ message, sink, reason string }{
+		{"lost", "loggo.BatchSenderFunc", "collector down"},
+		{"after close", "*loggo.BatchSink", errSinkClosed.Error()},
+	} {
+		name, _ := entries[i].Field(DeadLetterSinkKey)
+		reason, _ := entries[i].Field(DeadLetterReasonKey)
+		if entries[i].Message != want.message || name.Str != want.sink || reason.Str != want.reason {
+			t.Errorf("Expected %+v, got %+v", want, entries[i])
+		}
+	}
+	if tenant, _ := entries[0].Field("tenant"); tenant.Str != "acme" {
+		t.Errorf("Expected the entry's fields to be kept, got %+v", entries[0].Fields)
+	}
+}
+
+func TestEscapeSink(t *testing.T) {
+	sink, raw := &memorySink{}, &memorySink{}
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(NewEscapeSink(sink, EscapeStripANSI))
+	logger.AddSink(raw)
+
+	logger.With("path", "/a\x1b]0;pwned\a").With("n", 1).Info("line\r\nforged")
+	entry := sink.entries[0]
+	if entry.Message != `line\r\nforged` {
+		t.Errorf("Expected escaped message, got %q", entry.Message)
+	}
+	if path, _ := entry.Field("path"); path.Str != "/a" {
+		t.Errorf("Expected escape sequences stripped from fields, got %q", path.Str)
+	}
+	if path, _ := raw.entries[0].Field("path"); path.Str != "/a\x1b]0;pwned\a" || raw.entries[0].Message != "line\r\nforged" {
+		t.Errorf("Expected other sinks to receive the entry unchanged, got %q", path.Str)
+	}
+}
+
+func TestEntryHashes(t *testing.T) {
+	logger := New()
+	logger.SetOutput(io.Discard)
+	first, second := &memorySink{}, &memorySink{}
+	logger.AddSink(first)
+	logger.AddSink(second)
+	logger.SetEntryHashes(true)
+
+	at := time.Date(2025, 4, 4, 12, 0, 0, 0, time.UTC)
+	logger.With("id", 1).InfoEvent().Time(at).Msg("charged")
+	logger.With("id", 1).InfoEvent().Time(at).Msg("charged")
+	logger.With("id", 2).InfoEvent().Time(at).Msg("charged")
+
+	hashes := func(sink *memorySink) []string {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		var out []string
+		for _, entry := range sink.entries {
+			f, ok := entry.Field(EntryHashKey)
+			if !ok {
+				t.Fatalf("Expected an %s field, got %v", EntryHashKey, entry.Fields)
+			}
+			if EntryHash(entry) != f.Str {
+				t.Errorf("Expected the field to match EntryHash, got %s", f.Str)
+			}
+			out = append(out, f.Str)
+		}
+		return out
+	}
+	got := hashes(first)
+	if !slices.Equal(got, hashes(second)) {
+		t.Errorf("Expected every sink to see the same hashes")
+	}
+	if len(got) != 3 || len(got[0]) != 32 || got[0] != got[1] || got[0] == got[2] {
+		t.Errorf("Expected equal hashes for equal content only, got %v", got)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFaultyWriter(&buf, FaultConfig{ErrorRate: 1})
+	if n, err := w.Write([]byte("line\n")); n != 0 || !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected an injected error, got %d, %v", n, err)
+	}
+	w = NewFaultyWriter(&buf, FaultConfig{PartialRate: 1})
+	if n, err := w.Write([]byte("abcdef")); n != 3 || err != io.ErrShortWrite || buf.String() != "abc" {
+		t.Errorf("Expected a partial write, got %d, %v, %q", n, err, buf.String())
+	}
+
+	// The same seed injects the same faults
+	outcomes := func() []bool {
+		w := NewFaultyWriter(io.Discard, FaultConfig{ErrorRate: 0.3, Seed: 42})
+		var failed []bool
+		for range 200 {
+			_, err := w.Write([]byte("x"))
+			failed = append(failed, err != nil)
+		}
+		if stats := w.Stats(); stats.Operations != 200 || stats.Errors < 30 || stats.Errors > 90 {
+			t.Errorf("Expected about 60 errors in 200 writes, got %+v", stats)
+		}
+		return failed
+	}
+	if !slices.Equal(outcomes(), outcomes()) {
+		t.Errorf("Expected reproducible faults for a fixed seed")
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	conn := NewFaultyConn(client, FaultConfig{ShortReadRate: 1})
+	go server.Write([]byte("ack-1234"))
+	p := make([]byte, 8)
+	if n, err := conn.Read(p); n != 4 || err != nil {
+		t.Errorf("Expected a short read of 4 bytes, got %d, %v", n, err)
+	}
+
+	sent := 0
+	sender := NewFaultySender(BatchSenderFunc(func(context.Context, *Batch) error {
+		sent++
+		return nil
+	}), FaultConfig{ErrorRate: 1, Latency: time.Millisecond})
+	start := time.Now()
+	if err := sender.Send(context.Background(), &Batch{}); !errors.Is(err, ErrInjectedFault) || sent != 0 {
+		t.Errorf("Expected the batch to fail unsent, got %v", err)
+	}
+	if time.Since(start) < time.Millisecond {
+		t.Errorf("Expected the configured latency")
+	}
+}
+
+func TestShutdownReport(t *testing.T) {
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(DEBUG)
+	sink := &memorySink{}
+	logger.AddSink(sink)
+	logger.AddHookSync(func(Level, string) error { return errors.New("unavailable") }, 0)
+	logger.SetSampler(keepTemplate("kept"))
+	logger.SetShutdownReport(true)
+
+	logger.InfoEvent().Msg("kept")
+	logger.InfoEvent().Msg("kept")
+	logger.ErrorEvent().Msg("kept")
+	logger.DebugEvent().Msg("sampled out")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sink.mu.Lock()
+	report := sink.entries[len(sink.entries)-1]
+	sink.mu.Unlock()
+	if report.Level != INFO || !strings.HasPrefix(report.Message, "session ended after ") ||
+		!strings.HasSuffix(report.Message, ": 3 records, 1 dropped, 1 errors") {
+		t.Errorf("Expected the report as the last entry, got %s %q", report.Level, report.Message)
+	}
+	want := map[string]int64{
+		ReportEntriesKey + ".debug": 0,
+		ReportEntriesKey + ".info":  2,
+		ReportEntriesKey + ".error": 1,
+		ReportSampledKey:            1,
+		ReportQuotaKey:              0,
+		ReportErrorsKey:             1,
+	}
+	for key, n := range want {
+		if f, ok := report.Field(key); !ok || f.Int != n {
+			t.Errorf("Expected %s=%d, got %v", key, n, f)
+		}
+	}
+	if f, ok := report.Field(ReportUptimeKey); !ok {
+		t.Errorf("Expected an %s field", ReportUptimeKey)
+	} else if _, err := time.ParseDuration(f.Str); err != nil {
+		t.Errorf("Expected a duration, got %q", f.Str)
+	}
+
+	// The report is written once
+	logger.Close()
+	if n := len(sink.messages()); n != 4 {
+		t.Errorf("Expected 4 entries, got %d", n)
+	}
+}
+
+func TestEventTypedFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetOutput(&buf)
+	sink := &memorySink{}
+	logger.AddSink(sink)
+
+	at := time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC)
+	logger.WarnEvent().
+		Str("user", "alice").
+		Int("attempts", 3).
+		Int64("account", 1<<40).
+		Float64("score", 0.25).
+		Bool("locked", true).
+		Err(errors.New("bad password")).
+		Dur("elapsed", 1500*time.Millisecond).
+		TimeField("last_seen", at).
+		Any("roles", []string{"admin"}).
+		Err(nil).
+		Msg("login failed")
+
+	if got, want := buf.String(), "login failed user=alice attempts=3 account=1099511627776 score=0.25 locked=true error=\"bad password\" elapsed=1.5s last_seen=2025-06-01T09:30:00Z roles=[admin]\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("Expected a line ending in %q, got %q", want, got)
+	}
+	entry := sink.entries[0]
+	for key, typ := range map[string]FieldType{"user": StringType, "attempts": IntType, "score": FloatType, "locked": BoolType, ErrorKey: StringType} {
+		if f, ok := entry.Field(key); !ok || f.Type != typ {
+			t.Errorf("Expected %s of type %d, got %+v", key, typ, f)
+		}
+	}
+	if n := len(entry.Fields); n != 9 {
+		t.Errorf("Expected 9 fields without the nil error, got %d", n)
+	}
+
+	// Typed methods are no-ops on filtered events
+	logger.DebugEvent().Str("user", "bob").Int("attempts", 1).Err(errors.New("ignored")).Msg("hidden")
+	if len(sink.messages()) != 1 {
+		t.Errorf("Expected the debug event to be filtered, got %v", sink.messages())
+	}
+}
+
+func TestWellKnownKeys(t *testing.T) {
+	logger := New()
+	logger.SetOutput(io.Discard)
+	sink := &memorySink{}
+	logger.AddSink(sink)
+
+	logger.InfoEvent().
+		RequestID("req-1").
+		TraceID("4bf92f3577b34da6a3ce929d0e0e4736").
+		SpanID("00f067aa0ba902b7").
+		UserID("u-42").
+		TenantID("acme").
+		SessionID("s-9").
+		Duration(1500*time.Millisecond).
+		Field(Str(ServiceKey, "billing"), Err(errors.New("timeout"))).
+		Msg("request handled")
+	logger.DebugEvent().RequestID("filtered").Field(UserID("u-1")).Msg("hidden")
+
+	if got := sink.messages(); len(got) != 1 {
+		t.Fatalf("Expected the debug event to be filtered, got %q", got)
+	}
+	want := map[string]string{
+		RequestIDKey: "req-1",
+		TraceIDKey:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanIDKey:    "00f067aa0ba902b7",
+		UserIDKey:    "u-42",
+		TenantIDKey:  "acme",
+		SessionIDKey: "s-9",
+		DurationKey:  "1.5s",
+		ServiceKey:   "billing",
+		ErrorKey:     "timeout",
+	}
+	entry := sink.entries[0]
+	for key, value := range want {
+		if f, ok := entry.Field(key); !ok || f.ValueString() != value {
+			t.Errorf("Expected %s=%q, got %+v", key, value, f)
+		}
+	}
+	if n := len(entry.Fields); n != len(want) {
+		t.Errorf("Expected %d fields, got %v", len(want), entry.Fields)
+	}
+}
+
+func TestRuntimeStats(t *testing.T) {
+	logger := New()
+	logger.SetOutput(io.Discard)
+	sink := &memorySink{}
+	logger.AddSink(sink)
+
+	logger.InfoEvent().RuntimeStats().Msg("health")
+	runtime.GC()
+	logger.With("k", "v").InfoEvent().RuntimeStats().Msg("health")
+	logger.DebugEvent().RuntimeStats().Msg("hidden")
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(sink.entries))
+	}
+	for i, entry := range sink.entries {
+		for _, key := range []string{"runtime.goroutines", "runtime.heap_inuse", "runtime.gc_pauses", "runtime.gc_pause"} {
+			if _, ok := entry.Field(key); !ok {
+				t.Errorf("Expected %s in entry %d", key, i)
+			}
+		}
+		if f, _ := entry.Field("runtime.goroutines"); f.Int < 1 {
+			t.Errorf("Expected at least one goroutine, got %d", f.Int)
+		}
+		if f, _ := entry.Field("runtime.heap_inuse"); f.Int <= 0 {
+			t.Errorf("Expected heap in use, got %d", f.Int)
+		}
+	}
+	// Pauses are counted since the previous call, shared by derived loggers
+	if f, _ := sink.entries[1].Field("runtime.gc_pauses"); f.Int < 1 {
+		t.Errorf("Expected the forced GC's pauses, got %d", f.Int)
+	}
+	if f, _ := sink.entries[1].Field("runtime.gc_pause"); f.ValueString() == "0s" {
+		t.Errorf("Expected a pause time, got %s", f.ValueString())
+	}
+}
+
+func TestEncodingCache(t *testing.T) {
+	entry := &Entry{Time: time.Unix(0, 0).UTC(), Level: INFO, Message: "first", Fields: []Field{Int("n", 1)}}
+	attachEncodingCache(entry)
+	first := string(AppendJSON(nil, entry))
+	logfmt := string(AppendLogfmt(nil, entry))
+
+	// Sinks must not modify entries; doing so shows the encodings are reused
+	entry.Message = "second"
+	if got := string(AppendJSON([]byte("> "), entry)); got != "> "+first {
+		t.Errorf("Expected the cached JSON encoding appended, got %q", got)
+	}
+	if got := string(AppendLogfmt(nil, entry)); got != logfmt {
+		t.Errorf("Expected the cached logfmt encoding, got %q", got)
+	}
+	if got := string(AppendJSONKeys(nil, entry, JSONKeys{Message: "message"})); !strings.Contains(got, `"message":"second"`) {
+		t.Errorf("Expected other keys to be encoded separately, got %q", got)
+	}
+	escaped := *entry
+	if got := string(AppendJSON(nil, &escaped)); !strings.Contains(got, `"msg":"second"`) {
+		t.Errorf("Expected copies of the entry to be encoded on their own, got %q", got)
+	}
+	detachEncodingCache(entry)
+	if got := string(AppendJSON(nil, entry)); !strings.Contains(got, `"msg":"second"`) {
+		t.Errorf("Expected no caching once detached, got %q", got)
+	}
+
+	// Sinks sharing a format write the same lines
+	logger := New()
+	logger.SetOutput(io.Discard)
+	var a, b bytes.Buffer
+	logger.Tee(&a, TeeOptions{JSON: true})
+	logger.Tee(&b, TeeOptions{JSON: true})
+	logger.With("user", 7).Info("one")
+	logger.Warn("two")
+	if a.String() != b.String() || strings.Count(a.String(), "\n") != 2 || !strings.Contains(a.String(), `"msg":"two"`) {
+		t.Errorf("Expected identical JSON lines, got %q and %q", a.String(), b.String())
+	}
+	logger.Close()
+}
@@ -0,0 +1,143 @@
+package loggo
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SoakConfig configures Soak. Zero values select the defaults.
+type SoakConfig struct {
+	Duration   time.Duration // Length of the run (default 1m)
+	Interval   time.Duration // Time between samples (default Duration/20)
+	Goroutines int           // Goroutines logging concurrently (default GOMAXPROCS)
+
+	// Log writes one record; n counts the records of the goroutine. The
+	// default logs an INFO event with two fields.
+	Log func(logger *Logger, n int)
+
+	// MinHeapGrowth is the growth of the live heap over the run, in
+	// bytes, above which steady growth counts as a leak (default 1 MiB).
+	// It keeps slow warm-up of pools and caches from failing a run.
+	MinHeapGrowth uint64
+
+	// MinGoroutineGrowth is the growth in goroutines above which steady
+	// growth counts as a leak (default 10).
+	MinGoroutineGrowth int
+}
+
+// SoakSample is a measurement taken during a soak run, after a garbage
+// collection.
+type SoakSample struct {
+	Elapsed    time.Duration
+	Records    int64  // Records logged so far
+	HeapAlloc  uint64 // Bytes of live heap objects
+	Goroutines int
+}
+
+// SoakReport is the outcome of a soak run.
+type SoakReport struct {
+	Samples []SoakSample
+	Records int64 // Records logged
+}
+
+// Soak logs continuously to logger for config.Duration, sampling the live
+// heap and the number of goroutines, to catch leaks in buffer pools,
+// caches and worker lifecycles that short tests miss. It returns an error
+// if either grew in every sample after the first quarter of the run, by
+// more than the configured minimum. The run stops early, without error,
+// when ctx is done.
+//
+// Run it from a test with a dedicated logger, e.g. writing to io.Discard:
+//
+//	report, err := loggo.Soak(ctx, logger, loggo.SoakConfig{Duration: 10 * time.Minute})
+func Soak(ctx context.Context, logger *Logger, config SoakConfig) (SoakReport, error) {
+	if config.Duration <= 0 {
+		config.Duration = time.Minute
+	}
+	if config.Interval <= 0 {
+		config.Interval = config.Duration / 20
+	}
+	if config.Goroutines <= 0 {
+		config.Goroutines = runtime.GOMAXPROCS(0)
+	}
+	if config.Log == nil {
+		config.Log = func(logger *Logger, n int) {
+			logger.InfoEvent().LazyStr("worker", func() string { return "soak" }).Msgf("soak record %d of %s", n, "run")
+		}
+	}
+	if config.MinHeapGrowth == 0 {
+		config.MinHeapGrowth = 1 << 20
+	}
+	if config.MinGoroutineGrowth == 0 {
+		config.MinGoroutineGrowth = 10
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+
+	var records atomic.Int64
+	var wg sync.WaitGroup
+	for range config.Goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ctx.Err() == nil; n++ {
+				config.Log(logger, n)
+				records.Add(1)
+			}
+		}()
+	}
+
+	var report SoakReport
+	start := time.Now()
+	sample := func() {
+		runtime.GC()
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		report.Samples = append(report.Samples, SoakSample{
+			Elapsed:    time.Since(start),
+			Records:    records.Load(),
+			HeapAlloc:  mem.HeapAlloc,
+			Goroutines: runtime.NumGoroutine(),
+		})
+	}
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+			sample()
+		case <-ctx.Done():
+			done = true
+		}
+	}
+	wg.Wait()
+	report.Records = records.Load()
+	return report, report.leak(config)
+}
+
+// leak reports steady growth over the samples after the first quarter
+func (r SoakReport) leak(config SoakConfig) error {
+	samples := r.Samples[len(r.Samples)/4:]
+	if len(samples) < 3 {
+		return nil
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	heapGrows, goroutinesGrow := true, true
+	for i := 1; i < len(samples); i++ {
+		heapGrows = heapGrows && samples[i].HeapAlloc > samples[i-1].HeapAlloc
+		goroutinesGrow = goroutinesGrow && samples[i].Goroutines > samples[i-1].Goroutines
+	}
+	switch {
+	case heapGrows && last.HeapAlloc-first.HeapAlloc > config.MinHeapGrowth:
+		return fmt.Errorf("loggo: live heap grew in every sample, from %d to %d bytes over %d records",
+			first.HeapAlloc, last.HeapAlloc, last.Records-first.Records)
+	case goroutinesGrow && last.Goroutines-first.Goroutines > config.MinGoroutineGrowth:
+		return fmt.Errorf("loggo: goroutines grew in every sample, from %d to %d", first.Goroutines, last.Goroutines)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package loggo
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// SocketFraming selects how entries are delimited on a socket.
+type SocketFraming int
+
+// Supported framings.
+const (
+	FrameLength  SocketFraming = iota // 4-byte big-endian length prefix
+	FrameNewline                      // Entry followed by '\n'
+)
+
+// SocketConfig configures a SocketSink. Zero values select the defaults.
+type SocketConfig struct {
+	// Path of the collector's socket. A leading '@' selects the Linux
+	// abstract namespace, which needs no file on disk and cannot go stale.
+	Path string
+
+	Network      string        // "unixgram" (default) or "unix" for a stream socket
+	Framing      SocketFraming // Delimiting of entries (default FrameLength)
+	Proto        bool          // Encode entries with AppendProto instead of JSON
+	Keys         JSONKeys      // JSON keys for time, level and message (default DefaultJSONKeys)
+	WriteTimeout time.Duration // Give up on an entry if the collector is not reading (default 100ms)
+}
+
+// SocketStats reports the activity of a SocketSink.
+type SocketStats struct {
+	Sent     int64 // Entries written to the socket
+	Dropped  int64 // Entries lost to write errors or timeouts
+	Connects int64 // Connections established, including reconnects
+}
+
+// SocketSink writes encoded entries to a local Unix socket, for sidecar
+// collectors such as Vector or Fluent Bit. Compared to tailing a file this
+// has no polling latency and no rotation races. The connection is opened
+// lazily and re-established after errors, so the collector may start
+// after the application or restart while it runs; entries written while
+// it is unavailable are dropped rather than blocking the logger.
+type SocketSink struct {
+	config SocketConfig
+	mu     sync.Mutex
+	conn   net.Conn
+	buf    []byte
+	stats  SocketStats
+	closed bool
+}
+
+// NewSocketSink creates a sink writing to the socket at config.Path.
+// It does not fail if the collector is not listening yet.
+func NewSocketSink(config SocketConfig) (*SocketSink, error) {
+	if config.Path == "" {
+		return nil, errors.New("loggo: socket sink requires a path")
+	}
+	switch config.Network {
+	case "":
+		config.Network = "unixgram"
+	case "unixgram", "unix":
+	default:
+		return nil, errors.New("loggo: socket sink network must be unixgram or unix")
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 100 * time.Millisecond
+	}
+	s := &SocketSink{config: config}
+	s.mu.Lock()
+	s.connect()
+	s.mu.Unlock()
+	return s, nil
+}
+
+// connect dials the collector; s.mu must be held
+func (s *SocketSink) connect() error {
+	conn, err := net.DialTimeout(s.config.Network, s.config.Path, s.config.WriteTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.stats.Connects++
+	return nil
+}
+
+// WriteEntry encodes and frames the entry and writes it in a single call,
+// so each datagram carries exactly one entry.
+func (s *SocketSink) WriteEntry(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSinkClosed
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			s.stats.Dropped++
+			return err
+		}
+	}
+
+	buf := s.buf[:0]
+	if s.config.Framing == FrameLength {
+		buf = append(buf, 0, 0, 0, 0)
+	}
+	if s.config.Proto {
+		buf = AppendProto(buf, entry)
+	} else {
+		buf = AppendJSONKeys(buf, entry, s.config.Keys)
+	}
+	if s.config.Framing == FrameLength {
+		binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	} else {
+		buf = append(buf, '\n')
+	}
+	s.buf = buf
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	if _, err := s.conn.Write(buf); err != nil {
+		// Reconnect on the next entry; a stream socket may hold a partial frame
+		s.conn.Close()
+		s.conn = nil
+		s.stats.Dropped++
+		return err
+	}
+	s.stats.Sent++
+	return nil
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (s *SocketSink) Stats() SocketStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close closes the connection.
+func (s *SocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,142 @@
+package loggo
+
+import (
+	"sync"
+	"time"
+)
+
+// spikeKey marks synthetic spike entries so they are not counted again
+const spikeKey = "spike.fingerprint"
+
+// SpikeConfig configures a SpikeDetector. Zero values select the defaults.
+type SpikeConfig struct {
+	MinLevel  Level         // Lowest level counted (default ERROR)
+	Threshold int           // Entries per Window with the same fingerprint that make a spike (default 50)
+	Window    time.Duration // Counting window (default 1m)
+	Cooldown  time.Duration // Minimum time between reports for the same fingerprint (default 5m)
+	OnSpike   func(Spike)   // Called for every detected spike
+}
+
+// Spike describes a detected burst of similar entries.
+type Spike struct {
+	Fingerprint string        // Fingerprint of the entries
+	Example     string        // Message of the entry that crossed the threshold
+	Level       Level         // Level of that entry
+	Count       int           // Entries counted in the window
+	Window      time.Duration // Counting window
+	Time        time.Time     // When the threshold was crossed
+}
+
+// spikeCounter counts entries for one fingerprint in the current window
+type spikeCounter struct {
+	start    time.Time
+	count    int
+	reported time.Time
+}
+
+// SpikeDetector is a sink that tracks the rate of errors per fingerprint
+// and reports when one crosses a threshold, giving basic self-monitoring
+// without external tooling. Each spike is logged as a synthetic CRITICAL
+// "error spike detected" entry on the logger passed to NewSpikeDetector
+// (if any) and passed to OnSpike.
+type SpikeDetector struct {
+	logger   *Logger
+	config   SpikeConfig
+	mu       sync.Mutex
+	counters map[string]*spikeCounter
+}
+
+// NewSpikeDetector creates a detector reporting spikes on logger, which
+// may be nil to only use OnSpike. Register it with logger.AddSink.
+func NewSpikeDetector(logger *Logger, config SpikeConfig) *SpikeDetector {
+	if config.MinLevel == DEBUG {
+		config.MinLevel = ERROR
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 50
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 5 * time.Minute
+	}
+	return &SpikeDetector{logger: logger, config: config, counters: make(map[string]*spikeCounter)}
+}
+
+// WriteEntry counts the entry and reports a spike when its fingerprint
+// crosses the threshold.
+func (d *SpikeDetector) WriteEntry(entry *Entry) error {
+	if entry.Level < d.config.MinLevel {
+		return nil
+	}
+	if _, ok := entry.Field(spikeKey); ok {
+		return nil
+	}
+	fingerprint := entryFingerprint(entry)
+	now := time.Now()
+
+	d.mu.Lock()
+	counter, ok := d.counters[fingerprint]
+	if !ok {
+		if len(d.counters) >= 1024 {
+			d.purge(now)
+		}
+		counter = &spikeCounter{start: now}
+		d.counters[fingerprint] = counter
+	}
+	if now.Sub(counter.start) >= d.config.Window {
+		counter.start, counter.count = now, 0
+	}
+	counter.count++
+	spiked := counter.count == d.config.Threshold &&
+		(counter.reported.IsZero() || now.Sub(counter.reported) >= d.config.Cooldown)
+	if spiked {
+		counter.reported = now
+	}
+	d.mu.Unlock()
+
+	if spiked {
+		d.report(Spike{
+			Fingerprint: fingerprint,
+			Example:     entry.Message,
+			Level:       entry.Level,
+			Count:       d.config.Threshold,
+			Window:      d.config.Window,
+			Time:        now,
+		})
+	}
+	return nil
+}
+
+// purge drops counters idle for longer than a window and a cooldown; d.mu must be held
+func (d *SpikeDetector) purge(now time.Time) {
+	for fingerprint, counter := range d.counters {
+		if now.Sub(counter.start) >= d.config.Window && now.Sub(counter.reported) >= d.config.Cooldown {
+			delete(d.counters, fingerprint)
+		}
+	}
+}
+
+// report logs the synthetic entry and invokes the callback
+func (d *SpikeDetector) report(spike Spike) {
+	if d.logger != nil {
+		if e := d.logger.CriticalEvent(); e != nil {
+			e.internal = true
+			e.fields = append(e.fields,
+				Str(spikeKey, spike.Fingerprint),
+				Int("spike.count", spike.Count),
+				Str("spike.window", spike.Window.String()),
+			)
+			e.Msg("error spike detected: " + spike.Example)
+		}
+	}
+	if d.config.OnSpike != nil {
+		d.config.OnSpike(spike)
+	}
+}
+
+// Close does nothing; the detector holds no resources.
+func (d *SpikeDetector) Close() error {
+	return nil
+}
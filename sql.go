@@ -0,0 +1,163 @@
+package loggo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields added by SQL and SQLRows, named after the OpenTelemetry semantic
+// conventions where they exist. Arguments are added as SQLParameterPrefix
+// followed by their position, starting at 1.
+const (
+	SQLQueryKey        = "db.query.text"
+	SQLParameterPrefix = "db.query.parameter."
+	SQLDurationKey     = "db.query.duration_ms" // Duration in milliseconds
+	SQLRowsKey         = "db.response.returned_rows"
+)
+
+// SQL adds a database query with its arguments and duration. Whitespace
+// and comments in the query are collapsed so that the same statement is
+// always logged the same way. Unless scrubbing was disabled with
+// SetSQLScrubbing, string and numeric literals and bind parameters ($1,
+// :name, @p1) are replaced with "?" and the arguments are not logged, so
+// values such as passwords or personal data never reach the logs.
+func (e *Event) SQL(query string, args []any, duration time.Duration) *Event {
+	if e == nil {
+		return nil
+	}
+	scrub := !e.logger.sqlVerbatim.Load()
+	e.fields = append(e.fields,
+		Str(SQLQueryKey, normalizeSQL(query, scrub)),
+		Float64(SQLDurationKey, float64(duration)/float64(time.Millisecond)),
+	)
+	if !scrub {
+		for i, arg := range args {
+			e.fields = append(e.fields, Any(SQLParameterPrefix+strconv.Itoa(i+1), arg))
+		}
+	}
+	return e
+}
+
+// SQLRows adds the number of rows returned or affected by a query.
+func (e *Event) SQLRows(n int64) *Event {
+	if e == nil {
+		return nil
+	}
+	e.fields = append(e.fields, Int64(SQLRowsKey, n))
+	return e
+}
+
+// SetSQLScrubbing sets whether SQL replaces literals and bind parameters
+// with placeholders and omits arguments. It is enabled by default; disable
+// it only where queries are known to carry no sensitive values.
+func (l *Logger) SetSQLScrubbing(enabled bool) {
+	l.sqlVerbatim.Store(!enabled)
+}
+
+// normalizeSQL collapses whitespace and comments in query and, if scrub
+// is set, replaces literals and bind parameters with "?"
+func normalizeSQL(query string, scrub bool) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false
+	// write adds a token, preceded by a single space if any separated it
+	// from the previous one
+	write := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			space = true
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			space = true
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(query, i)
+			if scrub && c == '\'' {
+				write("?")
+			} else {
+				write(query[i:end])
+			}
+			i = end
+		case scrub && isSQLBind(query, i):
+			end := i + 1
+			for end < len(query) && isIdentByte(query[end]) {
+				end++
+			}
+			write("?")
+			i = end
+		case scrub && c >= '0' && c <= '9' && (i == 0 || !isIdentByte(query[i-1])):
+			end := i + 1
+			for end < len(query) && (isIdentByte(query[end]) || query[end] == '.' ||
+				((query[end] == '+' || query[end] == '-') && (query[end-1] == 'e' || query[end-1] == 'E'))) {
+				end++
+			}
+			write("?")
+			i = end
+		default:
+			end := i + 1
+			for end < len(query) && isIdentByte(query[end]) && isIdentByte(c) {
+				end++
+			}
+			write(query[i:end])
+			i = end
+		}
+	}
+	return b.String()
+}
+
+// quotedEnd returns the index after the quoted string or identifier
+// starting at i, where doubled quotes stand for one
+func quotedEnd(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != quote {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(query)
+}
+
+// isSQLBind reports whether a bind parameter ($1, :name or @name) starts
+// at i; casts (::) and system variables (@@) are not binds
+func isSQLBind(query string, i int) bool {
+	if i+1 >= len(query) || !isIdentByte(query[i+1]) {
+		return false
+	}
+	switch query[i] {
+	case '$':
+		return query[i+1] >= '0' && query[i+1] <= '9'
+	case ':', '@':
+		return i == 0 || (query[i-1] != query[i] && !isIdentByte(query[i-1]))
+	}
+	return false
+}
+
+// isIdentByte reports whether c can be part of an identifier or number
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,77 @@
+package loggo
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// StackKey is the key of the stack trace field added by Event.Stack and
+// SetStackTraces, one "function file:line" frame per line.
+const StackKey = "stack"
+
+// SetStackTraces captures a stack trace for every record at one of the
+// levels, e.g. SetStackTraces(ERROR, CRITICAL, FATAL, PANIC) so failures
+// say where they came from. Calling it without levels turns automatic
+// stack traces off, the default. Capturing a trace costs a stack walk per
+// record written at those levels.
+func (l *Logger) SetStackTraces(levels ...Level) {
+	var mask uint32
+	for _, level := range levels {
+		if level >= DEBUG && level <= PANIC {
+			mask |= 1 << level
+		}
+	}
+	l.stackLevels.Store(mask)
+}
+
+// Stack adds the stack trace of the call in a StackKey field. Text lines
+// show it as an indented block below the line; JSON lines, formatters
+// and sinks get it as a field.
+func (e *Event) Stack() *Event {
+	if e == nil || e.stackField > 0 {
+		return e
+	}
+	stack := captureStack()
+	e.fields = append(e.fields, LazyStr(StackKey, func() string { return formatStack(stack) }))
+	e.stackField = len(e.fields)
+	return e
+}
+
+// addStack adds a stack trace if the level asks for one
+func (e *Event) addStack() {
+	if e.level >= DEBUG && e.level <= PANIC && e.logger.stackLevels.Load()&(1<<e.level) != 0 {
+		e.Stack()
+	}
+}
+
+// captureStack returns the stack of its caller's caller; formatStack
+// leaves out the frames of this package
+func captureStack() []uintptr {
+	var pcs [maxStackFrames]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return slices.Clone(pcs[:n])
+}
+
+// textFields returns the event's fields written inline in text lines,
+// which leave out the stack trace
+func (e *Event) textFields() []Field {
+	if e.stackField == 0 {
+		return e.fields
+	}
+	return slices.Delete(slices.Clone(e.fields), e.stackField-1, e.stackField)
+}
+
+// appendStackBlock appends the stack trace as lines indented by a tab,
+// after the text line
+func (e *Event) appendStackBlock(buf []byte) []byte {
+	if e.stackField == 0 {
+		return buf
+	}
+	for line := range strings.Lines(e.fields[e.stackField-1].ValueString()) {
+		buf = append(buf, '\t')
+		buf = append(buf, strings.TrimSuffix(line, "\n")...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
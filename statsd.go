@@ -0,0 +1,223 @@
+package loggo
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MetricType is the kind of statsd metric emitted by a StatsDRule.
+type MetricType int
+
+// Supported metric types.
+const (
+	StatsDCounter MetricType = iota // Incremented by 1, or by ValueField if set ("c")
+	StatsDTimer                     // Timing in milliseconds read from ValueField ("ms")
+	StatsDGauge                     // Value read from ValueField ("g")
+)
+
+// statsdSuffixes are the wire types of the metric types
+var statsdSuffixes = [...]string{"|c", "|ms", "|g"}
+
+// maxStatsDPacket keeps packets within a typical Ethernet MTU
+const maxStatsDPacket = 1432
+
+// StatsDRule turns matching entries into a metric.
+type StatsDRule struct {
+	Filter     EntryFilter // Entries the rule applies to
+	Metric     string      // Metric name, after the configured prefix
+	Type       MetricType  // Counter, timer or gauge
+	ValueField string      // Numeric field holding the value; entries without it are skipped for timers and gauges
+	LevelTag   bool        // Tag the metric with "level:<level>"
+	TagFields  []string    // Fields copied into tags as "key:value" when present
+	Tags       []string    // Constant tags, e.g. "team:payments"
+	SampleRate float64     // Fraction of entries emitted, in (0, 1] (default 1)
+}
+
+// StatsDConfig configures a StatsDSink.
+type StatsDConfig struct {
+	Address   string       // statsd agent address (default "127.0.0.1:8125")
+	Prefix    string       // Prepended to every metric name, e.g. "app."
+	Tags      []string     // Tags added to every metric
+	DogStatsD bool         // Send tags using the DogStatsD "|#tag" extension
+	Rules     []StatsDRule // Rules evaluated for every entry
+}
+
+// StatsDStats reports the activity of a StatsDSink.
+type StatsDStats struct {
+	Metrics int64 // Metric lines emitted
+	Packets int64 // UDP packets sent
+	Errors  int64 // Failed sends
+}
+
+// StatsDSink converts entries matching its rules into statsd metrics sent
+// over UDP, e.g. counting every ERROR as app.errors tagged by level.
+// Like statsd clients, it is fire-and-forget: send errors are counted
+// in Stats but never returned, so an absent agent does not disrupt logging.
+type StatsDSink struct {
+	config StatsDConfig
+	conn   net.Conn
+	mu     sync.Mutex
+	buf    []byte
+	stats  StatsDStats
+	rand   *randSource // Source of the logger; nil for the global one
+}
+
+// NewStatsDSink creates a sink sending to the configured statsd agent.
+func NewStatsDSink(config StatsDConfig) (*StatsDSink, error) {
+	if config.Address == "" {
+		config.Address = "127.0.0.1:8125"
+	}
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsDSink{config: config, conn: conn}, nil
+}
+
+// setRand sets the source of the sampling decisions
+func (s *StatsDSink) setRand(r *randSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rand = r
+}
+
+// WriteEntry emits a metric for every rule matching the entry.
+func (s *StatsDSink) WriteEntry(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	packet := s.buf[:0]
+	for i := range s.config.Rules {
+		rule := &s.config.Rules[i]
+		if !rule.Filter.Match(entry) {
+			continue
+		}
+		if rule.SampleRate > 0 && rule.SampleRate < 1 && s.rand.Float64() >= rule.SampleRate {
+			continue
+		}
+		line, ok := s.appendMetric(nil, rule, entry)
+		if !ok {
+			continue
+		}
+		if len(packet) > 0 && len(packet)+1+len(line) > maxStatsDPacket {
+			s.send(packet)
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+		s.stats.Metrics++
+	}
+	if len(packet) > 0 {
+		s.send(packet)
+	}
+	s.buf = packet
+	return nil
+}
+
+// send writes a packet; s.mu must be held
+func (s *StatsDSink) send(packet []byte) {
+	if _, err := s.conn.Write(packet); err != nil {
+		s.stats.Errors++
+		return
+	}
+	s.stats.Packets++
+}
+
+// appendMetric formats "name:value|type|@rate|#tags" for a rule
+func (s *StatsDSink) appendMetric(buf []byte, rule *StatsDRule, entry *Entry) ([]byte, bool) {
+	buf = appendStatsDName(buf, s.config.Prefix+rule.Metric)
+	buf = append(buf, ':')
+
+	if rule.ValueField == "" {
+		if rule.Type != StatsDCounter {
+			return nil, false
+		}
+		buf = append(buf, '1')
+	} else {
+		field, ok := entry.Field(rule.ValueField)
+		switch {
+		case ok && field.Type == IntType:
+			buf = appendInt(buf, field.Int)
+		case ok && field.Type == FloatType:
+			buf = strconv.AppendFloat(buf, field.Float(), 'f', -1, 64)
+		default:
+			return nil, false
+		}
+	}
+	buf = append(buf, statsdSuffixes[rule.Type]...)
+	if rule.SampleRate > 0 && rule.SampleRate < 1 {
+		buf = append(buf, "|@"...)
+		buf = strconv.AppendFloat(buf, rule.SampleRate, 'f', -1, 64)
+	}
+
+	if !s.config.DogStatsD {
+		return buf, true
+	}
+	n := len(buf)
+	buf = append(buf, "|#"...)
+	sep := func() {
+		if len(buf) > n+2 {
+			buf = append(buf, ',')
+		}
+	}
+	for _, tag := range s.config.Tags {
+		sep()
+		buf = appendStatsDTag(buf, tag)
+	}
+	for _, tag := range rule.Tags {
+		sep()
+		buf = appendStatsDTag(buf, tag)
+	}
+	if rule.LevelTag {
+		sep()
+		buf = append(buf, "level:"...)
+		buf = append(buf, jsonLevelStrings[entry.Level]...)
+	}
+	for _, key := range rule.TagFields {
+		if field, ok := entry.Field(key); ok {
+			sep()
+			buf = appendStatsDTag(buf, key+":"+field.ValueString())
+		}
+	}
+	if len(buf) == n+2 {
+		buf = buf[:n]
+	}
+	return buf, true
+}
+
+// appendStatsDName appends a metric name, replacing protocol characters
+func appendStatsDName(buf []byte, name string) []byte {
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case ':', '|', '@', '#', ',', '\n', ' ':
+			buf = append(buf, '_')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// statsdTagReplacer removes characters that would end a tag
+var statsdTagReplacer = strings.NewReplacer("|", "_", ",", "_", "\n", "_")
+
+// appendStatsDTag appends a tag, replacing characters that end a tag
+func appendStatsDTag(buf []byte, tag string) []byte {
+	return append(buf, statsdTagReplacer.Replace(tag)...)
+}
+
+// Stats returns a snapshot of the sink's counters.
+func (s *StatsDSink) Stats() StatsDStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close closes the UDP socket.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
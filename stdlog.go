@@ -0,0 +1,87 @@
+package loggo
+
+import (
+	"bytes"
+	"log"
+)
+
+// maxLevelTag is the length of the longest level tag Write recognizes,
+// "[CRITICAL]"
+const maxLevelTag = len("[CRITICAL]")
+
+// SetWriteLevel sets the level of the records written with Write that do
+// not start with a level tag; the default is INFO.
+func (l *Logger) SetWriteLevel(level Level) {
+	l.writeLevel.Store(int32(level))
+}
+
+// Write makes the logger an io.Writer for code that prints instead of
+// logging, e.g. a log.Logger or http.Server.ErrorLog. Each call is one
+// record, without its trailing newline, as log.Logger writes one message
+// per call; use NewLineWriter for streams split across calls. A message
+// starting with a level tag such as "[WARN] " or "error: " is logged at
+// that level with the tag removed, and others at the level set with
+// SetWriteLevel. Tags above CRITICAL are logged as CRITICAL, so text
+// from a dependency cannot exit or panic the process.
+func (l *Logger) Write(p []byte) (int, error) {
+	l.writeMessage(Level(l.writeLevel.Load()), p)
+	return len(p), nil
+}
+
+// StdLogger returns a log.Logger writing to l, for legacy code and
+// dependencies such as http.Server.ErrorLog. Messages are logged at level
+// unless they start with a level tag, as with Write. The log.Logger adds
+// no prefix or timestamp of its own; records carry the logger's.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(stdWriter{logger: l, level: level}, "", 0)
+}
+
+// stdWriter logs each write at level, as Logger.Write
+type stdWriter struct {
+	logger *Logger
+	level  Level
+}
+
+// Write logs p as one record
+func (w stdWriter) Write(p []byte) (int, error) {
+	w.logger.writeMessage(w.level, p)
+	return len(p), nil
+}
+
+// writeMessage logs p as one record at level, or at the level of its tag
+func (l *Logger) writeMessage(level Level, p []byte) {
+	p = bytes.TrimRight(p, "\r\n")
+	if tagged, rest, ok := parseLevelTag(p); ok {
+		level, p = min(tagged, CRITICAL), rest
+	}
+	if e := l.newEvent(level); e != nil {
+		e.Msg(string(p))
+	}
+}
+
+// parseLevelTag recognizes a leading "[LEVEL]" or "LEVEL:" tag, in any
+// case, and returns its level and the message after it
+func parseLevelTag(p []byte) (Level, []byte, bool) {
+	var name, rest []byte
+	if len(p) > 0 && p[0] == '[' {
+		end := bytes.IndexByte(p[:min(len(p), maxLevelTag)], ']')
+		if end < 0 {
+			return 0, p, false
+		}
+		name, rest = p[1:end], p[end+1:]
+	} else {
+		end := bytes.IndexByte(p[:min(len(p), maxLevelTag)], ':')
+		if end < 0 {
+			return 0, p, false
+		}
+		name, rest = p[:end], p[end+1:]
+	}
+	if len(name) == 0 || bytes.ContainsAny(name, " \t") {
+		return 0, p, false
+	}
+	level, err := ParseLevel(string(name))
+	if err != nil {
+		return 0, p, false
+	}
+	return level, bytes.TrimLeft(rest, " \t"), true
+}
@@ -0,0 +1,219 @@
+package loggo
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Size of the stress tests; raise them to hunt races, e.g.
+//
+//	go test -race -run Stress -stress.goroutines=64 -stress.iterations=10000
+var (
+	stressGoroutines = flag.Int("stress.goroutines", 8, "goroutines per role in stress tests")
+	stressIterations = flag.Int("stress.iterations", 200, "iterations per goroutine in stress tests")
+	stressSoak       = flag.Duration("stress.soak", 500*time.Millisecond, "duration of the soak test, e.g. 10m to hunt leaks")
+)
+
+// lineCounter counts the lines written to it
+type lineCounter struct {
+	lines atomic.Int64
+}
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	c.lines.Add(int64(bytes.Count(p, []byte{'\n'})))
+	return len(p), nil
+}
+
+// discardSink accepts entries without keeping them
+type discardSink struct{}
+
+func (discardSink) WriteEntry(*Entry) error { return nil }
+func (discardSink) Close() error            { return nil }
+
+// stress runs each role in *stressGoroutines goroutines, *stressIterations
+// times, all starting together
+func stress(roles ...func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, role := range roles {
+		for range *stressGoroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for i := range *stressIterations {
+					role(i)
+					// Interleave the roles even with few CPUs
+					runtime.Gosched()
+				}
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestStressConfiguration(t *testing.T) {
+	var out1, out2 lineCounter
+	sink := &memorySink{}
+	logger := New()
+	logger.SetOutput(&out1)
+	logger.AddSink(sink)
+	var hookCalls atomic.Int64
+	hook := func(Level, string) error {
+		hookCalls.Add(1)
+		return nil
+	}
+	formats := []string{DefaultTimeFormat, "2006-01-02T15:04:05Z07:00", "15:04:05.000000"}
+
+	stress(
+		func(i int) { logger.Infof("request %d handled", i) },
+		func(i int) { logger.With("i", i).With("user", "alice").Warn("bound fields") },
+		func(i int) { logger.ErrorEvent().Stringer("level", Level(i%7)).Msg("event") },
+		func(i int) { logger.SetLevel(Level(i % 3)) },
+		func(i int) {
+			if i%10 == 0 {
+				logger.AddHook(hook, i)
+			}
+		},
+		func(i int) {
+			if i%2 == 0 {
+				logger.SetOutput(&out1)
+			} else {
+				logger.SetOutputs(&out1, &out2)
+			}
+		},
+		func(i int) { logger.SetTimeFormat(formats[i%len(formats)]) },
+		func(i int) {
+			if i%20 == 0 {
+				logger.DebugInfo()
+				logger.Flush()
+			}
+		},
+		func(i int) {
+			if i%25 == 0 {
+				logger.SetAsync(4<<(i%3), DropPolicy(i%3))
+			}
+		},
+		// Features reading their settings while logging
+		func(i int) {
+			logger.WarnEvent().
+				Blob("payload", []byte("0123456789abcdef"), BlobHex).
+				SQL("SELECT name FROM users WHERE id = 42", []any{42}, time.Millisecond).
+				Proto("request", &testProto{text: "id: 42"}).
+				Msgf("slow query %d \xff\x1b[31m", i)
+		},
+		func(i int) {
+			on := i%2 == 0
+			logger.SetFingerprints(on)
+			logger.SetEntryHashes(on)
+			logger.SetProbes(on)
+			logger.SetCrashMirror(on)
+			logger.SetSQLScrubbing(on)
+			logger.SetBlobPaging(on)
+			logger.SetBlobLimit(i % 12)
+			logger.SetProtoLimit(i % 8)
+			logger.SetEscapePolicy(EscapePolicy(i % 3))
+			logger.SetUTF8Policy(UTF8Policy(i % 3))
+			logger.SetColorMode(ColorMode(i % 3))
+			logger.SetColorScope(ColorScope(i % 3))
+			if i%10 == 0 {
+				logger.SetRuntimeEnvironment(on)
+				logger.SetProtoMarshaler(func(msg ProtoMessage) ([]byte, error) { return []byte(msg.String()), nil })
+			}
+		},
+	)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Expected no close error, got %v", err)
+	}
+
+	if out1.lines.Load() == 0 || len(sink.messages()) == 0 {
+		t.Errorf("Expected records to be written, got %d lines and %d entries", out1.lines.Load(), len(sink.messages()))
+	}
+	if hookCalls.Load() == 0 {
+		t.Errorf("Expected hooks added concurrently to run")
+	}
+}
+
+func TestStressClose(t *testing.T) {
+	var out lineCounter
+	logger := New()
+	logger.SetOutput(&out)
+	logger.AddHook(func(Level, string) error { return nil }, 0)
+	logger.AddSink(&memorySink{})
+
+	var closed sync.Once
+	stress(
+		func(i int) { logger.Infof("request %d", i) },
+		func(i int) { logger.ErrorEvent().Msg("urgent") },
+		func(i int) {
+			if i == *stressIterations/2 {
+				closed.Do(func() { logger.Close() })
+			}
+		},
+	)
+	// Logging after Close must not panic or block
+	logger.Info("after close")
+	if out.lines.Load() == 0 {
+		t.Errorf("Expected records to be written")
+	}
+}
+
+func TestSoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test in short mode")
+	}
+	logger := New()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(discardSink{})
+	logger.AddHook(func(Level, string) error { return nil }, 0)
+	defer logger.Close()
+
+	report, err := Soak(context.Background(), logger, SoakConfig{
+		Duration: *stressSoak,
+		Log: func(logger *Logger, n int) {
+			logger.With("n", n).InfoEvent().Msgf("soak %d", n)
+			if n%500 == 0 {
+				// Worker pool lifecycle of short-lived loggers
+				child := New()
+				child.SetOutput(io.Discard)
+				child.Info("short-lived")
+				child.Close()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("%v\n%+v", err, report.Samples)
+	}
+	if report.Records == 0 || len(report.Samples) == 0 {
+		t.Errorf("Expected records and samples, got %+v", report)
+	}
+}
+
+func TestSoakDetectsLeak(t *testing.T) {
+	var mu sync.Mutex
+	var leaked [][]byte
+	report, err := Soak(context.Background(), New(), SoakConfig{
+		Duration:      400 * time.Millisecond,
+		Interval:      40 * time.Millisecond,
+		Goroutines:    1,
+		MinHeapGrowth: 64 << 10,
+		Log: func(*Logger, int) {
+			mu.Lock()
+			leaked = append(leaked, make([]byte, 1024))
+			mu.Unlock()
+			time.Sleep(10 * time.Microsecond)
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "live heap grew") {
+		t.Errorf("Expected a heap leak to be reported, got %v\n%+v", err, report.Samples)
+	}
+}
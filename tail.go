@@ -0,0 +1,169 @@
+package loggo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// TailOptions configures a Tailer.
+type TailOptions struct {
+	Follow       bool          // Keep waiting for new records at end of file
+	PollInterval time.Duration // How often to check for new data when following (default 250ms)
+}
+
+// TailRecord is a single record read by a Tailer.
+type TailRecord struct {
+	Entry  *Entry // Parsed entry, nil if Err is set
+	Offset int64  // Byte offset just after the record, usable to resume
+	Err    error  // Parse error for a malformed line
+}
+
+// Tailer reads JSON-lines log files, such as those written by
+// JSONLFileSink, and delivers parsed entries on C.
+// In follow mode it survives truncation and rotation by reopening the path.
+type Tailer struct {
+	C <-chan TailRecord // Parsed records; closed when the tailer stops
+
+	path     string
+	options  TailOptions
+	records  chan TailRecord
+	stopChan chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Tail starts reading path at fromOffset. Without Follow, C is closed once
+// the end of the file is reached; with Follow, the tailer runs until Stop.
+func Tail(path string, fromOffset int64, options TailOptions) (*Tailer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(fromOffset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = 250 * time.Millisecond
+	}
+
+	records := make(chan TailRecord, 64)
+	t := &Tailer{
+		C:        records,
+		path:     path,
+		options:  options,
+		records:  records,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.run(file, fromOffset)
+	return t, nil
+}
+
+// Stop ends tailing and waits for the reader goroutine to exit.
+// It is safe to call multiple times.
+func (t *Tailer) Stop() {
+	t.stopOnce.Do(func() { close(t.stopChan) })
+	<-t.done
+}
+
+// run reads lines and handles end of file, truncation and rotation
+func (t *Tailer) run(file *os.File, offset int64) {
+	defer close(t.done)
+	defer close(t.records)
+	defer func() { file.Close() }()
+
+	reader := bufio.NewReader(file)
+	var pending []byte // Partial line waiting for its newline
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		pending = append(pending, line...)
+		if err == nil {
+			offset += int64(len(pending))
+			if !t.emit(pending, offset) {
+				return
+			}
+			pending = pending[:0]
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			t.send(TailRecord{Offset: offset, Err: err})
+			return
+		}
+
+		if !t.options.Follow {
+			if len(pending) > 0 {
+				t.emit(pending, offset+int64(len(pending)))
+			}
+			return
+		}
+
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(t.options.PollInterval):
+		}
+
+		// Detect rotation (path now refers to a new file) or truncation
+		current, statErr := file.Stat()
+		latest, pathErr := os.Stat(t.path)
+		switch {
+		case statErr == nil && pathErr == nil && !os.SameFile(current, latest):
+			// Drain what is left of the old file before switching
+			if rest, _ := io.ReadAll(reader); len(rest) > 0 {
+				pending = append(pending, rest...)
+				for _, l := range bytes.SplitAfter(pending, []byte{'\n'}) {
+					if len(l) > 0 && l[len(l)-1] == '\n' {
+						offset += int64(len(l))
+						if !t.emit(l, offset) {
+							return
+						}
+					}
+				}
+			}
+			next, err := os.Open(t.path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file, offset, pending = next, 0, pending[:0]
+			reader.Reset(file)
+		case statErr == nil && current.Size() < offset:
+			if _, err := file.Seek(0, io.SeekStart); err == nil {
+				offset, pending = 0, pending[:0]
+				reader.Reset(file)
+			}
+		}
+	}
+}
+
+// emit parses a complete line and sends it, skipping blank lines.
+// It returns false if the tailer was stopped.
+func (t *Tailer) emit(line []byte, offset int64) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return true
+	}
+	if verifyChecksum(line) {
+		// Hide the integrity field from readers
+		line = append(line[:len(line)-checksumSuffixLen], '}')
+	}
+	entry, err := ParseJSON(line)
+	return t.send(TailRecord{Entry: entry, Offset: offset, Err: err})
+}
+
+// send delivers a record unless the tailer is stopped
+func (t *Tailer) send(record TailRecord) bool {
+	select {
+	case t.records <- record:
+		return true
+	case <-t.stopChan:
+		return false
+	}
+}
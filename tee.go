@@ -0,0 +1,76 @@
+package loggo
+
+import (
+	"io"
+	"slices"
+	"sync"
+)
+
+// TeeOptions configures a Tee. The zero value duplicates the text lines
+// unchanged.
+type TeeOptions struct {
+	StripColors bool     // Remove ANSI color codes from the text lines
+	JSON        bool     // Write entries as JSON lines instead of the text lines
+	Logfmt      bool     // Write entries as logfmt lines instead of the text lines
+	Keys        JSONKeys // Keys for the built-in attributes (default DefaultJSONKeys or DefaultLogfmtKeys)
+}
+
+// Tee duplicates the logger's output to w, e.g. to capture the records
+// of one operation in a test buffer, until the returned function is
+// called. The configured outputs and sinks are left untouched, and the
+// tee is kept when they are replaced. With JSON or Logfmt, records are
+// re-encoded as JSON or logfmt lines, including their fields, so each
+// destination can receive the encoding its consumer expects.
+func (l *Logger) Tee(w io.Writer, opts TeeOptions) (untee func()) {
+	if opts.JSON || opts.Logfmt {
+		sink := &teeSink{w: w, keys: opts.Keys, logfmt: opts.Logfmt}
+		l.AddSink(sink)
+		return func() { l.removeSink(sink) }
+	}
+
+	writer := w
+	if opts.StripColors {
+		writer = NewStripANSIWriter(w)
+	}
+	out := l.output
+	out.mu.Lock()
+	out.tees = append(out.tees, writer)
+	out.checkTerminal()
+	out.mu.Unlock()
+	return func() {
+		out.mu.Lock()
+		defer out.mu.Unlock()
+		if i := slices.Index(out.tees, writer); i >= 0 {
+			out.tees = slices.Delete(out.tees, i, i+1)
+			out.checkTerminal()
+		}
+	}
+}
+
+// teeSink writes entries to a tee as JSON or logfmt lines
+type teeSink struct {
+	w      io.Writer
+	keys   JSONKeys
+	logfmt bool
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// WriteEntry writes the entry as one line
+func (t *teeSink) WriteEntry(entry *Entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.logfmt {
+		t.buf = AppendLogfmtKeys(t.buf[:0], entry, t.keys)
+	} else {
+		t.buf = AppendJSONKeys(t.buf[:0], entry, t.keys)
+	}
+	t.buf = append(t.buf, '\n')
+	_, err := t.w.Write(t.buf)
+	return err
+}
+
+// Close does nothing; the tee's writer belongs to the caller
+func (t *teeSink) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package loggo
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Color is a foreground color of text output, as the parameters of an
+// ANSI SGR escape sequence. The empty Color leaves the text uncolored.
+type Color string
+
+// The eight basic terminal colors.
+const (
+	ColorBlack   Color = "30"
+	ColorRed     Color = "31"
+	ColorGreen   Color = "32"
+	ColorYellow  Color = "33"
+	ColorBlue    Color = "34"
+	ColorMagenta Color = "35"
+	ColorCyan    Color = "36"
+	ColorWhite   Color = "37"
+)
+
+// Color256 returns color n of the 256-color palette, e.g. Color256(208)
+// for orange.
+func Color256(n uint8) Color {
+	return Color("38;5;" + strconv.Itoa(int(n)))
+}
+
+// ColorRGB returns a 24-bit color, for terminals supporting true color.
+func ColorRGB(r, g, b uint8) Color {
+	return Color("38;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)))
+}
+
+// Style is how the colored part of a level's text lines is drawn.
+type Style struct {
+	Color     Color // Foreground color; empty for none
+	Bold      bool
+	Underline bool
+}
+
+// sequence returns the escape sequence starting the style, or "" for
+// plain text
+func (s Style) sequence() string {
+	var params []string
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Underline {
+		params = append(params, "4")
+	}
+	if s.Color != "" {
+		params = append(params, string(s.Color))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// Theme sets the look of text output per level, e.g. to make warnings
+// orange and spell out CRITICAL:
+//
+//	logger.SetTheme(loggo.Theme{
+//		Styles: map[loggo.Level]loggo.Style{loggo.WARN: {Color: loggo.Color256(208), Bold: true}},
+//		Format: &loggo.LevelFormat{Brackets: true, Labels: map[loggo.Level]string{loggo.CRITICAL: "CRITICAL"}},
+//	})
+type Theme struct {
+	Styles map[Level]Style // Styles of levels; levels not in the map keep those of DefaultTheme
+	Format *LevelFormat    // Level tags, as with SetLevelFormat; nil keeps the current tags
+}
+
+// DefaultTheme is the theme of text output by default.
+var DefaultTheme = Theme{
+	Styles: map[Level]Style{
+		DEBUG:    {Color: ColorCyan},
+		INFO:     {Color: ColorGreen},
+		WARN:     {Color: ColorYellow},
+		ERROR:    {Color: ColorRed},
+		CRITICAL: {Color: ColorRed},
+		FATAL:    {Color: ColorRed},
+		PANIC:    {Color: ColorRed},
+	},
+	Format: &DefaultLevelFormat,
+}
+
+// SetTheme sets the colors, attributes and level tags of text output.
+// The part of the line drawn in a level's style is selected with
+// SetColorScope; styles have no effect while colors are off.
+func (l *Logger) SetTheme(theme Theme) {
+	styles := maps.Clone(DefaultTheme.Styles)
+	maps.Copy(styles, theme.Styles)
+	l.themeColors.Store(styleSequences(styles))
+	if theme.Format != nil {
+		l.SetLevelFormat(*theme.Format)
+	}
+}
+
+// defaultLevelColors are the escape sequences of DefaultTheme, used until
+// SetTheme is called
+var defaultLevelColors = styleSequences(DefaultTheme.Styles)
+
+// styleSequences returns the escape sequences of the styles of every level
+func styleSequences(styles map[Level]Style) *levelTags {
+	var colors levelTags
+	for level := range colors {
+		colors[level] = styles[Level(level)].sequence()
+	}
+	return &colors
+}
+
+// levelColor returns the escape sequence starting the colored part of a
+// line at level
+func (l *Logger) levelColor(level Level) string {
+	if level < DEBUG || level > PANIC {
+		return ""
+	}
+	if colors := l.themeColors.Load(); colors != nil {
+		return colors[level]
+	}
+	return defaultLevelColors[level]
+}
@@ -0,0 +1,119 @@
+package loggo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeFormat is the layout of timestamps in text lines by default.
+const DefaultTimeFormat = "2006-01-02 15:04:05.000 MST"
+
+// TimePreset names a timestamp format for SetTimePreset.
+type TimePreset int
+
+// Timestamp presets.
+const (
+	TimeDefault     TimePreset = iota // DefaultTimeFormat, e.g. "2024-05-01 14:03:07.123 UTC"
+	TimeRFC3339                       // "2024-05-01T14:03:07Z"
+	TimeRFC3339Nano                   // "2024-05-01T14:03:07.123456789Z"
+	TimeISO8601                       // ISO 8601 with milliseconds, "2024-05-01T14:03:07.123Z"
+	TimeKitchen                       // "2:03PM"
+	TimeUnixMillis                    // Milliseconds since the Unix epoch, "1714572187123"
+)
+
+// iso8601Layout is the layout of TimeISO8601
+const iso8601Layout = "2006-01-02T15:04:05.000Z07:00"
+
+// timePresetLayouts are the layouts of the presets; TimeUnixMillis has none
+var timePresetLayouts = map[TimePreset]string{
+	TimeDefault:     DefaultTimeFormat,
+	TimeRFC3339:     time.RFC3339,
+	TimeRFC3339Nano: time.RFC3339Nano,
+	TimeISO8601:     iso8601Layout,
+	TimeKitchen:     time.Kitchen,
+}
+
+// timePresetNames are the names of the presets
+var timePresetNames = map[TimePreset]string{
+	TimeDefault:     "Default",
+	TimeRFC3339:     "RFC3339",
+	TimeRFC3339Nano: "RFC3339Nano",
+	TimeISO8601:     "ISO8601",
+	TimeKitchen:     "Kitchen",
+	TimeUnixMillis:  "UnixMillis",
+}
+
+// String returns the name of the preset.
+func (p TimePreset) String() string {
+	if name, ok := timePresetNames[p]; ok {
+		return name
+	}
+	return "TimePreset(" + strconv.Itoa(int(p)) + ")"
+}
+
+// unixMillisFormat is the time format reported for TimeUnixMillis
+const unixMillisFormat = "UnixMillis"
+
+// SetTimePreset selects a named timestamp format.
+// It returns an error for unknown presets.
+func (l *Logger) SetTimePreset(preset TimePreset) error {
+	if preset == TimeUnixMillis {
+		l.timeCache.Store(&timeCache{format: unixMillisFormat, subSecond: true, unixMillis: true})
+		return nil
+	}
+	layout, ok := timePresetLayouts[preset]
+	if !ok {
+		return fmt.Errorf("loggo: unknown time preset %v", preset)
+	}
+	return l.SetTimeFormat(layout)
+}
+
+// timeCache is the time format of a logger with the formatted timestamp
+// of the last second seen. It is immutable once published: updates swap
+// in a new one.
+type timeCache struct {
+	format     string
+	subSecond  bool   // The format shows fractions of a second, so is not cached
+	unixMillis bool   // Timestamps are Unix milliseconds
+	second     int64  // Unix second of value
+	value      string // Formatted timestamp of second
+}
+
+// newTimeCache returns an empty cache for a layout
+func newTimeCache(format string) *timeCache {
+	return &timeCache{format: format, subSecond: subSecondFormat(format), second: math.MinInt64}
+}
+
+// timeCheck is an arbitrary instant used to validate layouts
+var timeCheck = time.Date(2001, 2, 3, 4, 5, 6, 789000000, time.UTC)
+
+// validateTimeFormat reports layouts that are not Go reference time
+// layouts, such as strftime patterns
+func validateTimeFormat(format string) error {
+	hint := "layouts are written with Go's reference time, e.g. \"2006-01-02 15:04:05\", or use SetTimePreset"
+	switch {
+	case strings.TrimSpace(format) == "":
+		return fmt.Errorf("loggo: empty time format; %s", hint)
+	case strings.Contains(format, "%"):
+		return fmt.Errorf("loggo: time format %q looks like a strftime pattern; %s", format, hint)
+	case strings.Contains(format, "YYYY") || strings.Contains(format, "yyyy"):
+		return fmt.Errorf("loggo: time format %q uses YYYY-style placeholders; %s", format, hint)
+	case timeCheck.Format(format) == format:
+		return fmt.Errorf("loggo: time format %q contains no time elements; %s", format, hint)
+	}
+	return nil
+}
+
+// subSecondFormat reports whether a layout shows fractions of a second,
+// so timestamps cannot be cached per second
+func subSecondFormat(format string) bool {
+	return timeCheck.Format(format) != timeCheck.Truncate(time.Second).Format(format)
+}
+
+// appendUnixMillis appends the time as milliseconds since the Unix epoch
+func appendUnixMillis(buf []byte, t time.Time) []byte {
+	return appendInt(buf, t.UnixMilli())
+}
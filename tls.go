@@ -0,0 +1,77 @@
+package loggo
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig describes the TLS settings of a network sink.
+// Files are read when the sink is created, so misconfiguration is
+// reported immediately rather than on the first delivery.
+type TLSConfig struct {
+	CAFile             string   // PEM bundle of trusted CAs (default: system roots)
+	CertFile           string   // PEM client certificate for mutual TLS
+	KeyFile            string   // PEM private key for CertFile
+	ServerName         string   // Overrides the name used for verification
+	PinnedKeys         []string // Base64 SHA-256 of accepted SubjectPublicKeyInfo ("sha256/..." prefix optional)
+	InsecureSkipVerify bool     // Disable chain verification; pins are still enforced
+}
+
+// Build returns the *tls.Config described by c.
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		data, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("loggo: reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("loggo: no certificates found in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loggo: loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(c.PinnedKeys) > 0 {
+		pins := make(map[string]bool, len(c.PinnedKeys))
+		for _, pin := range c.PinnedKeys {
+			pins[strings.TrimPrefix(pin, "sha256/")] = true
+		}
+		cfg.VerifyConnection = func(state tls.ConnectionState) error {
+			for _, cert := range state.PeerCertificates {
+				if pins[PublicKeyPin(cert)] {
+					return nil
+				}
+			}
+			return errors.New("loggo: server certificate does not match any pinned key")
+		}
+	}
+
+	return cfg, nil
+}
+
+// PublicKeyPin returns the base64 SHA-256 digest of the certificate's
+// SubjectPublicKeyInfo, the format expected by TLSConfig.PinnedKeys.
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
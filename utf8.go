@@ -0,0 +1,67 @@
+package loggo
+
+import "unicode/utf8"
+
+// UTF8Policy controls what happens to invalid UTF-8 in messages, field
+// keys and string field values, e.g. when raw binary data is logged.
+type UTF8Policy int
+
+// UTF-8 policies.
+const (
+	UTF8Replace UTF8Policy = iota // Replace each invalid byte with U+FFFD (the default)
+	UTF8Escape                    // Write each invalid byte as \xNN, so the data can be recovered
+	UTF8Keep                      // Pass bytes through unchanged
+)
+
+// Apply returns s with invalid UTF-8 handled according to the policy.
+// Valid strings are returned unchanged without allocating.
+func (p UTF8Policy) Apply(s string) string {
+	if p == UTF8Keep || utf8.ValidString(s) {
+		return s
+	}
+	return string(appendValidUTF8(make([]byte, 0, len(s)+8), s, p))
+}
+
+// appendValidUTF8 appends s to buf with invalid bytes replaced or
+// escaped according to policy
+func appendValidUTF8(buf []byte, s string, policy UTF8Policy) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != utf8.RuneError || size != 1 {
+			i += size
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		if policy == UTF8Escape {
+			buf = append(buf, '\\', 'x', hexDigits[s[i]>>4], hexDigits[s[i]&0xf])
+		} else {
+			buf = append(buf, "\ufffd"...)
+		}
+		i++
+		start = i
+	}
+	return append(buf, s[start:]...)
+}
+
+// SetUTF8Policy sets how invalid UTF-8 in messages and fields is handled
+// before records reach the text output, sinks and hooks. By default each
+// invalid byte is replaced with U+FFFD, so every encoder produces valid
+// documents.
+func (l *Logger) SetUTF8Policy(policy UTF8Policy) {
+	l.utf8Policy.Store(int32(policy))
+}
+
+// validateMessage applies the UTF-8 policy to the message written to the
+// buffer from start on
+func (l *Logger) validateMessage(buf []byte, start int) []byte {
+	policy := UTF8Policy(l.utf8Policy.Load())
+	if policy == UTF8Keep || utf8.Valid(buf[start:]) {
+		return buf
+	}
+	return appendValidUTF8(buf[:start], string(buf[start:]), policy)
+}
@@ -0,0 +1,84 @@
+package loggo
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Keys of the fields added by WithError.
+const (
+	ErrorKey      = "error"       // Message of the error
+	ErrorKindKey  = "error.kind"  // Go type of the error under fmt.Errorf wrapping, e.g. "*fs.PathError"
+	ErrorStackKey = "error.stack" // Stack of the WithError call, one "function file:line" frame per line
+)
+
+// maxStackFrames is the number of frames recorded by WithError
+const maxStackFrames = 32
+
+// WithError returns a logger whose records carry err: its message, its
+// kind and the stack WithError was called from, as in logrus:
+//
+//	logger.WithError(err).Error("upload failed")
+//
+// The stack is captured right away but only formatted for records that
+// are written. A nil err returns l itself.
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l
+	}
+	var pcs [maxStackFrames]uintptr
+	n := runtime.Callers(2, pcs[:])
+	stack := pcs[:n:n]
+
+	bound := make([]Field, len(l.bound), len(l.bound)+3)
+	copy(bound, l.bound)
+	bound = append(bound,
+		Str(ErrorKey, err.Error()),
+		Str(ErrorKindKey, errorKind(err)),
+		LazyStr(ErrorStackKey, func() string { return formatStack(stack) }),
+	)
+	return &Logger{loggerCore: l.loggerCore, bound: bound, callerSkip: l.callerSkip}
+}
+
+// errorKind returns the type of err, looking through the wrapping added
+// by fmt.Errorf, which says nothing about the kind of failure
+func errorKind(err error) string {
+	for {
+		kind := fmt.Sprintf("%T", err)
+		if kind != "*fmt.wrapError" {
+			return kind
+		}
+		err = errors.Unwrap(err)
+	}
+}
+
+// formatStack formats the frames of a stack, one "function file:line"
+// per line, leaving out the frames of this package it starts with
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	inPackage := true
+	for {
+		frame, more := frames.Next()
+		if inPackage {
+			inPackage = filepath.Dir(frame.File) == packageDir && !strings.HasSuffix(frame.File, "_test.go")
+		}
+		if frame.Function != "" && !inPackage {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(frame.Function)
+			b.WriteByte(' ')
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}